@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/backtest"
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+	"github.com/febritecno/stockmap-cli/internal/universe"
+	"github.com/febritecno/stockmap-cli/internal/watchlist"
+)
+
+var (
+	btStrategy     string
+	btFrom         string
+	btTo           string
+	btSymbols      string
+	btUniverse     string
+	btWatchlist    bool
+	btCapital      float64
+	btPositionPct  float64
+	btMaxPositions int
+	btMaxHold      int
+	btWindow       int
+	btMinScore     float64
+	btWhere        string
+	btFundamentals bool
+	btOffline      bool
+	btTrades       bool
+	btFormat       string
+)
+
+// backtestCmd replays a strategy over historical bars
+var backtestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Replay a strategy over historical bars",
+	Long: `Replay daily bars from --from to --to through a strategy preset. Each day the
+metrics are calculated on the trailing window of every symbol without an open
+position; those passing the strategy's criteria are bought at the next open,
+best confluence scores first, with the strategy's ATR stop loss and take
+profit. A bar reaching both levels counts as stopped out. Positions still open
+at the end are closed at the last close.
+
+Bars come from the price history cache, refreshed from the provider, or only
+from the cache with --offline, or from recordings with --replay. Without
+--fundamentals valuation is left out of the score, since only today's
+fundamentals are available.
+
+The report shows total return, CAGR, max drawdown, Sharpe ratio, win rate and
+profit factor; --trades lists every trade and --format json prints the whole
+report, including the equity curve.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if btFormat != "table" && btFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (available: table, json)\n", btFormat)
+			os.Exit(1)
+		}
+		opts, err := backtestOptions()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if cmd.Flags().Changed("min-score") {
+			opts.Criteria.MinConfluence = btMinScore
+		}
+		if btWhere != "" {
+			src := btWhere
+			if base := opts.Criteria.Expression; base != nil {
+				src = "(" + base.String() + ") and (" + btWhere + ")"
+			}
+			if opts.Criteria.Expression, err = screener.Compile(src); err != nil {
+				printExprError(btWhere, err)
+				os.Exit(1)
+			}
+		}
+		series, err := loadBacktestSeries(cmd, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		report, err := backtest.Run(series, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr)
+
+		if btFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printBacktestReport(report)
+		if btTrades {
+			fmt.Println()
+			printTrades(report.Trades)
+		}
+	},
+}
+
+// activeStrategy looks up a strategy by name; without one it is the
+// strategy in settings.json, else the default
+func activeStrategy(name string) (*screener.Strategy, error) {
+	if name == "" {
+		if s, err := config.Load(); err == nil && s.Scan.Strategy != "" {
+			name = s.Scan.Strategy
+		}
+	}
+	if name == "" {
+		return screener.DefaultStrategy(), nil
+	}
+	return screener.LookupStrategy(name)
+}
+
+// backtestOptions builds the backtest options from the flags shared by
+// backtest and optimize
+func backtestOptions() (backtest.Options, error) {
+	if err := screener.StrategyLoadError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if err := screener.ScoringModelLoadError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (builtin scoring model used)\n", err)
+	}
+	strategy, err := activeStrategy(btStrategy)
+	if err != nil {
+		return backtest.Options{}, err
+	}
+	if btOffline && btFundamentals {
+		return backtest.Options{}, fmt.Errorf("--fundamentals needs the provider and can't be combined with --offline")
+	}
+
+	opts := backtest.DefaultOptions(strategy)
+	if err := backtestDates(&opts); err != nil {
+		return opts, err
+	}
+	opts.Capital, opts.PositionPct, opts.MaxPositions = btCapital, btPositionPct, btMaxPositions
+	opts.MaxHoldBars, opts.Window = btMaxHold, btWindow
+	return opts, nil
+}
+
+// loadBacktestSeries validates the options and loads the bars of the
+// selected symbols, from the cache only with --offline
+func loadBacktestSeries(cmd *cobra.Command, opts backtest.Options) ([]backtest.Series, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	symbols, source, err := backtestSymbols(cmd)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "%s (%d symbols)\n", source, len(symbols))
+	fmt.Fprintf(os.Stderr, "Strategy: %s, %s to %s\n", opts.Strategy.Name, opts.From.Format("2006-01-02"), opts.To.Format("2006-01-02"))
+
+	var series []backtest.Series
+	var failed map[string]error
+	if btOffline {
+		series, failed = backtest.LoadCached(fetcher.NewBarCache(fetcher.DefaultCacheDir()), symbols, opts)
+	} else {
+		provider, err := fetcher.NewDefaultProvider()
+		if err != nil {
+			return nil, err
+		}
+		defer provider.Close()
+		series, failed = backtest.Load(context.Background(), provider, symbols, opts, btFundamentals)
+	}
+	for _, symbol := range sortedKeys(failed) {
+		fmt.Fprintf(os.Stderr, "Skipped %s: %v\n", symbol, failed[symbol])
+	}
+	return series, nil
+}
+
+// backtestDates parses --from and --to (YYYY-MM-DD) into the options
+func backtestDates(opts *backtest.Options) error {
+	for _, d := range []struct {
+		flag, value string
+		dst         *time.Time
+	}{{"--from", btFrom, &opts.From}, {"--to", btTo, &opts.To}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", d.value)
+		if err != nil {
+			return fmt.Errorf("%s: expected a date like 2024-01-31, got %q", d.flag, d.value)
+		}
+		*d.dst = t
+	}
+	return nil
+}
+
+// backtestSymbols returns the symbols selected by --symbols, --universe or
+// --watchlist (default: the active universe) and a description of the source
+func backtestSymbols(cmd *cobra.Command) ([]string, string, error) {
+	set := 0
+	for _, name := range []string{"symbols", "universe", "watchlist"} {
+		if cmd.Flags().Changed(name) {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, "", fmt.Errorf("--symbols, --universe and --watchlist cannot be combined")
+	}
+
+	switch {
+	case cmd.Flags().Changed("symbols"):
+		symbols := parseSymbols(btSymbols)
+		if len(symbols) == 0 {
+			return nil, "", fmt.Errorf("no symbols given")
+		}
+		return symbols, "Symbols", nil
+	case btWatchlist:
+		symbols := watchlist.NewManager("").GetAll()
+		if len(symbols) == 0 {
+			return nil, "", fmt.Errorf("watchlist is empty")
+		}
+		return symbols, "Watchlist", nil
+	case cmd.Flags().Changed("universe"):
+		u, err := universe.Load(btUniverse)
+		if err != nil {
+			return nil, "", err
+		}
+		return u.Symbols(), "Universe: " + u.Name, nil
+	default:
+		u := universe.Active()
+		return u.Symbols(), "Universe: " + u.Name, nil
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printBacktestReport prints the summary statistics of a backtest
+func printBacktestReport(r *backtest.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Strategy\t%s\n", r.Strategy)
+	fmt.Fprintf(w, "Period\t%s to %s (%d trading days)\n", r.From.Format("2006-01-02"), r.To.Format("2006-01-02"), len(r.Equity))
+	fmt.Fprintf(w, "Symbols\t%d\n", r.Symbols)
+	fmt.Fprintf(w, "Equity\t%.2f → %.2f\n", r.Capital, r.FinalEquity)
+	fmt.Fprintf(w, "Total return\t%+.2f%%\n", r.TotalReturn)
+	fmt.Fprintf(w, "CAGR\t%+.2f%%\n", r.CAGR)
+	fmt.Fprintf(w, "Max drawdown\t%.2f%%\n", r.MaxDrawdown)
+	fmt.Fprintf(w, "Sharpe\t%.2f\n", r.Sharpe)
+	fmt.Fprintf(w, "Trades\t%d (%d won, %d lost)\n", len(r.Trades), r.Wins, r.Losses)
+	fmt.Fprintf(w, "Win rate\t%.1f%%\n", r.WinRate)
+	if r.ProfitFactor > 0 {
+		fmt.Fprintf(w, "Profit factor\t%.2f\n", r.ProfitFactor)
+	} else {
+		fmt.Fprintf(w, "Profit factor\t-\n")
+	}
+	w.Flush()
+}
+
+// printTrades prints the trade list of a backtest
+func printTrades(trades []backtest.Trade) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSCORE\tENTRY\tPRICE\tSHARES\tEXIT\tPRICE\tREASON\tBARS\tRETURN\tP&L")
+	for _, t := range trades {
+		fmt.Fprintf(w, "%s\t%.1f\t%s\t%.2f\t%d\t%s\t%.2f\t%s\t%d\t%+.2f%%\t%+.2f\n",
+			t.Symbol, t.Score, t.EntryTime.Format("2006-01-02"), t.EntryPrice, t.Shares,
+			t.ExitTime.Format("2006-01-02"), t.ExitPrice, t.Reason, t.Bars, t.ReturnPct, t.PnL)
+	}
+	w.Flush()
+}
+
+// addBacktestFlags registers the flags backtest and optimize share
+func addBacktestFlags(c *cobra.Command) {
+	c.Flags().StringVar(&btStrategy, "strategy", "", "Strategy preset (default from settings, else Deep Value)")
+	c.Flags().StringVar(&btFrom, "from", "", "First day, YYYY-MM-DD (default: a year ago)")
+	c.Flags().StringVar(&btTo, "to", "", "Last day, YYYY-MM-DD (default: today)")
+	c.Flags().StringVar(&btSymbols, "symbols", "", "Use these symbols (comma-separated) instead of the universe")
+	c.Flags().StringVar(&btUniverse, "universe", "", "Use this universe instead of the active one")
+	c.Flags().BoolVar(&btWatchlist, "watchlist", false, "Use the watchlist symbols")
+	c.Flags().Float64Var(&btCapital, "capital", 100000, "Starting equity")
+	c.Flags().Float64Var(&btPositionPct, "position-pct", 10, "Percent of equity put into each new position")
+	c.Flags().IntVar(&btMaxPositions, "max-positions", 10, "Most positions held at the same time")
+	c.Flags().IntVar(&btMaxHold, "max-hold", 0, "Close positions after this many bars (0 = hold until stop or target)")
+	c.Flags().IntVar(&btWindow, "window", 0, "Trailing bars the metrics are calculated on (0 = what the indicators need)")
+	c.Flags().BoolVar(&btFundamentals, "fundamentals", false, "Score valuation with today's fundamentals (look-ahead bias)")
+	c.Flags().BoolVar(&btOffline, "offline", false, "Only use bars already in the price history cache")
+}
+
+func init() {
+	addBacktestFlags(backtestCmd)
+	backtestCmd.Flags().Float64Var(&btMinScore, "min-score", 0, "Minimum confluence score (default from the strategy)")
+	backtestCmd.Flags().StringVar(&btWhere, "where", "", "Screening expression entries must also satisfy")
+	backtestCmd.Flags().BoolVar(&btTrades, "trades", false, "List every trade")
+	backtestCmd.Flags().StringVar(&btFormat, "format", "table", "Output format: table or json")
+	rootCmd.AddCommand(backtestCmd)
+}
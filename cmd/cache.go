@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+)
+
+var pruneOlderThan time.Duration
+
+// cacheCmd groups the price history cache commands
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the on-disk price history cache",
+	Long: `Scans keep daily OHLCV bars in config/cache, one file per symbol and interval.
+Later scans only download bars newer than the last cached one.`,
+}
+
+// cacheStatsCmd prints cache statistics
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache statistics",
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := fetcher.NewBarCache(fetcher.DefaultCacheDir()).Stats()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Directory:     %s\n", stats.Dir)
+		fmt.Printf("Series:        %d (%d bars)\n", stats.Series, stats.Bars)
+		fmt.Printf("Fundamentals:  %d\n", stats.FundamentalFiles)
+		fmt.Printf("Size:          %s\n", formatBytes(stats.Bytes))
+		if !stats.Newest.IsZero() {
+			fmt.Printf("Last updated:  %s\n", stats.Newest.Format("2006-01-02 15:04"))
+			fmt.Printf("Oldest entry:  %s\n", stats.Oldest.Format("2006-01-02 15:04"))
+		}
+	},
+}
+
+// cachePruneCmd removes entries that haven't been refreshed recently
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cache entries not updated recently",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := fetcher.NewBarCache(fetcher.DefaultCacheDir()).Prune(pruneOlderThan)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %d entries not updated in %s\n", removed, pruneOlderThan)
+	},
+}
+
+// cacheClearCmd removes every cache entry
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cache entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := fetcher.NewBarCache(fetcher.DefaultCacheDir()).Clear()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %d entries\n", removed)
+	},
+}
+
+// formatBytes formats a byte count as B/KB/MB
+func formatBytes(n int64) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	if n >= 1<<10 {
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+func init() {
+	cachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 30*24*time.Hour, "Remove entries not updated within this duration")
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
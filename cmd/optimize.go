@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/backtest"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+var (
+	optSearch    string
+	optSamples   int
+	optSeed      int64
+	optFolds     int
+	optInSample  int
+	optObjective string
+	optMinTrades int
+	optParams    []string
+	optName      string
+	optNoSave    bool
+)
+
+// optimizeCmd tunes a strategy's indicator parameters walk-forward
+var optimizeCmd = &cobra.Command{
+	Use:   "optimize",
+	Short: "Tune a strategy's indicator parameters walk-forward",
+	Long: `Search a strategy's RSI period, Bollinger bands, MACD and SL/TP multipliers
+with backtests (see "stockmap backtest"). The period is split into --folds
+out-of-sample windows, each preceded by an in-sample window --in-sample times
+as long. On each in-sample window every combination is backtested and the best
+by --objective (sharpe, cagr or return) is then tested on the following
+out-of-sample window, so the reported out-of-sample results are for
+parameters chosen without seeing that data.
+
+--search random (default) tries --samples random combinations, --search grid
+all of them. --param replaces the values searched for one parameter, e.g.
+--param rsi_period=7,10,14 --param stop_loss_atr=1.5,2,3; the defaults are
+printed with the results. The strategy's own values are always tried too.
+
+The stability table shows how often each parameter's most chosen value was
+picked across folds. The winning combination, chosen on the latest in-sample
+window, is saved to config/strategies.yaml as a new preset ("<strategy>
+(optimised)" unless --name is given; --no-save skips this).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		oo := backtest.DefaultOptimizeOptions()
+		oo.Search, oo.Samples, oo.Seed = optSearch, optSamples, optSeed
+		oo.Folds, oo.InSample, oo.Objective, oo.MinTrades = optFolds, optInSample, optObjective, optMinTrades
+		if len(optParams) > 0 {
+			oo.Params = nil
+			for _, spec := range optParams {
+				p, err := backtest.ParseParam(spec)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: --param: %v\n", err)
+					os.Exit(1)
+				}
+				oo.Params = append(oo.Params, p)
+			}
+		}
+		if err := oo.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts, err := backtestOptions()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		series, err := loadBacktestSeries(cmd, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, p := range oo.Params {
+			fmt.Fprintf(os.Stderr, "Searching %s: %s\n", p.Name, formatValues(p.Values))
+		}
+
+		result, err := backtest.Optimize(series, opts, oo, func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rBacktest %d/%d        ", done, total)
+		})
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr)
+		printOptimization(result, oo)
+
+		if optNoSave {
+			return
+		}
+		tuned := *opts.Strategy
+		tuned.Name = optName
+		if tuned.Name == "" {
+			tuned.Name = opts.Strategy.Name + " (optimised)"
+		}
+		tuned.Description = fmt.Sprintf("%s walk-forward optimised for %s, %s to %s", opts.Strategy.Name,
+			oo.Objective, opts.From.Format("2006-01-02"), opts.To.Format("2006-01-02"))
+		tuned.Indicators = result.Indicators
+		path, err := screener.SaveStrategy(&tuned)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nSaved strategy %q to %s; use it with --strategy %q\n", tuned.Name, path, tuned.Name)
+	},
+}
+
+// printOptimization prints the folds, stability and winner of an optimisation
+func printOptimization(r *backtest.Optimization, oo backtest.OptimizeOptions) {
+	fmt.Printf("Walk-forward: %d folds, in-sample %d× out-of-sample, %d combinations (%s search), maximising %s\n\n",
+		len(r.Folds), oo.InSample, r.Candidates, oo.Search, r.Objective)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FOLD\tIN-SAMPLE\tOUT-OF-SAMPLE\tIN\tOUT\tOWN\tRETURN\tTRADES\tBEST")
+	for _, f := range r.Folds {
+		fmt.Fprintf(w, "%d\t%s..%s\t%s..%s\t%.2f\t%.2f\t%.2f\t%+.2f%%\t%d\t%s\n", f.Number,
+			f.InFrom.Format("2006-01-02"), f.InTo.Format("2006-01-02"),
+			f.OutFrom.Format("2006-01-02"), f.OutTo.Format("2006-01-02"),
+			f.InObjective, f.OutObjective, f.BaseObjective, f.OutReturn, f.OutTrades, f.Best)
+	}
+	w.Flush()
+	fmt.Println("(IN/OUT: objective of the best combination; OWN: the strategy's own values out of sample)")
+	for _, f := range r.Skipped {
+		fmt.Printf("Skipped fold %d (%s..%s, out of sample %s..%s): %s\n", f.Number,
+			f.InFrom.Format("2006-01-02"), f.InTo.Format("2006-01-02"),
+			f.OutFrom.Format("2006-01-02"), f.OutTo.Format("2006-01-02"), f.Reason)
+	}
+
+	fmt.Printf("\nOut-of-sample return: %+.2f%% (own values: %+.2f%%)\n", r.OutReturn, r.BaseReturn)
+	if r.Efficiency != 0 {
+		fmt.Printf("Walk-forward efficiency: %.2f (mean out-of-sample %s %.2f)\n", r.Efficiency, r.Objective, r.OutObjective)
+	}
+
+	if len(r.Stability) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PARAMETER\tCHOSEN\tMODE\tSTABILITY\tSPREAD")
+		for _, s := range r.Stability {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.0f%%\t%.0f%%\n", s.Name, formatValues(s.Chosen),
+				strconv.FormatFloat(s.Mode, 'f', -1, 64), s.ModeShare, s.Spread)
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("\nWinner on the latest in-sample window (%s %.2f): %s\n", r.Objective, r.WinnerScore, r.Winner)
+}
+
+// formatValues joins parameter values with commas
+func formatValues(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+func init() {
+	addBacktestFlags(optimizeCmd)
+	optimizeCmd.Flags().StringVar(&optSearch, "search", backtest.SearchRandom, "Search method: random or grid")
+	optimizeCmd.Flags().IntVar(&optSamples, "samples", 50, "Combinations tried by a random search")
+	optimizeCmd.Flags().Int64Var(&optSeed, "seed", 1, "Random search seed")
+	optimizeCmd.Flags().IntVar(&optFolds, "folds", 4, "Out-of-sample windows")
+	optimizeCmd.Flags().IntVar(&optInSample, "in-sample", 3, "In-sample window length, in out-of-sample windows")
+	optimizeCmd.Flags().StringVar(&optObjective, "objective", backtest.ObjectiveSharpe, "What to maximise: sharpe, cagr or return")
+	optimizeCmd.Flags().IntVar(&optMinTrades, "min-trades", 5, "Prefer combinations with at least this many in-sample trades")
+	optimizeCmd.Flags().StringArrayVar(&optParams, "param", nil, "Values to search for a parameter, e.g. rsi_period=7,14,21 (repeatable)")
+	optimizeCmd.Flags().StringVar(&optName, "name", "", "Name of the saved preset (default: \"<strategy> (optimised)\")")
+	optimizeCmd.Flags().BoolVar(&optNoSave, "no-save", false, "Don't save the winning parameters as a preset")
+	rootCmd.AddCommand(optimizeCmd)
+}
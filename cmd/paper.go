@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/paper"
+)
+
+var (
+	paperCapital      float64
+	paperMinScore     float64
+	paperMaxPositions int
+	paperRisk         float64
+	paperMaxPosition  float64
+	paperNoAlerts     bool
+	paperTrades       bool
+	paperEvents       int
+	paperFormat       string
+	paperYes          bool
+)
+
+// paperCmd groups the paper trading commands
+var paperCmd = &cobra.Command{
+	Use:   "paper",
+	Short: "Forward-test the strategy with simulated trades",
+	Long: `Paper trading turns signals into simulated trades, without a broker. While it
+is on, every scan or reload in the TUI buys, at the scan's price (TUI scans and
+reloads only; "stockmap scan" places no paper trades):
+
+  - the symbols of triggered price and RSI alerts
+  - the symbols whose confluence score reached --min-score since the last
+    reload (on the first reload, every symbol at or above it)
+
+Each trade risks --risk percent of the paper equity down to the computed stop
+loss, capped to --max-position percent of equity and to the cash left, and
+keeps the stop loss and take profit it was entered with. Later reloads close
+it at their price once it is at or below the stop loss or at or above the
+take profit. Trades, skipped signals and the equity after each reload are
+journaled to config/paper.json; "report" shows the resulting performance.`,
+}
+
+// paperStartCmd turns paper trading on
+var paperStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Turn paper trading on (or resume it)",
+	Long: `Turn paper trading on. A new journal starts with the capital and risk limits
+of the "account" in settings.json (see "stockmap size"), or a capital of
+100000, unless the flags say otherwise. A journal with trades resumes with its
+settings; to change them, "reset" it first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		m := openPaper()
+		var settings *paper.Settings
+		changed := false
+		for _, name := range []string{"capital", "min-score", "max-positions", "risk", "max-position", "no-alerts"} {
+			changed = changed || cmd.Flags().Changed(name)
+		}
+		if changed || !m.Started() {
+			s := m.Journal().Settings
+			if !m.Started() {
+				cfg, err := config.Load()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				s = paper.DefaultSettings(cfg.Account)
+			}
+			if cmd.Flags().Changed("capital") {
+				s.Capital = paperCapital
+			}
+			if cmd.Flags().Changed("min-score") {
+				s.MinScore = paperMinScore
+			}
+			if cmd.Flags().Changed("max-positions") {
+				s.MaxPositions = paperMaxPositions
+			}
+			if cmd.Flags().Changed("risk") {
+				s.RiskPercent = paperRisk
+			}
+			if cmd.Flags().Changed("max-position") {
+				s.MaxPositionPercent = paperMaxPosition
+			}
+			if cmd.Flags().Changed("no-alerts") {
+				s.Alerts = !paperNoAlerts
+			}
+			settings = &s
+		}
+
+		if err := m.Start(settings, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		j := m.Journal()
+		fmt.Println(j.Events[len(j.Events)-1].Message)
+		fmt.Println("Trades are placed on TUI scans and reloads only (press R, or T for auto-reload), not by \"stockmap scan\".")
+	},
+}
+
+// paperStopCmd turns paper trading off
+var paperStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Turn paper trading off; open trades are kept",
+	Run: func(cmd *cobra.Command, args []string) {
+		m := openPaper()
+		if err := m.Stop(time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Stopped paper trading. Open trades are managed again after \"stockmap paper start\".")
+	},
+}
+
+// paperReportCmd prints the forward-tested performance
+var paperReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show the paper trading performance",
+	Long: `Show the paper account's equity, return, drawdown, win rate and profit factor,
+and the open trades at the price of the last reload. --trades lists the
+closed trades, --events the latest journal entries and --format json prints
+the whole report.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if paperFormat != "table" && paperFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (available: table, json)\n", paperFormat)
+			os.Exit(1)
+		}
+		m := openPaper()
+		if !m.Started() {
+			fmt.Println("Paper trading has not been started. Start it with \"stockmap paper start\".")
+			return
+		}
+		j := m.Journal()
+		r := j.Report()
+
+		if paperFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(r); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printPaperReport(r)
+		fmt.Println()
+		printPaperTrades("Open trades", r.Open)
+		if paperTrades {
+			fmt.Println()
+			printPaperTrades("Closed trades", r.Closed)
+		}
+		if paperEvents > 0 {
+			fmt.Println()
+			printPaperEvents(j.Events, paperEvents)
+		}
+	},
+}
+
+// paperResetCmd clears the journal
+var paperResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete every paper trade and start over",
+	Run: func(cmd *cobra.Command, args []string) {
+		m := openPaper()
+		if !paperYes {
+			fmt.Fprintf(os.Stderr, "Error: this deletes %d paper trade(s) from %s; pass --yes to confirm\n",
+				len(m.Journal().Trades), m.Path())
+			os.Exit(1)
+		}
+		if err := m.Reset(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Paper trading journal cleared.")
+	},
+}
+
+// openPaper opens the paper trading journal, exiting when its file can't be
+// read
+func openPaper() *paper.Manager {
+	m := paper.NewManager("")
+	if err := m.LoadError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// printPaperReport prints the summary statistics of the paper account
+func printPaperReport(r paper.Report) {
+	state := "off"
+	if r.Active {
+		state = "on"
+	}
+	updated := "no reload yet"
+	if !r.Updated.IsZero() {
+		updated = "last " + r.Updated.Format("2006-01-02 15:04")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Paper trading\t%s since %s (%d reloads, %s)\n", state, r.Started.Format("2006-01-02"), r.Reloads, updated)
+	fmt.Fprintf(w, "Rules\tmin score %g, alerts %v, up to %d positions, risk %g%%, max position %g%%\n",
+		r.Settings.MinScore, r.Settings.Alerts, r.Settings.MaxPositions, r.Settings.RiskPercent, r.Settings.MaxPositionPercent)
+	fmt.Fprintf(w, "Equity\t%.2f → %.2f (cash %.2f)\n", r.Settings.Capital, r.Equity, r.Cash)
+	fmt.Fprintf(w, "Total return\t%+.2f%%\n", r.TotalReturn)
+	fmt.Fprintf(w, "Realised\t%+.2f\n", r.Realized)
+	fmt.Fprintf(w, "Unrealised\t%+.2f (at risk %.2f)\n", r.Unrealized, r.OpenRisk)
+	fmt.Fprintf(w, "Max drawdown\t%.2f%%\n", r.MaxDrawdown)
+	fmt.Fprintf(w, "Closed trades\t%d (%d won, %d lost; %d at target, %d stopped)\n",
+		len(r.Closed), r.Wins, r.Losses, r.Targets, r.Stops)
+	fmt.Fprintf(w, "Win rate\t%.1f%%\n", r.WinRate)
+	fmt.Fprintf(w, "Avg win / loss\t%+.2f%% / %+.2f%%\n", r.AvgWin, r.AvgLoss)
+	if r.ProfitFactor > 0 {
+		fmt.Fprintf(w, "Profit factor\t%.2f\n", r.ProfitFactor)
+	} else {
+		fmt.Fprintf(w, "Profit factor\t-\n")
+	}
+	w.Flush()
+}
+
+// printPaperTrades prints paper trades; open ones at their last price
+func printPaperTrades(title string, trades []paper.Trade) {
+	fmt.Printf("%s:\n", title)
+	if len(trades) == 0 {
+		fmt.Println("  none")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSOURCE\tENTRY\tPRICE\tSHARES\tSL\tTP\tEXIT\tPRICE\tREASON\tRETURN\tP&L")
+	for _, t := range trades {
+		exit, price, reason := "open", t.LastPrice, "-"
+		if !t.Open() {
+			exit, price, reason = t.ExitTime.Format("2006-01-02"), t.ExitPrice, t.ExitReason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%d\t%.2f\t%.2f\t%s\t%.2f\t%s\t%+.2f%%\t%+.2f\n",
+			t.Symbol, t.Source, t.EntryTime.Format("2006-01-02"), t.EntryPrice, t.Shares, t.StopLoss,
+			t.TakeProfit, exit, price, reason, t.ReturnPct(), t.PnL())
+	}
+	w.Flush()
+}
+
+// printPaperEvents prints the last n journal events
+func printPaperEvents(events []paper.Event, n int) {
+	if len(events) > n {
+		events = events[len(events)-n:]
+	}
+	fmt.Println("Journal:")
+	for _, e := range events {
+		fmt.Printf("  %s  %-5s  %s\n", e.Time.Format("2006-01-02 15:04"), e.Kind, e.Message)
+	}
+}
+
+func init() {
+	paperStartCmd.Flags().Float64Var(&paperCapital, "capital", 0, "Starting paper equity (default: the account equity, else 100000)")
+	paperStartCmd.Flags().Float64Var(&paperMinScore, "min-score", paper.DefaultMinScore, "Confluence score that opens a trade")
+	paperStartCmd.Flags().IntVar(&paperMaxPositions, "max-positions", paper.DefaultMaxPositions, "Most trades open at the same time")
+	paperStartCmd.Flags().Float64Var(&paperRisk, "risk", 0, "Equity risked per trade, in percent (default: the account's, else 1)")
+	paperStartCmd.Flags().Float64Var(&paperMaxPosition, "max-position", 0, "Largest trade, in percent of equity (default: the account's, else 20)")
+	paperStartCmd.Flags().BoolVar(&paperNoAlerts, "no-alerts", false, "Don't trade triggered alerts, only high scores")
+	paperReportCmd.Flags().BoolVar(&paperTrades, "trades", false, "List the closed trades")
+	paperReportCmd.Flags().IntVar(&paperEvents, "events", 0, "Show the last N journal entries")
+	paperReportCmd.Flags().StringVar(&paperFormat, "format", "table", "Output format: table or json")
+	paperResetCmd.Flags().BoolVar(&paperYes, "yes", false, "Confirm deleting the journal")
+
+	paperCmd.AddCommand(paperStartCmd)
+	paperCmd.AddCommand(paperStopCmd)
+	paperCmd.AddCommand(paperReportCmd)
+	paperCmd.AddCommand(paperResetCmd)
+	rootCmd.AddCommand(paperCmd)
+}
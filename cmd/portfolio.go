@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/history"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
+)
+
+var (
+	pfDate       string
+	pfFees       float64
+	pfStopLoss   float64
+	pfTakeProfit float64
+	pfCurrency   string
+	pfLive       bool
+	pfLots       bool
+	pfSales      bool
+)
+
+// portfolioCmd groups the portfolio commands
+var portfolioCmd = &cobra.Command{
+	Use:   "portfolio",
+	Short: "Track positions and their P&L",
+	Long: `The portfolio records the lots bought (symbol, quantity, entry price, date and
+fees) in config/portfolio.json. Sales are matched against the oldest lots
+first and their realised P&L is kept. "show" values the open positions at the
+prices of the latest saved scan (--live fetches quotes instead) and shows the
+distance to each position's stop loss and take profit and its weight.
+Amounts in different currencies are added up as quoted.`,
+}
+
+// portfolioAddCmd records a purchase
+var portfolioAddCmd = &cobra.Command{
+	Use:   "add SYMBOL QUANTITY PRICE",
+	Short: "Record a purchase",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		qty, price, err := parseQuantityPrice(args[1], args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		date, err := parsePortfolioDate()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		m := openPortfolio()
+		lot, err := m.Add(portfolio.Lot{
+			Symbol:     args[0],
+			Quantity:   qty,
+			EntryPrice: price,
+			Date:       date,
+			Fees:       pfFees,
+			StopLoss:   pfStopLoss,
+			TakeProfit: pfTakeProfit,
+			Currency:   strings.ToUpper(pfCurrency),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Bought %g %s at %.2f on %s (cost %.2f)\n", lot.Quantity, lot.Symbol, lot.EntryPrice,
+			lot.Date.Format("2006-01-02"), lot.Cost())
+	},
+}
+
+// portfolioSellCmd records a sale
+var portfolioSellCmd = &cobra.Command{
+	Use:   "sell SYMBOL QUANTITY|all PRICE",
+	Short: "Record a sale (oldest lots first)",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		m := openPortfolio()
+		symbol := strings.ToUpper(args[0])
+		qtyArg := args[1]
+		if strings.EqualFold(qtyArg, "all") {
+			held := 0.0
+			for _, l := range m.Lots() {
+				if l.Symbol == symbol {
+					held += l.Quantity
+				}
+			}
+			qtyArg = strconv.FormatFloat(held, 'f', -1, 64)
+		}
+		qty, price, err := parseQuantityPrice(qtyArg, args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		date, err := parsePortfolioDate()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		sales, err := m.Sell(symbol, qty, price, pfFees, date)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		realized := 0.0
+		for _, s := range sales {
+			realized += s.Realized
+		}
+		fmt.Printf("Sold %g %s at %.2f from %d lot(s); realised P&L %+.2f\n", qty, symbol, price, len(sales), realized)
+	},
+}
+
+// portfolioShowCmd values the open positions
+var portfolioShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show positions with unrealised and realised P&L",
+	Run: func(cmd *cobra.Command, args []string) {
+		m := openPortfolio()
+		symbols := m.Symbols()
+
+		var prices map[string]float64
+		source := "no prices"
+		if len(symbols) > 0 {
+			if pfLive {
+				prices = fetchPrices(symbols)
+				source = "live quotes"
+			} else if record, err := history.NewManager().GetLatest(); err == nil && record != nil {
+				prices = portfolio.Prices(record.Results)
+				source = "scan of " + history.FormatTimestamp(record.Timestamp)
+			} else {
+				source = "no saved scan; use --live for quotes"
+			}
+		}
+
+		summary := m.Summary(prices)
+		printPortfolio(summary, source)
+		if pfLots {
+			printLots(m.Lots())
+		}
+		if pfSales {
+			printSales(m.Sales())
+		}
+	},
+}
+
+// openPortfolio opens the portfolio, exiting when its file can't be read
+func openPortfolio() *portfolio.Manager {
+	m := portfolio.NewManager("")
+	if err := m.LoadError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return m
+}
+
+// parseQuantityPrice parses the QUANTITY and PRICE arguments
+func parseQuantityPrice(qtyArg, priceArg string) (float64, float64, error) {
+	qty, err := strconv.ParseFloat(qtyArg, 64)
+	if err != nil || qty <= 0 {
+		return 0, 0, fmt.Errorf("quantity: expected a positive number, got %q", qtyArg)
+	}
+	price, err := strconv.ParseFloat(priceArg, 64)
+	if err != nil || price <= 0 {
+		return 0, 0, fmt.Errorf("price: expected a positive number, got %q", priceArg)
+	}
+	return qty, price, nil
+}
+
+// parsePortfolioDate parses --date (default: now)
+func parsePortfolioDate() (time.Time, error) {
+	if pfDate == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse("2006-01-02", pfDate)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--date: expected a date like 2024-01-31, got %q", pfDate)
+	}
+	return t, nil
+}
+
+// fetchPrices fetches quotes for symbols; failures are left out
+func fetchPrices(symbols []string) map[string]float64 {
+	provider, err := fetcher.NewDefaultProvider()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer provider.Close()
+
+	prices := make(map[string]float64, len(symbols))
+	for _, symbol := range symbols {
+		q, err := provider.FetchQuote(context.Background(), symbol)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", symbol, err)
+			continue
+		}
+		prices[symbol] = q.Price
+	}
+	return prices
+}
+
+// printPortfolio prints the positions and totals of a portfolio summary
+func printPortfolio(s portfolio.Summary, source string) {
+	if len(s.Positions) == 0 {
+		fmt.Println("No open positions. Add one with \"stockmap portfolio add SYMBOL QUANTITY PRICE\".")
+	} else {
+		fmt.Printf("Prices: %s\n\n", source)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "SYMBOL\tQTY\tAVG COST\tPRICE\tVALUE\tP&L\tP&L %\tTO SL\tTO TP\tWEIGHT\tREALISED\t")
+		for _, p := range s.Positions {
+			price, value, pnl, pnlPct, weight := "-", "-", "-", "-", "-"
+			toStop, toTarget := "-", "-"
+			if p.Priced {
+				price = fmt.Sprintf("%.2f", p.Price)
+				value = fmt.Sprintf("%.2f", p.MarketValue)
+				pnl = fmt.Sprintf("%+.2f", p.Unrealized)
+				pnlPct = fmt.Sprintf("%+.2f%%", p.UnrealizedPct)
+				weight = fmt.Sprintf("%.1f%%", p.Weight)
+				if p.StopLoss > 0 {
+					toStop = fmt.Sprintf("%.1f%%", p.ToStop)
+				}
+				if p.TakeProfit > 0 {
+					toTarget = fmt.Sprintf("%.1f%%", p.ToTarget)
+				}
+			}
+			fmt.Fprintf(w, "%s\t%g\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%+.2f\t\n", p.Symbol, p.Quantity, p.AvgCost,
+				price, value, pnl, pnlPct, toStop, toTarget, weight, p.Realized)
+		}
+		w.Flush()
+		fmt.Println()
+		fmt.Printf("Cost:        %.2f\n", s.Cost)
+		fmt.Printf("Value:       %.2f\n", s.MarketValue)
+		fmt.Printf("Unrealised:  %+.2f (%+.2f%%)\n", s.Unrealized, s.UnrealizedPct)
+		if len(s.Unpriced) > 0 {
+			fmt.Printf("Unpriced:    %s\n", strings.Join(s.Unpriced, ", "))
+		}
+	}
+	fmt.Printf("Realised:    %+.2f over %d sale(s)\n", s.Realized, s.Sales)
+}
+
+// printLots prints the open lots
+func printLots(lots []portfolio.Lot) {
+	fmt.Println("\nLots:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tSYMBOL\tQTY\tPRICE\tFEES\tSL\tTP")
+	for _, l := range lots {
+		fmt.Fprintf(w, "%s\t%s\t%g\t%.2f\t%.2f\t%.2f\t%.2f\n", l.Date.Format("2006-01-02"), l.Symbol,
+			l.Quantity, l.EntryPrice, l.Fees, l.StopLoss, l.TakeProfit)
+	}
+	w.Flush()
+}
+
+// printSales prints the recorded sales
+func printSales(sales []portfolio.Sale) {
+	fmt.Println("\nSales:")
+	if len(sales) == 0 {
+		fmt.Println("  none")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tSYMBOL\tQTY\tBOUGHT\tSOLD\tFEES\tREALISED")
+	for _, s := range sales {
+		fmt.Fprintf(w, "%s\t%s\t%g\t%.2f\t%.2f\t%.2f\t%+.2f\n", s.Date.Format("2006-01-02"), s.Symbol,
+			s.Quantity, s.EntryPrice, s.ExitPrice, s.Fees, s.Realized)
+	}
+	w.Flush()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{portfolioAddCmd, portfolioSellCmd} {
+		c.Flags().StringVar(&pfDate, "date", "", "Trade date, e.g. 2024-01-31 (default: now)")
+		c.Flags().Float64Var(&pfFees, "fees", 0, "Commission and other fees paid")
+	}
+	portfolioAddCmd.Flags().Float64Var(&pfStopLoss, "sl", 0, "Stop loss level")
+	portfolioAddCmd.Flags().Float64Var(&pfTakeProfit, "tp", 0, "Take profit level")
+	portfolioAddCmd.Flags().StringVar(&pfCurrency, "currency", "", "Currency the price is quoted in")
+	portfolioShowCmd.Flags().BoolVar(&pfLive, "live", false, "Value positions at live quotes instead of the latest scan")
+	portfolioShowCmd.Flags().BoolVar(&pfLots, "lots", false, "List the open lots")
+	portfolioShowCmd.Flags().BoolVar(&pfSales, "sales", false, "List the sales")
+
+	portfolioCmd.AddCommand(portfolioAddCmd)
+	portfolioCmd.AddCommand(portfolioSellCmd)
+	portfolioCmd.AddCommand(portfolioShowCmd)
+	rootCmd.AddCommand(portfolioCmd)
+}
@@ -3,18 +3,54 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/export"
 	"github.com/febritecno/stockmap-cli/internal/fetcher"
 	"github.com/febritecno/stockmap-cli/internal/screener"
 	"github.com/febritecno/stockmap-cli/internal/ui"
+	"github.com/febritecno/stockmap-cli/internal/universe"
 )
 
 var (
-	version   = "1.0.3"
-	dnsServer string
+	version      = "1.0.3"
+	dnsServer    string
+	providerName string
+	recordDir    string
+	replayDir    string
+	noCache      bool
+	scanInterval string
+	scanLookback int
+	scanFillGaps bool
+	scanBaseCcy  string
+	scanFormat   string
+	scanColumns  string
+	scanOutput   string
+
+	scanSymbols         string
+	scanUniverse        string
+	scanWatchlist       bool
+	scanMinScore        float64
+	scanMinRSI          float64
+	scanMaxRSI          float64
+	scanMaxPBV          float64
+	scanMinGraham       float64
+	scanOnlyOversold    bool
+	scanOnlyUndervalued bool
+	scanSort            string
+	scanTop             int
+	scanWorkers         int
+	scanWhere           string
+	scanScreen          string
+	scanStrategy        string
+	scanRank            bool
+	scanRankBySector    bool
+	scanSectors         bool
 )
 
 // rootCmd represents the base command
@@ -32,11 +68,30 @@ Features:
   • Confluence scoring system
   • Interactive TUI with keyboard navigation
   • Watchlist management`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Pass DNS server to UI if set
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Pass provider to engine/UI if set
+		if providerName != "" {
+			os.Setenv("STOCKMAP_PROVIDER", providerName)
+		}
+		if recordDir != "" && replayDir != "" {
+			return fmt.Errorf("--record and --replay cannot be used together")
+		}
+		if recordDir != "" {
+			os.Setenv("STOCKMAP_RECORD", recordDir)
+		}
+		if replayDir != "" {
+			os.Setenv("STOCKMAP_REPLAY", replayDir)
+		}
+		if noCache {
+			os.Setenv("STOCKMAP_NO_CACHE", "1")
+		}
+		// Every command's provider picks up the DNS server
 		if dnsServer != "" {
 			os.Setenv("STOCKMAP_DNS", dnsServer)
 		}
+		return validateProvider()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
 		if err := ui.Run(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -57,17 +112,112 @@ var versionCmd = &cobra.Command{
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Run a quick scan and print results",
-	Long:  "Run a stock scan and print results to stdout without launching the TUI.",
+	Long: `Run a stock scan and print results to stdout without launching the TUI.
+
+The symbols come from the active universe unless --symbols, --universe or
+--watchlist is given. --strategy picks a strategy preset (criteria, score
+weights and indicator parameters; see "stockmap strategy list"), by default
+the one in settings.json, else Deep Value. Filter flags override the
+strategy's criteria (Deep Value: max RSI 40, max P/B 2, min score 50); with
+--watchlist every watchlist symbol is shown, as in the TUI. --where adds a
+screening expression and --screen a saved one (see "stockmap screen"). --sort orders by any column (numbers high to low
+unless ":asc" is added) and --top keeps the first N results.
+
+--rank ranks the results against everything scanned before filtering: each
+gets a percentile and z-score per metric (rsi_pct, rsi_z, pbv_pct, ...), a
+composite rank_score and a top_decile flag, all usable in --where and --sort.
+--rank-by-sector ranks within each sector instead.
+
+Sectors and industries come from the universe file's tags, else from the
+provider's company profile. relative_pbv and relative_pe compare a result
+with its sector's median, and --sectors prints per-sector aggregates.
+
+Output formats: table (default), csv, json, jsonl and markdown. --columns
+selects any result field by its snake_case name (e.g. symbol,price,rsi,pbv)
+or "all"; an unknown name lists the available ones.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Starting stock scan...")
+		format, err := export.ParseFormat(scanFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		var columns []export.Column
+		if scanColumns != "" {
+			if columns, err = export.ParseColumns(scanColumns); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
-		// Set DNS env for engine to pick up if needed
-		if dnsServer != "" {
-			os.Setenv("STOCKMAP_DNS", dnsServer)
+		if scanSort != "" {
+			// Sorting nothing validates the spec before scanning
+			if err := export.Sort(nil, scanSort); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if scanWorkers < 1 {
+			fmt.Fprintln(os.Stderr, "Error: --workers must be at least 1")
+			os.Exit(1)
+		}
+		if scanTop < 0 {
+			fmt.Fprintln(os.Stderr, "Error: --top must not be negative")
+			os.Exit(1)
+		}
+		if err := screener.StrategyLoadError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if err := screener.ScoringModelLoadError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (builtin scoring model used)\n", err)
+		}
+		var strategy *screener.Strategy
+		if scanStrategy != "" {
+			if strategy, err = screener.LookupStrategy(scanStrategy); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
-		symbols := fetcher.DefaultSymbols()
-		engine := screener.NewEngine(10)
+		// Progress goes to stderr so stdout stays machine readable
+		fmt.Fprintln(os.Stderr, "Starting stock scan...")
+
+		engine := screener.NewEngine(scanWorkers)
+		if strategy != nil {
+			engine.SetStrategy(strategy)
+		}
+		symbols, source, err := scanSymbolList(cmd, engine)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%s (%d symbols)\n", source, len(symbols))
+		fmt.Fprintf(os.Stderr, "Strategy: %s\n", engine.Strategy().Name)
+		criteria := scanCriteria(cmd, engine.GetCriteria())
+		if criteria.Expression, err = scanExpression(criteria.Expression); err != nil {
+			os.Exit(1)
+		}
+		engine.SetCriteria(criteria)
+
+		history, err := scanHistoryConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		engine.SetHistoryConfig(history)
+		ranking, err := scanRanking(cmd, criteria)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		engine.SetRanking(ranking)
+		if ranking != nil {
+			fmt.Fprintf(os.Stderr, "Ranking: %s\n", ranking)
+		}
+		if cmd.Flags().Changed("base-currency") {
+			engine.SetBaseCurrency(scanBaseCcy)
+		}
+		opts := engine.HistoryOptions()
+		fmt.Fprintf(os.Stderr, "History: %s bars, %d days\n", opts.Interval, opts.Days)
 
 		// Set progress callback
 		engine.SetProgressCallback(func(completed, total int, current string) {
@@ -75,24 +225,63 @@ var scanCmd = &cobra.Command{
 		})
 
 		results := engine.Scan(symbols)
-		fmt.Fprintf(os.Stderr, "\nScan complete. Found %d results.\n\n", len(results))
-
-		// Print results in a table
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SYMBOL\tPRICE\tRSI\tPBV\tGRAHAM%\tSCORE\tGRADE")
+		fmt.Fprintf(os.Stderr, "\nScan complete. Found %d results.\n", len(results))
+		if cached, ok := engine.Provider().(*fetcher.CachedProvider); ok {
+			c := cached.Counters()
+			fmt.Fprintf(os.Stderr, "History cache: %d fresh, %d incremental, %d full\n", c.Fresh, c.Incremental, c.Full)
+		}
+		if limiter := engine.GetProgress().Limiter; limiter.Slowed() {
+			fmt.Fprintf(os.Stderr, "Throttled: %s (%s paused)\n", limiter.Reason(), limiter.Throttled.Round(time.Second))
+		}
+		if err := engine.FXError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (native prices kept)\n", err)
+		}
+		if scanSectors {
+			fmt.Fprintln(os.Stderr)
+			printSectorStats(engine.SectorStats())
+		}
+		fmt.Fprintln(os.Stderr)
 
+		if columns == nil {
+			columns = export.DefaultColumns(format, engine.BaseCurrency() != "")
+		}
+		// Watchlist scans show every pinned symbol unless filter flags are given
+		filtered := !scanWatchlist || scanFiltered(cmd)
+		var printable []*screener.ScreenResult
 		for _, r := range results {
 			// Skip placeholders or errors if any
 			if r.HasError || r.Price == 0 {
 				continue
 			}
+			if filtered && !criteria.Matches(r) {
+				continue
+			}
+			printable = append(printable, r)
+		}
+		if scanSort != "" {
+			export.Sort(printable, scanSort)
+		}
+		if scanTop > 0 && len(printable) > scanTop {
+			printable = printable[:scanTop]
+		}
 
-			grade := screener.ScoreToGrade(r.ConfluenceScore)
-
-			fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%.1f%%\t%.1f\t%s\n",
-				r.Symbol, r.Price, r.RSI, r.PBV, r.GrahamUpside, r.ConfluenceScore, grade)
+		out := os.Stdout
+		if scanOutput != "" {
+			f, err := os.Create(scanOutput)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := export.Write(out, format, columns, printable); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if scanOutput != "" {
+			fmt.Fprintf(os.Stderr, "Wrote %d results to %s\n", len(printable), scanOutput)
 		}
-		w.Flush()
 	},
 }
 
@@ -103,12 +292,20 @@ var debugCmd = &cobra.Command{
 	Long:  "Test connectivity to Yahoo Finance API and report any errors.",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Running connection diagnostics...")
+		fmt.Printf("Using provider: %s\n", fetcher.ActiveProviderName())
 		if dnsServer != "" {
 			fmt.Printf("Using custom DNS: %s\n", dnsServer)
 		}
 		fmt.Println("--------------------------------")
 
-		client := fetcher.NewDirectYahooClientWithDNS(dnsServer)
+		client, err := fetcher.NewProvider(fetcher.ActiveProviderName(), fetcher.ProviderOptions{
+			DNSServer: dnsServer,
+			ReplayDir: replayDir,
+		})
+		if err != nil {
+			fmt.Println("Result: FAILED - " + err.Error())
+			os.Exit(1)
+		}
 		defer client.Close()
 
 		result := client.CheckConnection()
@@ -127,8 +324,255 @@ var debugCmd = &cobra.Command{
 	},
 }
 
+// scanHistoryConfig combines the scan settings from settings.json with the
+// --interval and --lookback flags
+func scanHistoryConfig(cmd *cobra.Command) (screener.HistoryConfig, error) {
+	s, err := config.Load()
+	if err != nil {
+		return screener.DefaultHistoryConfig(), err
+	}
+
+	if cmd.Flags().Changed("interval") {
+		s.Scan.Interval = scanInterval
+	}
+	if cmd.Flags().Changed("lookback") {
+		s.Scan.LookbackDays = scanLookback
+	}
+	if cmd.Flags().Changed("fill-gaps") {
+		s.Scan.FillGaps = scanFillGaps
+	}
+
+	return screener.HistoryConfigFromSettings(s.Scan)
+}
+
+// printSectorStats prints the sector aggregates of a scan to stderr
+func printSectorStats(stats []screener.SectorStats) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SECTOR\tSCANNED\tPASSING\tMEDIAN P/B\tMEDIAN P/E\tAVG RSI\tAVG SCORE")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%.1f\t%.1f\n", s.Sector, s.Count, s.Passing,
+			formatMedian(s.MedianPBV), formatMedian(s.MedianPE), s.AvgRSI, s.AvgScore)
+	}
+	w.Flush()
+}
+
+// formatMedian formats a sector median, "-" when unknown
+func formatMedian(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// scanRanking combines the ranking settings from settings.json with the
+// --rank and --rank-by-sector flags; nil means ranking is off. A --where
+// using rank fields turns ranking on, since unranked results never match it.
+func scanRanking(cmd *cobra.Command, criteria screener.FilterCriteria) (*screener.RankOptions, error) {
+	s, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Flags().Changed("rank") {
+		s.Scan.Rank = scanRank
+		if !scanRank {
+			s.Scan.RankBySector = false
+		}
+	}
+	if cmd.Flags().Changed("rank-by-sector") {
+		s.Scan.RankBySector = scanRankBySector
+	}
+	if !s.Scan.Rank && !s.Scan.RankBySector {
+		if !criteria.NeedsRanking() {
+			return nil, nil
+		}
+		if cmd.Flags().Changed("rank") {
+			return nil, fmt.Errorf("--where: rank fields need --rank")
+		}
+		s.Scan.Rank = true
+	}
+
+	opts, err := screener.RankOptionsFromSettings(s.Scan)
+	if err != nil {
+		return nil, fmt.Errorf("rank_weights: %v", err)
+	}
+	return &opts, nil
+}
+
+// scanSymbolList returns the symbols selected by --symbols, --universe or
+// --watchlist (default: the active universe) and a description of the source
+func scanSymbolList(cmd *cobra.Command, engine *screener.Engine) ([]string, string, error) {
+	// Symbols outside a scanned universe take their tags from the active one
+	engine.SetClassifier(universe.Active().Classify)
+
+	set := 0
+	for _, name := range []string{"symbols", "universe", "watchlist"} {
+		if cmd.Flags().Changed(name) {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, "", fmt.Errorf("--symbols, --universe and --watchlist cannot be combined")
+	}
+
+	switch {
+	case cmd.Flags().Changed("symbols"):
+		symbols := parseSymbols(scanSymbols)
+		if len(symbols) == 0 {
+			return nil, "", fmt.Errorf("no symbols given")
+		}
+		return symbols, "Symbols", nil
+	case scanWatchlist:
+		symbols := engine.GetWatchlistManager().GetAll()
+		if len(symbols) == 0 {
+			return nil, "", fmt.Errorf("watchlist is empty")
+		}
+		return symbols, "Watchlist", nil
+	case cmd.Flags().Changed("universe"):
+		u, err := universe.Load(scanUniverse)
+		if err != nil {
+			return nil, "", err
+		}
+		engine.SetClassifier(u.Classify)
+		return u.Symbols(), "Universe: " + u.Name, nil
+	default:
+		u := universe.Active()
+		return u.Symbols(), "Universe: " + u.Name, nil
+	}
+}
+
+// parseSymbols splits a comma or space separated symbol list, upper-cased
+// and without duplicates
+func parseSymbols(s string) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, sym := range strings.Fields(strings.ReplaceAll(s, ",", " ")) {
+		sym = strings.ToUpper(sym)
+		if !seen[sym] {
+			seen[sym] = true
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols
+}
+
+// scanFiltered reports whether any filter flag was given
+func scanFiltered(cmd *cobra.Command) bool {
+	for _, name := range []string{"min-score", "min-rsi", "max-rsi", "max-pbv", "min-graham-upside",
+		"only-oversold", "only-undervalued", "where", "screen"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanCriteria applies the filter flags that were set to the given criteria
+func scanCriteria(cmd *cobra.Command, c screener.FilterCriteria) screener.FilterCriteria {
+	flags := cmd.Flags()
+	if flags.Changed("min-score") {
+		c.MinConfluence = scanMinScore
+	}
+	if flags.Changed("min-rsi") {
+		c.MinRSI = scanMinRSI
+	}
+	if flags.Changed("max-rsi") {
+		c.MaxRSI = scanMaxRSI
+	}
+	if flags.Changed("max-pbv") {
+		c.MaxPBV = scanMaxPBV
+	}
+	if flags.Changed("min-graham-upside") {
+		c.MinGrahamUpside = scanMinGraham
+	}
+	if flags.Changed("only-oversold") {
+		c.OnlyOversold = scanOnlyOversold
+	}
+	if flags.Changed("only-undervalued") {
+		c.OnlyUndervalued = scanOnlyUndervalued
+	}
+	return c
+}
+
+// scanExpression compiles the strategy's expression and the --screen and
+// --where expressions, joined with "and"; nil if there are none. Errors are
+// printed.
+func scanExpression(base *screener.Expr) (*screener.Expr, error) {
+	var parts []string
+	if base != nil {
+		parts = append(parts, base.String())
+	}
+	if scanScreen != "" {
+		s, err := screener.LookupScreen(scanScreen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return nil, err
+		}
+		parts = append(parts, s.Expression)
+	}
+	if scanWhere != "" {
+		parts = append(parts, scanWhere)
+	}
+
+	for _, src := range parts {
+		if _, err := screener.Compile(src); err != nil {
+			printExprError(src, err)
+			return nil, err
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return nil, nil
+	case 1:
+		return screener.Compile(parts[0])
+	default:
+		return screener.Compile("(" + strings.Join(parts, ") and (") + ")")
+	}
+}
+
+// validateProvider checks that the active provider name is registered
+func validateProvider() error {
+	name := strings.ToLower(fetcher.ActiveProviderName())
+	for _, p := range fetcher.ProviderNames() {
+		if p == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(fetcher.ProviderNames(), ", "))
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVar(&dnsServer, "dns", "", "Custom DNS server (e.g., 8.8.8.8)")
+	rootCmd.PersistentFlags().StringVar(&providerName, "provider", "", "Market data provider ("+strings.Join(fetcher.ProviderNames(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Save raw Yahoo responses to this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve recorded responses from this directory (offline)")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Don't use the on-disk price history cache")
+	scanCmd.Flags().StringVar(&scanInterval, "interval", "", "Bar interval: 1h, 1d, 1wk, 1mo (default from settings, else 1d)")
+	scanCmd.Flags().IntVar(&scanLookback, "lookback", 0, "Days of history to fetch (0 = enough for the longest indicator)")
+	scanCmd.Flags().BoolVar(&scanFillGaps, "fill-gaps", false, "Forward-fill missing bars instead of dropping them")
+	scanCmd.Flags().StringVar(&scanBaseCcy, "base-currency", "", "Also show prices converted to this currency (default from settings)")
+	scanCmd.Flags().StringVar(&scanFormat, "format", string(export.FormatTable), "Output format: table, csv, json, jsonl, markdown")
+	scanCmd.Flags().StringVar(&scanColumns, "columns", "", "Comma-separated columns to print, or \"all\" (default depends on format)")
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", "", "Write results to this file instead of stdout")
+	scanCmd.Flags().StringVar(&scanSymbols, "symbols", "", "Scan these symbols (comma-separated) instead of the universe")
+	scanCmd.Flags().StringVar(&scanUniverse, "universe", "", "Scan this universe instead of the active one")
+	scanCmd.Flags().BoolVar(&scanWatchlist, "watchlist", false, "Scan the watchlist symbols (unfiltered unless filter flags are given)")
+	scanCmd.Flags().StringVar(&scanStrategy, "strategy", "", "Strategy preset, e.g. momentum (default from settings, else Deep Value)")
+	scanCmd.Flags().Float64Var(&scanMinScore, "min-score", 0, "Minimum confluence score (default from the strategy)")
+	scanCmd.Flags().Float64Var(&scanMinRSI, "min-rsi", 0, "Minimum RSI (default from the strategy)")
+	scanCmd.Flags().Float64Var(&scanMaxRSI, "max-rsi", 0, "Maximum RSI (default from the strategy)")
+	scanCmd.Flags().Float64Var(&scanMaxPBV, "max-pbv", 0, "Maximum price/book ratio (default from the strategy)")
+	scanCmd.Flags().Float64Var(&scanMinGraham, "min-graham-upside", 0, "Minimum upside to the Graham number, in percent")
+	scanCmd.Flags().BoolVar(&scanOnlyOversold, "only-oversold", false, fmt.Sprintf("Only show oversold stocks (RSI below the strategy's oversold_rsi, default %g)", screener.DefaultIndicators().OversoldRSI))
+	scanCmd.Flags().BoolVar(&scanOnlyUndervalued, "only-undervalued", false, "Only show undervalued stocks (P/B below 1.5, Graham upside over 20%)")
+	scanCmd.Flags().StringVar(&scanWhere, "where", "", "Screening expression, e.g. 'rsi < 30 and pbv < 1.2'")
+	scanCmd.Flags().StringVar(&scanScreen, "screen", "", "Apply a saved screen (see stockmap screen)")
+	scanCmd.Flags().StringVar(&scanSort, "sort", "", "Sort by a column, e.g. rsi:asc or graham_upside (default: score)")
+	scanCmd.Flags().IntVar(&scanTop, "top", 0, "Only print the first N results (0 = all)")
+	scanCmd.Flags().BoolVar(&scanRank, "rank", false, "Rank results against the whole scan (default from settings; on when --where uses rank fields)")
+	scanCmd.Flags().BoolVar(&scanRankBySector, "rank-by-sector", false, "Rank results within their sector")
+	scanCmd.Flags().BoolVar(&scanSectors, "sectors", false, "Print per-sector aggregates after the scan")
+	scanCmd.Flags().IntVar(&scanWorkers, "workers", 10, "Number of concurrent fetches")
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(debugCmd)
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// scoringCmd groups the scoring model commands
+var scoringCmd = &cobra.Command{
+	Use:   "scoring",
+	Short: "Show or check the scoring model",
+	Long: `The scoring model defines the confluence score: component weights, the
+threshold tables of the technical (per technical style), valuation and risk
+scores, and bonus rules. Each table awards the points of its first rule whose
+screening expression holds, e.g.
+
+  valuation:
+    - name: P/B
+      rules:
+        - { when: pbv > 0 and pbv < 1, points: 35 }
+        - { when: pbv > 0 and pbv < 2, points: 20 }
+
+Sections in config/scoring.yaml replace the builtin ones. Every scan saved to
+history records the model it was scored with.`,
+}
+
+// scoringShowCmd prints the active scoring model
+var scoringShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the active scoring model as YAML",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := screener.ScoringModelLoadError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (builtin scoring model used)\n", err)
+		}
+		data, err := yaml.Marshal(screener.DefaultScoringModel())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	},
+}
+
+// scoringCheckCmd validates a scoring model file
+var scoringCheckCmd = &cobra.Command{
+	Use:   "check [FILE]",
+	Short: "Validate a scoring model file (default config/scoring.yaml)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := config.Path("scoring.yaml")
+		if len(args) == 1 {
+			path = args[0]
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		base, _ := yaml.Marshal(screener.BuiltinScoringModel())
+		if _, err := screener.ParseScoringModel(data, base); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", path)
+	},
+}
+
+func init() {
+	scoringCmd.AddCommand(scoringShowCmd)
+	scoringCmd.AddCommand(scoringCheckCmd)
+	rootCmd.AddCommand(scoringCmd)
+}
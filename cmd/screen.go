@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+var screenDescription string
+
+// screenCmd groups the saved screen commands
+var screenCmd = &cobra.Command{
+	Use:   "screen",
+	Short: "Manage saved screening expressions",
+	Long: `A screen is a named screening expression saved in config/screens.json, e.g.
+
+  stockmap screen save oversold-value 'rsi < 30 and pbv < 1.2'
+  stockmap scan --screen oversold-value
+
+Expressions compare result fields by their snake_case name (rsi, pbv,
+macd_crossover, bb_percent_b, graham_upside, ...) with < <= > >= == !=,
+combine them with and/or/not and may use + - * / and parentheses. Strings are
+quoted; a comparison with an unknown value (no fundamentals) is false.`,
+}
+
+// screenListCmd lists the saved screens
+var screenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved screens",
+	Run: func(cmd *cobra.Command, args []string) {
+		screens, err := screener.LoadScreens()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(screens) == 0 {
+			fmt.Println("No saved screens")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tEXPRESSION\tDESCRIPTION")
+		for _, s := range screens {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, s.Expression, s.Description)
+		}
+		w.Flush()
+	},
+}
+
+// screenSaveCmd saves a screen
+var screenSaveCmd = &cobra.Command{
+	Use:   "save NAME EXPRESSION",
+	Short: "Save a screen (replaces one with the same name)",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		expr := strings.Join(args[1:], " ")
+		s := screener.Screen{Name: args[0], Expression: expr, Description: screenDescription}
+		if err := screener.SaveScreen(s); err != nil {
+			printExprError(expr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved screen %s: %s\n", s.Name, expr)
+	},
+}
+
+// screenDeleteCmd deletes a saved screen
+var screenDeleteCmd = &cobra.Command{
+	Use:   "delete NAME",
+	Short: "Delete a saved screen",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := screener.DeleteScreen(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted screen %s\n", args[0])
+	},
+}
+
+// printExprError prints an error, pointing at the position of an
+// expression error in its source
+func printExprError(src string, err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	var exprErr *screener.ExprError
+	if errors.As(err, &exprErr) {
+		fmt.Fprintf(os.Stderr, "  %s\n  %s\n", src, exprErr.Caret())
+	}
+}
+
+func init() {
+	screenSaveCmd.Flags().StringVar(&screenDescription, "description", "", "Description of the screen")
+	screenCmd.AddCommand(screenListCmd)
+	screenCmd.AddCommand(screenSaveCmd)
+	screenCmd.AddCommand(screenDeleteCmd)
+	rootCmd.AddCommand(screenCmd)
+}
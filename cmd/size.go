@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/history"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+var (
+	sizeEquity      float64
+	sizeRisk        float64
+	sizeMaxPosition float64
+	sizeEntry       float64
+	sizeStop        float64
+	sizeStrategy    string
+	sizeSave        bool
+)
+
+// sizeCmd sizes a position from the account settings
+var sizeCmd = &cobra.Command{
+	Use:   "size SYMBOL",
+	Short: "Size a position with the strategy's ATR stop",
+	Long: `Calculate how many shares of SYMBOL to buy so that a fall to the stop loss
+loses --risk percent of the account equity, capped to --max-position percent
+of equity. The entry is the current price and the stop the strategy's ATR stop
+loss, unless --entry and --stop are given.
+
+Portfolio heat is what every open portfolio position with a stop loss (see
+"stockmap portfolio") plus this trade would lose if all stops were hit, in
+percent of equity. Positions are valued at the latest saved scan.
+
+The account is read from "account" in settings.json; --save stores the
+--equity, --risk and --max-position given:
+
+  "account": {"equity": 50000, "risk_percent": 1, "max_position_percent": 20}`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		settings, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if cmd.Flags().Changed("equity") {
+			settings.Account.Equity = sizeEquity
+		}
+		if cmd.Flags().Changed("risk") {
+			settings.Account.RiskPercent = sizeRisk
+		}
+		if cmd.Flags().Changed("max-position") {
+			settings.Account.MaxPositionPercent = sizeMaxPosition
+		}
+		account := portfolio.NewAccount(settings.Account)
+		if err := account.Validate(); err != nil {
+			if account.Equity <= 0 {
+				err = fmt.Errorf("%v; pass --equity (and --save to keep it)", err)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if sizeSave {
+			if err := config.Save(settings); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Saved the account to %s\n", config.Path("settings.json"))
+		}
+
+		symbol := strings.ToUpper(args[0])
+		entry, stop, currency := sizeEntry, sizeStop, ""
+		if entry <= 0 || stop <= 0 {
+			r, err := sizeResult(symbol, settings)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if entry <= 0 {
+				entry = r.Price
+			}
+			if stop <= 0 {
+				stop = r.StopLoss
+			}
+			currency = r.Currency
+		}
+
+		openRisk := 0.0
+		if pm := portfolio.NewManager(""); pm.LoadError() == nil && pm.Count() > 0 {
+			var prices map[string]float64
+			if record, err := history.NewManager().GetLatest(); err == nil && record != nil {
+				prices = portfolio.Prices(record.Results)
+			}
+			openRisk = pm.Summary(prices).OpenRisk()
+		}
+
+		s, err := account.Size(entry, stop, openRisk)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", symbol, err)
+			os.Exit(1)
+		}
+		printSizing(symbol, currency, account, s)
+	},
+}
+
+// sizeResult fetches symbol and calculates its metrics with the active
+// strategy
+func sizeResult(symbol string, settings config.Settings) (*screener.ScreenResult, error) {
+	if err := screener.StrategyLoadError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	strategy, err := activeStrategy(sizeStrategy)
+	if err != nil {
+		return nil, err
+	}
+	hist, err := screener.HistoryConfigFromSettings(settings.Scan)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := fetcher.NewDefaultProvider()
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+	data, err := fetcher.FetchComplete(context.Background(), provider, symbol, hist.OptionsFor(strategy.Indicators.RequiredBars()))
+	if err != nil {
+		return nil, err
+	}
+	if data.Error != nil {
+		return nil, data.Error
+	}
+
+	r := screener.CalculateMetricsWith(data, strategy, screener.DefaultScoringModel())
+	if r.HasError {
+		return nil, fmt.Errorf("%s", r.ErrorMessage)
+	}
+	fmt.Fprintf(os.Stderr, "Strategy: %s (stop loss %.1f× ATR %.2f)\n", strategy.Name, strategy.Indicators.StopLossATR, r.ATR)
+	return r, nil
+}
+
+// printSizing prints a position size
+func printSizing(symbol, currency string, a portfolio.Account, s portfolio.Sizing) {
+	if currency != "" {
+		currency = " " + currency
+	}
+	fmt.Printf("%s: buy at %.2f%s, stop loss %.2f (%.2f risk per share, %.2f%%)\n", symbol, s.Entry, currency,
+		s.StopLoss, s.RiskPerShare, s.RiskPerShare/s.Entry*100)
+	fmt.Printf("Account: equity %.2f, risk %g%% per trade, max position %g%%\n\n", a.Equity, a.RiskPercent, a.MaxPositionPercent)
+
+	fmt.Printf("Shares:          %d\n", s.Shares)
+	if s.Capped {
+		fmt.Printf("                 (capped by the max position; the risk budget allows %d)\n", s.RiskShares)
+	}
+	fmt.Printf("Position value:  %.2f (%.1f%% of equity)\n", s.Value, s.ValuePercent)
+	fmt.Printf("Capital at risk: %.2f (%.2f%% of equity)\n", s.CapitalAtRisk, s.RiskPercent)
+	fmt.Printf("Portfolio heat:  %.2f%% (open positions %.2f + this trade)\n", s.Heat, s.OpenRisk)
+}
+
+func init() {
+	sizeCmd.Flags().Float64Var(&sizeEquity, "equity", 0, "Account equity (default: settings.json)")
+	sizeCmd.Flags().Float64Var(&sizeRisk, "risk", 0, "Equity risked per trade, in percent (default 1)")
+	sizeCmd.Flags().Float64Var(&sizeMaxPosition, "max-position", 0, "Largest position, in percent of equity (default 20)")
+	sizeCmd.Flags().Float64Var(&sizeEntry, "entry", 0, "Entry price (default: the current price)")
+	sizeCmd.Flags().Float64Var(&sizeStop, "stop", 0, "Stop loss (default: the strategy's ATR stop)")
+	sizeCmd.Flags().StringVar(&sizeStrategy, "strategy", "", "Strategy whose ATR stop is used (default: settings.json, else Deep Value)")
+	sizeCmd.Flags().BoolVar(&sizeSave, "save", false, "Save --equity, --risk and --max-position to settings.json")
+	rootCmd.AddCommand(sizeCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// strategyCmd groups the strategy preset commands
+var strategyCmd = &cobra.Command{
+	Use:   "strategy",
+	Short: "List strategy presets",
+	Long: `A strategy bundles filter criteria, score weights, a technical scoring style
+and indicator parameters. The builtin presets can be replaced, and new ones
+added, in config/strategies.yaml:
+
+  - name: Momentum
+    technical: momentum          # value, momentum, mean_reversion or breakout
+    weights: {technical: 0.7, valuation: 0, risk: 0.3}
+    indicators: {rsi_period: 10, stop_loss_atr: 3}
+    criteria: {min_rsi: 55, min_score: 60, where: "price > sma50"}
+
+Omitted fields take the Deep Value defaults. Pick a strategy with
+"stockmap scan --strategy NAME", "strategy" in the scan settings of
+settings.json, or the M key in the TUI.`,
+}
+
+// strategyListCmd lists the strategy presets
+var strategyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List strategy presets",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := screener.StrategyLoadError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		active := screener.DefaultStrategyName
+		if s, err := config.Load(); err == nil && s.Scan.Strategy != "" {
+			active = s.Scan.Strategy
+		}
+		current, _ := screener.LookupStrategy(active)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tTECHNICAL\tWEIGHTS (T/V/R)\tDESCRIPTION")
+		model := screener.DefaultScoringModel()
+		for _, s := range screener.Strategies() {
+			mark := ""
+			if s == current {
+				mark = "*"
+			}
+			weights := model.WeightsFor(s)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.2f/%.2f/%.2f\t%s\n", mark, s.Name, s.Technical,
+				weights.Technical, weights.Valuation, weights.Risk, s.Description)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	strategyCmd.AddCommand(strategyListCmd)
+	rootCmd.AddCommand(strategyCmd)
+}
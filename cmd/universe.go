@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/febritecno/stockmap-cli/internal/universe"
+)
+
+var (
+	universeSector   string
+	universeIndustry string
+	universeName     string
+)
+
+// universeCmd groups the symbol universe commands
+var universeCmd = &cobra.Command{
+	Use:   "universe",
+	Short: "Manage the symbol universes scanned",
+	Long: `A universe is a named list of symbols with optional sector/industry tags.
+Universes are YAML or CSV files in config/universes; S&P 500, Nasdaq-100 and
+Dow snapshots are built in. A file with the same name overrides a builtin one.
+The scanned universe is set with "scan": {"universe": "..."} in settings.json
+or picked in the scan mode view.`,
+}
+
+// universeListCmd lists the available universes
+var universeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available universes",
+	Run: func(cmd *cobra.Command, args []string) {
+		list, errs := universe.List()
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		active := universe.Key(universe.Active().Name)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tSYMBOLS\tSECTORS\tSOURCE\tDESCRIPTION")
+		for _, u := range list {
+			mark := ""
+			if universe.Key(u.Name) == active {
+				mark = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", mark, u.Name, len(u.Members), len(u.Groups()), u.Source, u.Description)
+		}
+		w.Flush()
+	},
+}
+
+// universeShowCmd prints the symbols of a universe
+var universeShowCmd = &cobra.Command{
+	Use:   "show [NAME]",
+	Short: "Show the symbols of a universe (default: the active one)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := universe.Active()
+		if len(args) == 1 {
+			var err error
+			if u, err = universe.Load(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Printf("%s (%d symbols, %s)\n", u.Name, len(u.Members), u.Source)
+		if u.Description != "" {
+			fmt.Println(u.Description)
+		}
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SYMBOL\tSECTOR\tINDUSTRY")
+		for _, m := range u.Members {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", m.Symbol, dashIfEmpty(m.Sector), dashIfEmpty(m.Industry))
+		}
+		w.Flush()
+	},
+}
+
+// universeAddCmd adds symbols to a user universe, creating it if needed
+var universeAddCmd = &cobra.Command{
+	Use:   "add NAME SYMBOL...",
+	Short: "Add symbols to a universe (created if it doesn't exist)",
+	Long: `Add symbols to a universe file in config/universes, creating it if needed.
+Adding to a builtin universe saves an editable copy that overrides it.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		u, err := universe.Load(args[0])
+		if err != nil {
+			u = &universe.Universe{Name: args[0]}
+		}
+
+		members := make([]universe.Member, 0, len(args)-1)
+		for _, s := range args[1:] {
+			members = append(members, universe.Member{Symbol: s, Sector: universeSector, Industry: universeIndustry})
+		}
+		added := u.Add(members...)
+
+		path, err := universe.Save(u)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added %d symbols to %s (%d total): %s\n", added, u.Name, len(u.Members), path)
+	},
+}
+
+// universeImportCmd copies a YAML or CSV file into the universes directory
+var universeImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import a YAML or CSV universe file",
+	Long: `Import a universe from a YAML file or a CSV file with a "symbol" column and
+optional "sector" and "industry" columns. The universe is named after the file
+unless it sets a name or --name is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		u, err := universe.LoadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if universeName != "" {
+			u.Name = universeName
+		}
+
+		path, err := universe.Save(u)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %s (%d symbols, %d sectors): %s\n", u.Name, len(u.Members), len(u.Groups()), path)
+	},
+}
+
+// dashIfEmpty returns "-" for empty strings
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	universeAddCmd.Flags().StringVar(&universeSector, "sector", "", "Sector tag for the added symbols")
+	universeAddCmd.Flags().StringVar(&universeIndustry, "industry", "", "Industry tag for the added symbols")
+	universeImportCmd.Flags().StringVar(&universeName, "name", "", "Name of the imported universe")
+	universeCmd.AddCommand(universeListCmd)
+	universeCmd.AddCommand(universeShowCmd)
+	universeCmd.AddCommand(universeAddCmd)
+	universeCmd.AddCommand(universeImportCmd)
+	rootCmd.AddCommand(universeCmd)
+}
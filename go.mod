@@ -7,6 +7,7 @@ require (
 	github.com/charmbracelet/lipgloss v0.9.1
 	github.com/piquette/finance-go v1.1.0
 	github.com/spf13/cobra v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
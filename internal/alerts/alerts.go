@@ -33,6 +33,7 @@ type Alert struct {
 	IsActive    bool      `json:"is_active"`
 	Message     string    `json:"message,omitempty"`
 	LastPrice   float64   `json:"last_price,omitempty"` // Last known price when created
+	Currency    string    `json:"currency,omitempty"`   // Currency of the price threshold
 }
 
 // TriggeredAlert represents an alert that has been triggered
@@ -147,8 +148,8 @@ func (m *Manager) saveUnsafe() error {
 	return os.WriteFile(m.filePath, data, 0644)
 }
 
-// Add creates a new alert
-func (m *Manager) Add(symbol string, alertType AlertType, threshold float64, lastPrice float64) (*Alert, error) {
+// Add creates a new alert; currency is the currency the symbol is quoted in
+func (m *Manager) Add(symbol string, alertType AlertType, threshold float64, lastPrice float64, currency string) (*Alert, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -163,6 +164,7 @@ func (m *Manager) Add(symbol string, alertType AlertType, threshold float64, las
 		CreatedAt: time.Now(),
 		IsActive:  true,
 		LastPrice: lastPrice,
+		Currency:  currency,
 	}
 
 	m.alerts[id] = alert
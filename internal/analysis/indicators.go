@@ -99,6 +99,20 @@ func SMA(prices []float64, period int) float64 {
 	return sum / float64(period)
 }
 
+// Momentum calculates the percentage change over the last period bars
+func Momentum(prices []float64, period int) float64 {
+	if period < 1 || len(prices) < period+1 {
+		return 0.0
+	}
+
+	past := prices[len(prices)-1-period]
+	if past == 0 {
+		return 0.0
+	}
+
+	return (prices[len(prices)-1] - past) / past * 100
+}
+
 // EMA calculates Exponential Moving Average
 func EMA(prices []float64, period int) float64 {
 	if len(prices) < period {
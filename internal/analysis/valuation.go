@@ -47,25 +47,29 @@ func DividendYield(annualDividend, price float64) float64 {
 
 // IsUndervalued checks if stock is potentially undervalued
 // Uses multiple criteria: PBV < 1.5, Graham Upside > 20%, etc.
+// An unknown PBV (0) is never undervalued.
 func IsUndervalued(pbv, grahamUpside float64) bool {
-	return pbv < 1.5 && grahamUpside > 20.0
+	return pbv > 0 && pbv < 1.5 && grahamUpside > 20.0
 }
 
-// ValuationScore returns a score from 0-100 based on valuation metrics
+// ValuationScore returns a score from 0-100 based on valuation metrics.
+// Unknown (0) PBV and P/E earn no points.
 func ValuationScore(pbv, grahamUpside, peRatio float64) float64 {
 	var score float64
 
 	// PBV scoring (max 35 points)
-	if pbv < 0.5 {
-		score += 35
-	} else if pbv < 1.0 {
-		score += 30
-	} else if pbv < 1.5 {
-		score += 25
-	} else if pbv < 2.0 {
-		score += 15
-	} else if pbv < 3.0 {
-		score += 5
+	if pbv > 0 {
+		if pbv < 0.5 {
+			score += 35
+		} else if pbv < 1.0 {
+			score += 30
+		} else if pbv < 1.5 {
+			score += 25
+		} else if pbv < 2.0 {
+			score += 15
+		} else if pbv < 3.0 {
+			score += 5
+		}
 	}
 
 	// Graham Upside scoring (max 35 points)
@@ -82,7 +86,7 @@ func ValuationScore(pbv, grahamUpside, peRatio float64) float64 {
 	}
 
 	// PE Ratio scoring (max 30 points)
-	if !math.IsInf(peRatio, 1) {
+	if peRatio > 0 && !math.IsInf(peRatio, 1) {
 		if peRatio < 10 {
 			score += 30
 		} else if peRatio < 15 {
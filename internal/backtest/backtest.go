@@ -0,0 +1,414 @@
+// Package backtest replays historical bars day by day through a screening
+// strategy and measures how its entries would have performed
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/analysis"
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// dateLayout keys bars by trading day
+const dateLayout = "2006-01-02"
+
+// tradingDays annualises the daily Sharpe ratio
+const tradingDays = 252
+
+// Options configures a backtest
+type Options struct {
+	Strategy *screener.Strategy     // Indicator parameters, scoring style and SL/TP multipliers
+	Model    *screener.ScoringModel // nil uses the active scoring model
+	Criteria screener.FilterCriteria
+
+	From, To     time.Time
+	Capital      float64 // Starting equity
+	PositionPct  float64 // Share of equity put into each new position, in percent
+	MaxPositions int     // Positions open (or to be filled at the next open) at the same time
+	MaxHoldBars  int     // Close positions at the close after this many bars; 0 holds until stop or target
+	Window       int     // Trailing bars the metrics are calculated on; 0 uses what the indicators need
+}
+
+// DefaultOptions backtests a strategy with its own criteria over the last
+// year: 100,000 starting equity, 10% per position, at most 10 positions
+func DefaultOptions(strategy *screener.Strategy) Options {
+	to := time.Now()
+	return Options{
+		Strategy:     strategy,
+		Criteria:     strategy.Criteria(),
+		From:         to.AddDate(-1, 0, 0),
+		To:           to,
+		Capital:      100000,
+		PositionPct:  10,
+		MaxPositions: 10,
+	}
+}
+
+// Validate checks the options
+func (o Options) Validate() error {
+	switch {
+	case o.Strategy == nil:
+		return fmt.Errorf("no strategy")
+	case !o.From.Before(o.To):
+		return fmt.Errorf("the start date must be before the end date")
+	case o.Capital <= 0:
+		return fmt.Errorf("capital must be positive")
+	case o.PositionPct <= 0 || o.PositionPct > 100:
+		return fmt.Errorf("position size must be between 0 and 100%%")
+	case o.MaxPositions < 1:
+		return fmt.Errorf("max positions must be at least 1")
+	case o.MaxHoldBars < 0 || o.Window < 0:
+		return fmt.Errorf("max hold and window must not be negative")
+	case o.Criteria.NeedsWholeScan():
+		return fmt.Errorf("the filter uses rankings, sector medians or exchange rates, which need a whole scan and can't be backtested")
+	}
+	return nil
+}
+
+// window returns the trailing bars the metrics are calculated on
+func (o Options) window() int {
+	if o.Window > 0 {
+		return o.Window
+	}
+	return o.Strategy.Indicators.RequiredBars()
+}
+
+// minBars returns the fewest bars a symbol needs before it can signal: enough
+// for RSI, ATR and the Bollinger bands. Longer indicators such as SMA200 are
+// left out, as in a scan, until enough history has built up.
+func (o Options) minBars() int {
+	in := o.Strategy.Indicators
+	n := 20 // SMA20
+	for _, p := range []int{in.RSIPeriod + 1, in.ATRPeriod + 1, in.BBPeriod} {
+		if p > n {
+			n = p
+		}
+	}
+	if w := o.window(); n > w {
+		n = w
+	}
+	return n
+}
+
+// Series is the price history of one symbol. Fundamentals, when set, are
+// applied to every day; they are today's, so valuation scores benefit from
+// hindsight.
+type Series struct {
+	Symbol       string
+	Bars         []fetcher.Bar
+	Fundamentals *fetcher.StockData
+}
+
+// Exit reasons of a trade
+const (
+	ExitStop   = "stop"   // Low reached the stop loss
+	ExitTarget = "target" // High reached the take profit
+	ExitTime   = "time"   // Held for MaxHoldBars
+	ExitEnd    = "end"    // Still open at the end of the backtest
+)
+
+// Trade is a closed position
+type Trade struct {
+	Symbol     string    `json:"symbol"`
+	Score      float64   `json:"score"` // Confluence score of the signal
+	EntryTime  time.Time `json:"entry_time"`
+	EntryPrice float64   `json:"entry_price"`
+	Shares     int       `json:"shares"`
+	StopLoss   float64   `json:"stop_loss"`
+	TakeProfit float64   `json:"take_profit"`
+	ExitTime   time.Time `json:"exit_time"`
+	ExitPrice  float64   `json:"exit_price"`
+	Reason     string    `json:"reason"`
+	Bars       int       `json:"bars"` // Bars held, counting the entry bar
+	PnL        float64   `json:"pnl"`
+	ReturnPct  float64   `json:"return_pct"`
+}
+
+// EquityPoint is the marked-to-market equity at a day's close
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Report is the outcome of a backtest
+type Report struct {
+	Strategy     string        `json:"strategy"`
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Symbols      int           `json:"symbols"`
+	Capital      float64       `json:"capital"`
+	FinalEquity  float64       `json:"final_equity"`
+	TotalReturn  float64       `json:"total_return"` // Percent
+	CAGR         float64       `json:"cagr"`         // Percent per year
+	MaxDrawdown  float64       `json:"max_drawdown"` // Percent from the equity peak
+	Sharpe       float64       `json:"sharpe"`       // Annualised, from daily returns, without a risk-free rate
+	WinRate      float64       `json:"win_rate"`     // Percent of trades with a profit
+	Wins         int           `json:"wins"`
+	Losses       int           `json:"losses"`
+	ProfitFactor float64       `json:"profit_factor"` // Gross profit / gross loss; 0 without losing trades
+	Trades       []Trade       `json:"trades"`
+	Equity       []EquityPoint `json:"equity"`
+}
+
+// series is a Series prepared for the daily loop
+type series struct {
+	Series
+	closes, highs, lows []float64
+	days                map[string]int // Bar index by trading day
+}
+
+// signal is a symbol that passed the filter, entered at its next open
+type signal struct {
+	series *series
+	score  float64
+	atr    float64
+}
+
+// Run replays the series day by day between opts.From and opts.To. Each day
+// open positions are checked against their stop loss and take profit, then
+// the symbols without one are scored on their trailing window; those that
+// pass the criteria are bought at their next open, best scores first, with
+// the stop loss and take profit of analysis.CalculateSLTP. When a bar reaches
+// both levels the stop loss is assumed to have been hit first.
+func Run(data []Series, opts Options) (*Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	model := opts.Model
+	if model == nil {
+		model = screener.DefaultScoringModel()
+	}
+
+	from, to := opts.From.UTC().Format(dateLayout), opts.To.UTC().Format(dateLayout)
+	all := make([]*series, 0, len(data))
+	dates := make(map[string]bool)
+	for _, d := range data {
+		s := prepare(d)
+		for day := range s.days {
+			if day >= from && day <= to {
+				dates[day] = true
+			}
+		}
+		all = append(all, s)
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no bars between %s and %s", from, to)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Symbol < all[j].Symbol })
+	timeline := make([]string, 0, len(dates))
+	for day := range dates {
+		timeline = append(timeline, day)
+	}
+	sort.Strings(timeline)
+
+	report := &Report{Strategy: opts.Strategy.Name, From: opts.From, To: opts.To, Symbols: len(data), Capital: opts.Capital}
+	in := opts.Strategy.Indicators
+	cash, equity := opts.Capital, opts.Capital
+	open := make(map[string]*Trade) // Open positions by symbol
+	var pending []signal
+	lastClose := make(map[string]float64)
+
+	closePosition := func(p *Trade, t time.Time, price float64, reason string) {
+		p.ExitTime, p.ExitPrice, p.Reason = t, price, reason
+		p.PnL = (price - p.EntryPrice) * float64(p.Shares)
+		p.ReturnPct = (price/p.EntryPrice - 1) * 100
+		cash += price * float64(p.Shares)
+		report.Trades = append(report.Trades, *p)
+		delete(open, p.Symbol)
+	}
+
+	for n, day := range timeline {
+		// Fill yesterday's signals at today's open; a symbol without a bar
+		// today (a gap or a halt) misses its fill rather than holding a slot
+		for _, sig := range pending {
+			i, ok := sig.series.days[day]
+			if !ok {
+				continue
+			}
+			bar := sig.series.Bars[i]
+			if bar.Open <= 0 {
+				continue
+			}
+			alloc := math.Min(equity*opts.PositionPct/100, cash)
+			shares := int(math.Floor(alloc / bar.Open))
+			if shares < 1 {
+				continue
+			}
+			risk := analysis.CalculateSLTP(bar.Open, sig.atr, in.StopLossATR, in.TakeProfitATR)
+			cash -= bar.Open * float64(shares)
+			open[sig.series.Symbol] = &Trade{
+				Symbol: sig.series.Symbol, Score: sig.score,
+				EntryTime: bar.Time, EntryPrice: bar.Open, Shares: shares,
+				StopLoss: risk.StopLoss, TakeProfit: risk.TakeProfit,
+			}
+		}
+		pending = nil
+
+		// Check the open positions against today's bar
+		for _, s := range all {
+			i, ok := s.days[day]
+			if !ok {
+				continue
+			}
+			bar := s.Bars[i]
+			lastClose[s.Symbol] = bar.Close
+			p := open[s.Symbol]
+			if p == nil {
+				continue
+			}
+			p.Bars++
+			switch {
+			case bar.Low <= p.StopLoss:
+				closePosition(p, bar.Time, math.Min(bar.Open, p.StopLoss), ExitStop)
+			case bar.High >= p.TakeProfit:
+				closePosition(p, bar.Time, math.Max(bar.Open, p.TakeProfit), ExitTarget)
+			case opts.MaxHoldBars > 0 && p.Bars >= opts.MaxHoldBars:
+				closePosition(p, bar.Time, bar.Close, ExitTime)
+			}
+		}
+
+		equity = cash
+		for _, p := range open {
+			equity += lastClose[p.Symbol] * float64(p.Shares)
+		}
+		report.Equity = append(report.Equity, EquityPoint{Time: dayTime(day), Equity: equity})
+
+		// Look for entries to fill tomorrow
+		slots := opts.MaxPositions - len(open)
+		if slots <= 0 || n == len(timeline)-1 {
+			continue
+		}
+		var signals []signal
+		for _, s := range all {
+			i, ok := s.days[day]
+			if !ok || open[s.Symbol] != nil || i+1 < opts.minBars() {
+				continue
+			}
+			r := screener.CalculateMetricsWith(s.stockData(i, opts.window()), opts.Strategy, model)
+			if opts.Criteria.Matches(r) {
+				signals = append(signals, signal{series: s, score: r.ConfluenceScore, atr: r.ATR})
+			}
+		}
+		sort.SliceStable(signals, func(i, j int) bool { return signals[i].score > signals[j].score })
+		if len(signals) > slots {
+			signals = signals[:slots]
+		}
+		pending = signals
+	}
+
+	// Close what's left at the last close
+	end := report.Equity[len(report.Equity)-1].Time
+	for _, s := range all {
+		if p := open[s.Symbol]; p != nil {
+			closePosition(p, end, lastClose[s.Symbol], ExitEnd)
+		}
+	}
+
+	report.FinalEquity = equity
+	report.summarize()
+	return report, nil
+}
+
+// prepare indexes a series by trading day and splits out its price columns
+func prepare(d Series) *series {
+	bars := append([]fetcher.Bar(nil), d.Bars...)
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+	d.Bars = bars
+
+	s := &series{Series: d, days: make(map[string]int, len(bars))}
+	for i, b := range bars {
+		s.closes = append(s.closes, b.Close)
+		s.highs = append(s.highs, b.High)
+		s.lows = append(s.lows, b.Low)
+		s.days[b.Time.UTC().Format(dateLayout)] = i
+	}
+	return s
+}
+
+// stockData builds the data a scan would have seen at the close of bar i:
+// the trailing window of bars and the series' fundamentals
+func (s *series) stockData(i, window int) *fetcher.StockData {
+	start := i + 1 - window
+	if start < 0 {
+		start = 0
+	}
+	bar := s.Bars[i]
+	data := &fetcher.StockData{
+		Symbol:           s.Symbol,
+		Price:            bar.Close,
+		Volume:           bar.Volume,
+		Bars:             s.Bars[start : i+1],
+		HistoricalPrices: s.closes[start : i+1],
+		HistoricalCloses: s.closes[start : i+1],
+		HistoricalHighs:  s.highs[start : i+1],
+		HistoricalLows:   s.lows[start : i+1],
+	}
+	if i > 0 && s.closes[i-1] > 0 {
+		data.Change = bar.Close - s.closes[i-1]
+		data.ChangePercent = data.Change / s.closes[i-1] * 100
+	}
+	if f := s.Fundamentals; f != nil {
+		data.ShortName, data.Currency, data.Sector, data.Industry = f.ShortName, f.Currency, f.Sector, f.Industry
+		data.PERatio, data.EPS, data.BookValue = f.PERatio, f.EPS, f.BookValue
+		data.DividendYield, data.MarketCap, data.HasFundamentals = f.DividendYield, f.MarketCap, f.HasFundamentals
+	}
+	return data
+}
+
+// dayTime parses a trading day key
+func dayTime(day string) time.Time {
+	t, _ := time.Parse(dateLayout, day)
+	return t
+}
+
+// summarize computes the statistics of the trades and the equity curve
+func (r *Report) summarize() {
+	r.TotalReturn = (r.FinalEquity/r.Capital - 1) * 100
+
+	var grossWin, grossLoss float64
+	for _, t := range r.Trades {
+		if t.PnL > 0 {
+			r.Wins++
+			grossWin += t.PnL
+		} else {
+			r.Losses++
+			grossLoss -= t.PnL
+		}
+	}
+	if len(r.Trades) > 0 {
+		r.WinRate = float64(r.Wins) / float64(len(r.Trades)) * 100
+	}
+	if grossLoss > 0 {
+		r.ProfitFactor = grossWin / grossLoss
+	}
+
+	values := []float64{r.Capital}
+	for _, p := range r.Equity {
+		values = append(values, p.Equity)
+	}
+	r.MaxDrawdown = analysis.MaxDrawdown(values)
+
+	if years := r.Equity[len(r.Equity)-1].Time.Sub(r.Equity[0].Time).Hours() / 24 / 365.25; years > 0 && r.FinalEquity > 0 {
+		r.CAGR = (math.Pow(r.FinalEquity/r.Capital, 1/years) - 1) * 100
+	}
+
+	returns := make([]float64, 0, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		returns = append(returns, values[i]/values[i-1]-1)
+	}
+	var mean, variance float64
+	for _, x := range returns {
+		mean += x
+	}
+	mean /= float64(len(returns))
+	for _, x := range returns {
+		variance += (x - mean) * (x - mean)
+	}
+	if std := math.Sqrt(variance / float64(len(returns))); std > 0 {
+		r.Sharpe = mean / std * math.Sqrt(tradingDays)
+	}
+}
@@ -0,0 +1,353 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// day0 is the first bar of the synthetic series
+var day0 = time.Date(2024, 1, 1, 14, 30, 0, 0, time.UTC)
+
+// trend builds daily bars that are flat for flat bars, then move by step a day
+func trend(symbol string, flat, moving int, step float64) Series {
+	s := Series{Symbol: symbol}
+	price := 100.0
+	for i := 0; i < flat+moving; i++ {
+		open := price
+		if i >= flat {
+			price += step
+		} else if i%2 == 0 {
+			price += 0.5 // A little noise keeps the RSI defined
+		} else {
+			price -= 0.5
+		}
+		s.Bars = append(s.Bars, fetcher.Bar{
+			Time: day0.AddDate(0, 0, i), Open: open, Close: price,
+			High: math.Max(open, price) + 0.5, Low: math.Min(open, price) - 0.5, Volume: 1000,
+		})
+	}
+	return s
+}
+
+// openOptions buys every symbol from the first day of the flat period's end
+func openOptions(from, to int) Options {
+	opts := DefaultOptions(screener.DefaultStrategy())
+	opts.Criteria = screener.FilterCriteria{MaxRSI: 100, MaxPBV: 100, MinGrahamUpside: math.Inf(-1)}
+	opts.From, opts.To = day0.AddDate(0, 0, from), day0.AddDate(0, 0, to)
+	return opts
+}
+
+func TestRun(t *testing.T) {
+	up := trend("UP", 40, 30, 2)
+	down := trend("DOWN", 40, 30, -2)
+
+	report, err := Run([]Series{up, down}, openOptions(39, 69))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Equity) != 31 {
+		t.Fatalf("Expected an equity point per day, got %d", len(report.Equity))
+	}
+
+	first := make(map[string]Trade)
+	var pnl float64
+	for _, tr := range report.Trades {
+		if _, ok := first[tr.Symbol]; !ok {
+			first[tr.Symbol] = tr
+		}
+		pnl += tr.PnL
+		if !tr.ExitTime.After(tr.EntryTime) && tr.Reason != ExitStop && tr.Reason != ExitTarget {
+			t.Errorf("Trade exited before it was entered: %+v", tr)
+		}
+	}
+
+	// Signalled on day 39, bought at day 40's open, before the trends start
+	upTrade := first["UP"]
+	if !upTrade.EntryTime.Equal(up.Bars[40].Time) || upTrade.EntryPrice != up.Bars[40].Open {
+		t.Errorf("Expected UP to be bought at the next open, got %+v", upTrade)
+	}
+	if upTrade.Reason != ExitTarget || upTrade.ExitPrice != upTrade.TakeProfit || upTrade.PnL <= 0 {
+		t.Errorf("Expected UP to reach its take profit, got %+v", upTrade)
+	}
+	if downTrade := first["DOWN"]; downTrade.Reason != ExitStop || downTrade.PnL >= 0 {
+		t.Errorf("Expected DOWN to be stopped out, got %+v", downTrade)
+	}
+	// Each position gets 10% of equity
+	if shares := int(100000 * 0.1 / upTrade.EntryPrice); upTrade.Shares != shares {
+		t.Errorf("Expected %d shares, got %d", shares, upTrade.Shares)
+	}
+
+	if math.Abs(report.Capital+pnl-report.FinalEquity) > 1e-6 {
+		t.Errorf("Trade P&L %.2f doesn't add up to the final equity %.2f", pnl, report.FinalEquity)
+	}
+	if report.Wins == 0 || report.Losses == 0 || report.WinRate <= 0 || report.WinRate >= 100 {
+		t.Errorf("Expected wins and losses, got %d/%d (%.1f%%)", report.Wins, report.Losses, report.WinRate)
+	}
+	if report.MaxDrawdown <= 0 || report.Sharpe == 0 || report.CAGR == 0 {
+		t.Errorf("Expected drawdown, Sharpe and CAGR to be computed: %+v", report)
+	}
+}
+
+func TestRun_MaxHoldAndPositions(t *testing.T) {
+	flat := trend("FLAT", 70, 0, 0)
+	opts := openOptions(39, 69)
+	opts.MaxHoldBars = 5
+	report, err := Run([]Series{flat}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Trades) == 0 || report.Trades[0].Reason != ExitTime || report.Trades[0].Bars != 5 {
+		t.Errorf("Expected a position closed after 5 bars, got %+v", report.Trades)
+	}
+	if last := report.Trades[len(report.Trades)-1]; last.Reason != ExitEnd && last.Reason != ExitTime {
+		t.Errorf("Expected the last position to be closed at the end, got %+v", last)
+	}
+
+	// One slot: only the best scored of two symbols is held at a time
+	opts = openOptions(39, 69)
+	opts.MaxPositions = 1
+	report, err = Run([]Series{trend("A", 70, 0, 0), trend("B", 70, 0, 0)}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(report.Trades); i++ {
+		if report.Trades[i].EntryTime.Before(report.Trades[i-1].ExitTime) {
+			t.Errorf("Expected positions not to overlap: %+v", report.Trades)
+		}
+	}
+}
+
+func TestRun_MissingDay(t *testing.T) {
+	// A, the first of two equal signals on day 39, has no bar on day 40
+	a := trend("A", 70, 0, 0)
+	a.Bars = append(a.Bars[:40:40], a.Bars[41:]...)
+	b := trend("B", 70, 0, 0)
+
+	opts := openOptions(39, 69)
+	opts.MaxPositions = 1
+	report, err := Run([]Series{a, b}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Trades) == 0 {
+		t.Fatal("Expected trades after the missing day")
+	}
+	// A's signal is dropped, so the slot goes to B's signal from day 40
+	first := report.Trades[0]
+	if first.Symbol != "B" || !first.EntryTime.Equal(b.Bars[41].Time) {
+		t.Errorf("Expected B to be bought on day 41, got %+v", first)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	rank, err := screener.Compile("rank_score > 50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for want, change := range map[string]func(o *Options){
+		"start date":           func(o *Options) { o.To = o.From },
+		"position size":        func(o *Options) { o.PositionPct = 0 },
+		"max positions":        func(o *Options) { o.MaxPositions = 0 },
+		"whole scan":           func(o *Options) { o.Criteria.Expression = rank },
+		"capital must be":      func(o *Options) { o.Capital = -1 },
+		"must not be negative": func(o *Options) { o.Window = -1 },
+	} {
+		opts := openOptions(39, 69)
+		change(&opts)
+		if _, err := Run(nil, opts); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q, got %v", want, err)
+		}
+	}
+
+	if _, err := Run([]Series{trend("A", 10, 0, 0)}, openOptions(39, 69)); err == nil {
+		t.Error("Expected an error without bars in the period")
+	}
+}
+
+func TestLoad_Replay(t *testing.T) {
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	opts := DefaultOptions(screener.DefaultStrategy())
+	opts.Criteria.MinConfluence = 0
+	opts.From = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts.To = time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+
+	series, failed := Load(context.Background(), provider, []string{"SPY", "AAPL", "MSFT", "NOPE"}, opts, true)
+	if len(series) != 3 || series[0].Symbol != "AAPL" || failed["NOPE"] == nil {
+		t.Fatalf("Expected 3 recorded series and NOPE to fail, got %d, %v", len(series), failed)
+	}
+	if series[0].Fundamentals == nil || series[1].Fundamentals != nil {
+		t.Error("Expected only AAPL to have recorded fundamentals")
+	}
+
+	report, err := Run(series, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Equity) != len(series[0].Bars) {
+		t.Errorf("Expected an equity point per recorded bar, got %d", len(report.Equity))
+	}
+	warmup := series[0].Bars[opts.minBars()-1].Time
+	for _, tr := range report.Trades {
+		if !tr.EntryTime.After(warmup) {
+			t.Errorf("Trade entered before the first full window: %+v", tr)
+		}
+	}
+}
+
+// wave builds daily bars oscillating around a rising trend
+func wave(symbol string, n int, phase float64) Series {
+	s := Series{Symbol: symbol}
+	prev := 100.0
+	for i := 0; i < n; i++ {
+		price := 100 + float64(i)*0.1 + 8*math.Sin(float64(i)/5+phase)
+		s.Bars = append(s.Bars, fetcher.Bar{
+			Time: day0.AddDate(0, 0, i), Open: prev, Close: price,
+			High: math.Max(prev, price) + 1, Low: math.Min(prev, price) - 1, Volume: 1000,
+		})
+		prev = price
+	}
+	return s
+}
+
+func TestOptimize(t *testing.T) {
+	data := []Series{wave("A", 160, 0), wave("B", 160, 2), wave("C", 160, 4)}
+	opts := openOptions(40, 159)
+
+	oo := DefaultOptimizeOptions()
+	oo.Search = SearchGrid
+	oo.Folds, oo.InSample, oo.MinTrades = 2, 2, 1
+	oo.Params = []Param{
+		{"stop_loss_atr", []float64{1, 2}},
+		{"take_profit_atr", []float64{1.5, 3}},
+		{"rsi_period", []float64{14}},
+	}
+	var calls, total int
+	result, err := Optimize(data, opts, oo, func(done, n int) { calls, total = done, n })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The strategy's own values (2 and 3 ATRs) are in the grid and aren't tried twice
+	if result.Candidates != 4 {
+		t.Errorf("Expected the 4 grid combinations, got %d", result.Candidates)
+	}
+	// Each candidate on 3 in-sample windows, plus the best and the own values per fold
+	if calls != total || total != 4*3+2*2 {
+		t.Errorf("Expected %d backtests to be reported, got %d of %d", 4*3+2*2, calls, total)
+	}
+	if len(result.Folds) != 2 {
+		t.Fatalf("Expected 2 folds, got %d", len(result.Folds))
+	}
+	for i, f := range result.Folds {
+		if !f.InTo.Before(f.OutFrom) || f.Best == nil {
+			t.Errorf("Fold %d: in-sample must end before out-of-sample starts: %+v", i, f)
+		}
+		if i > 0 && !result.Folds[i-1].OutTo.Before(f.OutFrom) {
+			t.Errorf("Fold %d overlaps the previous out-of-sample window", i)
+		}
+	}
+	if last := result.Folds[1]; !last.OutTo.Equal(opts.To) {
+		t.Errorf("Expected the last fold to end with the period, got %v", last.OutTo)
+	}
+
+	if result.Winner == nil || result.Indicators.StopLossATR != result.Winner["stop_loss_atr"] {
+		t.Errorf("Expected the winner to be applied to the indicators: %v %+v", result.Winner, result.Indicators)
+	}
+	if len(result.Stability) != 2 || result.Stability[0].Name != "stop_loss_atr" || len(result.Stability[0].Chosen) != 2 {
+		t.Errorf("Expected stability of the two searched parameters, got %+v", result.Stability)
+	}
+}
+
+func TestOptimize_SkipsFoldsWithoutBars(t *testing.T) {
+	// No bars for days 99 to 128: the first fold's out-of-sample window
+	var data []Series
+	for i, symbol := range []string{"A", "B"} {
+		s := wave(symbol, 160, float64(i)*2)
+		bars := s.Bars[:0]
+		for _, b := range s.Bars {
+			if d := int(b.Time.Sub(day0).Hours() / 24); d < 99 || d > 128 {
+				bars = append(bars, b)
+			}
+		}
+		s.Bars = bars
+		data = append(data, s)
+	}
+	opts := openOptions(40, 159)
+
+	oo := DefaultOptimizeOptions()
+	oo.Search = SearchGrid
+	oo.Folds, oo.InSample, oo.MinTrades = 2, 2, 0
+	oo.Params = []Param{{"stop_loss_atr", []float64{1, 2}}}
+	var calls, total int
+	result, err := Optimize(data, opts, oo, func(done, n int) { calls, total = done, n })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Folds) != 1 || result.Folds[0].Number != 2 {
+		t.Fatalf("Expected only fold 2 to run, got %+v", result.Folds)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Number != 1 || !strings.Contains(result.Skipped[0].Reason, "out of sample") {
+		t.Errorf("Expected fold 1 to be skipped for lack of out-of-sample bars, got %+v", result.Skipped)
+	}
+	if calls != total {
+		t.Errorf("Expected progress to reach the total, got %d of %d", calls, total)
+	}
+
+	// Without bars in any fold there is nothing to report
+	opts = openOptions(200, 400)
+	if _, err := Optimize(data, opts, oo, nil); err == nil || !strings.Contains(err.Error(), "no fold") {
+		t.Errorf("Expected an error without bars in any fold, got %v", err)
+	}
+}
+
+func TestOptimizeOptions(t *testing.T) {
+	p, err := ParseParam("rsi_period=7, 14,21")
+	if err != nil || p.Name != "rsi_period" || len(p.Values) != 3 || p.Values[1] != 14 {
+		t.Errorf("ParseParam = %+v, %v", p, err)
+	}
+	for spec, want := range map[string]string{
+		"rsi_period":       "expected name=value",
+		"beta=1":           "unknown parameter",
+		"bb_stddev=2,wide": "not a positive number",
+		"rsi_period=7.5":   "not a whole number",
+	} {
+		if _, err := ParseParam(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseParam(%q) = %v, want an error containing %q", spec, err, want)
+		}
+	}
+
+	// Random search is repeatable and skips invalid MACD combinations
+	oo := DefaultOptimizeOptions()
+	oo.Params = []Param{{"macd_fast", []float64{8, 12, 30}}, {"macd_slow", []float64{21, 26}}}
+	oo.Samples = 20
+	in := screener.DefaultIndicators()
+	first, _ := oo.candidates(in)
+	second, _ := oo.candidates(in)
+	if len(first) != 4 || fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("Expected the 4 valid combinations, own values first, repeatably: %v / %v", first, second)
+	}
+	for _, set := range first {
+		if set["macd_fast"] >= set["macd_slow"] {
+			t.Errorf("Invalid combination tried: %v", set)
+		}
+	}
+
+	oo.Search = SearchGrid
+	oo.Params = DefaultParams()
+	oo.Params[0].Values = make([]float64, 100)
+	if _, err := oo.candidates(in); err == nil || !strings.Contains(err.Error(), "grid") {
+		t.Errorf("Expected an oversized grid to be rejected, got %v", err)
+	}
+	oo.Objective = "profit"
+	if err := oo.Validate(); err == nil || !strings.Contains(err.Error(), "objective") {
+		t.Errorf("Expected an unknown objective to be rejected, got %v", err)
+	}
+}
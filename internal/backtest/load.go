@@ -0,0 +1,95 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+)
+
+// loadWorkers is how many symbols are fetched concurrently
+const loadWorkers = 8
+
+// errNoBars is returned for symbols without price history
+var errNoBars = errors.New("no price history")
+
+// historyStart returns when the bars of a backtest must start: a full
+// metrics window before opts.From
+func historyStart(opts Options) time.Time {
+	return opts.From.AddDate(0, 0, -fetcher.LookbackDays(opts.window(), fetcher.Interval1d))
+}
+
+// Load fetches the daily bars of symbols from a provider, reaching back far
+// enough to fill the first window. The active provider serves them from the
+// bar cache or, with --replay, from recordings. With fundamentals, each
+// symbol's current fundamentals are fetched too (see Series). Symbols that
+// can't be loaded are returned in failed.
+func Load(ctx context.Context, p fetcher.Provider, symbols []string, opts Options, fundamentals bool) ([]Series, map[string]error) {
+	days := int(time.Since(historyStart(opts)).Hours()/24) + 1
+	return load(symbols, func(symbol string) (Series, error) {
+		data, err := p.FetchHistorical(ctx, symbol, days, fetcher.Interval1d)
+		if err != nil {
+			return Series{}, err
+		}
+		s := Series{Symbol: symbol, Bars: data.Bars}
+		if fundamentals {
+			// Best effort: without fundamentals valuation is left out of the score
+			if f, err := p.FetchFundamentals(ctx, symbol); err == nil && f != nil {
+				f.HasFundamentals = f.HasValuation()
+				s.Fundamentals = f
+			}
+		}
+		return s, nil
+	})
+}
+
+// LoadCached reads the daily bars of symbols from the bar cache without any
+// network access. Symbols that aren't cached are returned in failed.
+func LoadCached(cache *fetcher.BarCache, symbols []string, opts Options) ([]Series, map[string]error) {
+	start := historyStart(opts)
+	return load(symbols, func(symbol string) (Series, error) {
+		data, err := cache.History(symbol, fetcher.Interval1d, start)
+		if err != nil {
+			return Series{}, err
+		}
+		return Series{Symbol: symbol, Bars: data.Bars}, nil
+	})
+}
+
+// load runs fetch for every symbol concurrently; the series come back in
+// symbol order
+func load(symbols []string, fetch func(symbol string) (Series, error)) ([]Series, map[string]error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		loaded []Series
+		failed = make(map[string]error)
+		sem    = make(chan struct{}, loadWorkers)
+	)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s, err := fetch(symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil && len(s.Bars) == 0 {
+				err = errNoBars
+			}
+			if err != nil {
+				failed[symbol] = err
+				return
+			}
+			loaded = append(loaded, s)
+		}(symbol)
+	}
+	wg.Wait()
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Symbol < loaded[j].Symbol })
+	return loaded, failed
+}
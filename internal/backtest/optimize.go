@@ -0,0 +1,544 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// Search methods of the optimiser
+const (
+	SearchGrid   = "grid"   // Every combination of the parameter values
+	SearchRandom = "random" // Samples random combinations
+)
+
+// Objectives the optimiser maximises
+const (
+	ObjectiveSharpe = "sharpe"
+	ObjectiveCAGR   = "cagr"
+	ObjectiveReturn = "return"
+)
+
+// maxGrid is the most combinations a grid search runs
+const maxGrid = 5000
+
+// paramSetters set an indicator parameter, by its strategies.yaml name
+var paramSetters = map[string]func(in *screener.Indicators, v float64){
+	"rsi_period":      func(in *screener.Indicators, v float64) { in.RSIPeriod = int(v) },
+	"bb_period":       func(in *screener.Indicators, v float64) { in.BBPeriod = int(v) },
+	"bb_stddev":       func(in *screener.Indicators, v float64) { in.BBStdDev = v },
+	"macd_fast":       func(in *screener.Indicators, v float64) { in.MACDFast = int(v) },
+	"macd_slow":       func(in *screener.Indicators, v float64) { in.MACDSlow = int(v) },
+	"macd_signal":     func(in *screener.Indicators, v float64) { in.MACDSignal = int(v) },
+	"stop_loss_atr":   func(in *screener.Indicators, v float64) { in.StopLossATR = v },
+	"take_profit_atr": func(in *screener.Indicators, v float64) { in.TakeProfitATR = v },
+}
+
+// intParams are the parameters that only take whole numbers
+var intParams = map[string]bool{"rsi_period": true, "bb_period": true, "macd_fast": true, "macd_slow": true, "macd_signal": true}
+
+// paramNames orders the tunable parameters
+var paramNames = []string{"rsi_period", "bb_period", "bb_stddev", "macd_fast", "macd_slow", "macd_signal", "stop_loss_atr", "take_profit_atr"}
+
+// paramValue reads an indicator parameter
+func paramValue(in screener.Indicators, name string) float64 {
+	switch name {
+	case "rsi_period":
+		return float64(in.RSIPeriod)
+	case "bb_period":
+		return float64(in.BBPeriod)
+	case "bb_stddev":
+		return in.BBStdDev
+	case "macd_fast":
+		return float64(in.MACDFast)
+	case "macd_slow":
+		return float64(in.MACDSlow)
+	case "macd_signal":
+		return float64(in.MACDSignal)
+	case "stop_loss_atr":
+		return in.StopLossATR
+	default:
+		return in.TakeProfitATR
+	}
+}
+
+// Param is a tunable indicator parameter and the values to try
+type Param struct {
+	Name   string
+	Values []float64
+}
+
+// DefaultParams returns the values searched when none are given: the
+// RSI period, Bollinger bands, MACD and SL/TP multipliers around their defaults
+func DefaultParams() []Param {
+	return []Param{
+		{"rsi_period", []float64{7, 10, 14, 21}},
+		{"bb_period", []float64{15, 20, 25}},
+		{"bb_stddev", []float64{1.5, 2, 2.5}},
+		{"macd_fast", []float64{8, 12}},
+		{"macd_slow", []float64{21, 26}},
+		{"macd_signal", []float64{7, 9}},
+		{"stop_loss_atr", []float64{1.5, 2, 2.5, 3}},
+		{"take_profit_atr", []float64{2, 3, 4, 5}},
+	}
+}
+
+// ParseParam parses a parameter and its values, e.g. "rsi_period=7,14,21"
+func ParseParam(spec string) (Param, error) {
+	name, list, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok {
+		return Param{}, fmt.Errorf("expected name=value,value... in %q", spec)
+	}
+	if _, known := paramSetters[name]; !known {
+		return Param{}, fmt.Errorf("unknown parameter %q (available: %s)", name, strings.Join(paramNames, ", "))
+	}
+	p := Param{Name: name}
+	for _, field := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v <= 0 {
+			return Param{}, fmt.Errorf("%s: %q is not a positive number", name, field)
+		}
+		if intParams[name] && v != math.Trunc(v) {
+			return Param{}, fmt.Errorf("%s: %q is not a whole number", name, field)
+		}
+		p.Values = append(p.Values, v)
+	}
+	return p, nil
+}
+
+// ParamSet is one combination of parameter values
+type ParamSet map[string]float64
+
+// Apply returns the indicators with the set's values
+func (p ParamSet) Apply(in screener.Indicators) screener.Indicators {
+	for name, v := range p {
+		paramSetters[name](&in, v)
+	}
+	return in
+}
+
+// String lists the values in parameter order, e.g. "rsi_period=10 stop_loss_atr=2.5"
+func (p ParamSet) String() string {
+	var parts []string
+	for _, name := range paramNames {
+		if v, ok := p[name]; ok {
+			parts = append(parts, name+"="+strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// OptimizeOptions configures a walk-forward optimisation
+type OptimizeOptions struct {
+	Params    []Param
+	Search    string // SearchGrid or SearchRandom
+	Samples   int    // Combinations tried by a random search
+	Seed      int64  // Random search seed, for repeatable runs
+	Folds     int    // Out-of-sample windows
+	InSample  int    // Length of each in-sample window, in out-of-sample windows
+	Objective string // What the in-sample search maximises
+	MinTrades int    // Combinations with fewer in-sample trades rank last
+}
+
+// DefaultOptimizeOptions tries 50 random combinations of the default
+// parameters, maximising the Sharpe ratio over 4 folds with in-sample
+// windows 3 times as long as the out-of-sample ones
+func DefaultOptimizeOptions() OptimizeOptions {
+	return OptimizeOptions{
+		Params:    DefaultParams(),
+		Search:    SearchRandom,
+		Samples:   50,
+		Seed:      1,
+		Folds:     4,
+		InSample:  3,
+		Objective: ObjectiveSharpe,
+		MinTrades: 5,
+	}
+}
+
+// Validate checks the options
+func (o OptimizeOptions) Validate() error {
+	if len(o.Params) == 0 {
+		return fmt.Errorf("no parameters to optimise")
+	}
+	seen := make(map[string]bool)
+	for _, p := range o.Params {
+		if _, ok := paramSetters[p.Name]; !ok {
+			return fmt.Errorf("unknown parameter %q (available: %s)", p.Name, strings.Join(paramNames, ", "))
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("parameter %s is given twice", p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Values) == 0 {
+			return fmt.Errorf("parameter %s has no values", p.Name)
+		}
+		for _, v := range p.Values {
+			if intParams[p.Name] && v != math.Trunc(v) {
+				return fmt.Errorf("parameter %s takes whole numbers, not %g", p.Name, v)
+			}
+		}
+	}
+	switch o.Search {
+	case SearchGrid:
+	case SearchRandom:
+		if o.Samples < 1 {
+			return fmt.Errorf("a random search needs at least 1 sample")
+		}
+	default:
+		return fmt.Errorf("unknown search %q (available: %s, %s)", o.Search, SearchGrid, SearchRandom)
+	}
+	switch o.Objective {
+	case ObjectiveSharpe, ObjectiveCAGR, ObjectiveReturn:
+	default:
+		return fmt.Errorf("unknown objective %q (available: %s, %s, %s)", o.Objective, ObjectiveSharpe, ObjectiveCAGR, ObjectiveReturn)
+	}
+	if o.Folds < 1 || o.InSample < 1 {
+		return fmt.Errorf("folds and in-sample length must be at least 1")
+	}
+	if o.MinTrades < 0 {
+		return fmt.Errorf("min trades must not be negative")
+	}
+	return nil
+}
+
+// objective returns the value of a report the search maximises
+func (o OptimizeOptions) objective(r *Report) float64 {
+	switch o.Objective {
+	case ObjectiveCAGR:
+		return r.CAGR
+	case ObjectiveReturn:
+		return r.TotalReturn
+	default:
+		return r.Sharpe
+	}
+}
+
+// candidates returns the combinations to try, starting with the strategy's
+// own values; combinations with invalid indicators (e.g. macd_fast not
+// below macd_slow) are left out
+func (o OptimizeOptions) candidates(base screener.Indicators) ([]ParamSet, error) {
+	own := make(ParamSet)
+	for _, p := range o.Params {
+		own[p.Name] = paramValue(base, p.Name)
+	}
+	list := []ParamSet{own}
+	seen := map[string]bool{own.String(): true}
+	add := func(set ParamSet) {
+		if key := set.String(); !seen[key] && set.Apply(base).Validate() == nil {
+			seen[key] = true
+			list = append(list, set)
+		}
+	}
+
+	if o.Search == SearchGrid {
+		total := 1
+		for _, p := range o.Params {
+			total *= len(p.Values)
+			if total > maxGrid {
+				return nil, fmt.Errorf("the grid has over %d combinations; give fewer values or use a random search", maxGrid)
+			}
+		}
+		for i := 0; i < total; i++ {
+			set := make(ParamSet)
+			n := i
+			for _, p := range o.Params {
+				set[p.Name] = p.Values[n%len(p.Values)]
+				n /= len(p.Values)
+			}
+			add(set)
+		}
+		return list, nil
+	}
+
+	rng := rand.New(rand.NewSource(o.Seed))
+	for tries := 0; len(list) < o.Samples+1 && tries < o.Samples*20; tries++ {
+		set := make(ParamSet)
+		for _, p := range o.Params {
+			set[p.Name] = p.Values[rng.Intn(len(p.Values))]
+		}
+		add(set)
+	}
+	return list, nil
+}
+
+// Fold is one walk-forward step: the best combination on the in-sample
+// window, tested on the following out-of-sample window
+type Fold struct {
+	Number         int // 1-based position among the folds, skipped ones included
+	InFrom, InTo   time.Time
+	OutFrom, OutTo time.Time
+	Best           ParamSet
+	InObjective    float64 // Best in-sample objective
+	OutObjective   float64 // Objective of Best out of sample
+	OutReturn      float64 // Total return of Best out of sample, in percent
+	OutTrades      int
+	BaseObjective  float64 // Out-of-sample objective of the strategy's own values
+}
+
+// SkippedFold is a walk-forward step left out for lack of bars
+type SkippedFold struct {
+	Number         int // 1-based position among the folds
+	InFrom, InTo   time.Time
+	OutFrom, OutTo time.Time
+	Reason         string
+}
+
+// Stability shows how consistently a parameter was chosen across folds
+type Stability struct {
+	Name      string
+	Chosen    []float64 // Value chosen in each fold
+	Mode      float64   // Most often chosen value
+	ModeShare float64   // Percent of folds that chose Mode
+	Spread    float64   // Standard deviation of the chosen values relative to their mean, in percent
+}
+
+// Optimization is the outcome of a walk-forward optimisation
+type Optimization struct {
+	Strategy   string
+	Objective  string
+	Candidates int
+	Folds      []Fold
+	Skipped    []SkippedFold // Folds whose windows had no bars
+	Stability  []Stability
+	// Winner is the best combination on the latest in-sample window, ending
+	// with the period, and Indicators the strategy's indicators with it
+	Winner       ParamSet
+	WinnerScore  float64
+	Indicators   screener.Indicators
+	OutReturn    float64 // Out-of-sample returns of all folds compounded, in percent
+	BaseReturn   float64 // The same for the strategy's own values
+	Efficiency   float64 // Mean out-of-sample / mean in-sample objective; 0 when in-sample isn't positive
+	OutObjective float64 // Mean out-of-sample objective
+}
+
+// Optimize runs a walk-forward optimisation over opts.From to opts.To. The
+// period is split into Folds out-of-sample windows, each preceded by an
+// in-sample window InSample times as long. Each combination is backtested
+// on every in-sample window; the best one is then backtested on the
+// following out-of-sample window. Folds with a window without bars, e.g.
+// before the history of every symbol starts, are skipped and reported in
+// Skipped. progress, if set, is called after each backtest.
+func Optimize(data []Series, opts Options, oo OptimizeOptions, progress func(done, total int)) (*Optimization, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := oo.Validate(); err != nil {
+		return nil, err
+	}
+	candidates, err := oo.candidates(opts.Strategy.Indicators)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := opts.To.Sub(opts.From) / time.Duration(oo.Folds+oo.InSample)
+	if unit < 7*24*time.Hour {
+		return nil, fmt.Errorf("the period is too short for %d folds with %d× in-sample windows", oo.Folds, oo.InSample)
+	}
+	window := func(from time.Time, units int) Options {
+		o := opts
+		o.From = from
+		// Windows don't share their boundary day
+		o.To = from.Add(time.Duration(units)*unit).AddDate(0, 0, -1)
+		return o
+	}
+
+	total := len(candidates)*(oo.Folds+1) + 2*oo.Folds
+	done := 0
+	step := func() {
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	result := &Optimization{Strategy: opts.Strategy.Name, Objective: oo.Objective, Candidates: len(candidates)}
+	outGrowth, baseGrowth := 1.0, 1.0
+	var inSum, outSum float64
+	for i := 0; i < oo.Folds; i++ {
+		in := window(opts.From.Add(time.Duration(i)*unit), oo.InSample)
+		out := window(in.To.AddDate(0, 0, 1), 1)
+		if i == oo.Folds-1 {
+			out.To = opts.To
+		}
+		reason := ""
+		switch {
+		case !hasBars(data, in.From, in.To):
+			reason = "no bars in sample"
+		case !hasBars(data, out.From, out.To):
+			reason = "no bars out of sample"
+		}
+		if reason != "" {
+			result.Skipped = append(result.Skipped, SkippedFold{
+				Number: i + 1, InFrom: in.From, InTo: in.To, OutFrom: out.From, OutTo: out.To, Reason: reason,
+			})
+			for n := 0; n < len(candidates)+2; n++ {
+				step()
+			}
+			continue
+		}
+
+		best, score, err := searchWindow(data, in, oo, candidates, step)
+		if err != nil {
+			return nil, err
+		}
+		outReport, err := runWith(data, out, best)
+		step()
+		if err != nil {
+			return nil, err
+		}
+		baseReport, err := runWith(data, out, candidates[0])
+		step()
+		if err != nil {
+			return nil, err
+		}
+
+		fold := Fold{
+			Number: i + 1, InFrom: in.From, InTo: in.To, OutFrom: out.From, OutTo: out.To,
+			Best: best, InObjective: score,
+			OutObjective: oo.objective(outReport), OutReturn: outReport.TotalReturn, OutTrades: len(outReport.Trades),
+			BaseObjective: oo.objective(baseReport),
+		}
+		result.Folds = append(result.Folds, fold)
+		outGrowth *= 1 + outReport.TotalReturn/100
+		baseGrowth *= 1 + baseReport.TotalReturn/100
+		inSum += fold.InObjective
+		outSum += fold.OutObjective
+	}
+
+	if len(result.Folds) == 0 {
+		return nil, fmt.Errorf("no fold has bars both in and out of sample between %s and %s",
+			opts.From.Format(dateLayout), opts.To.Format(dateLayout))
+	}
+
+	// The combination to trade now: the best on the most recent data
+	latest := opts
+	latest.From = opts.To.Add(-time.Duration(oo.InSample) * unit)
+	if result.Winner, result.WinnerScore, err = searchWindow(data, latest, oo, candidates, step); err != nil {
+		return nil, err
+	}
+	result.Indicators = result.Winner.Apply(opts.Strategy.Indicators)
+
+	result.OutReturn = (outGrowth - 1) * 100
+	result.BaseReturn = (baseGrowth - 1) * 100
+	result.OutObjective = outSum / float64(len(result.Folds))
+	if inSum > 0 {
+		result.Efficiency = outSum / inSum
+	}
+	result.Stability = stability(oo.Params, result.Folds)
+	return result, nil
+}
+
+// hasBars reports whether any series has a bar between from and to
+func hasBars(data []Series, from, to time.Time) bool {
+	first, last := from.UTC().Format(dateLayout), to.UTC().Format(dateLayout)
+	for _, d := range data {
+		for _, b := range d.Bars {
+			if day := b.Time.UTC().Format(dateLayout); day >= first && day <= last {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runWith backtests a window with a combination of parameter values
+func runWith(data []Series, opts Options, set ParamSet) (*Report, error) {
+	strategy := *opts.Strategy
+	strategy.Indicators = set.Apply(strategy.Indicators)
+	opts.Strategy = &strategy
+	return Run(data, opts)
+}
+
+// searchWindow backtests every candidate on a window in parallel and
+// returns the best by objective, preferring those with enough trades and
+// then the earlier candidates
+func searchWindow(data []Series, opts Options, oo OptimizeOptions, candidates []ParamSet, step func()) (ParamSet, float64, error) {
+	type outcome struct {
+		score  float64
+		enough bool
+		err    error
+	}
+	outcomes := make([]outcome, len(candidates))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r, err := runWith(data, opts, candidates[i])
+				if err != nil {
+					outcomes[i] = outcome{err: err}
+				} else {
+					outcomes[i] = outcome{score: oo.objective(r), enough: len(r.Trades) >= oo.MinTrades}
+				}
+				mu.Lock()
+				step()
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	best := -1
+	for i, o := range outcomes {
+		if o.err != nil {
+			return nil, 0, o.err
+		}
+		if best < 0 || (o.enough && !outcomes[best].enough) ||
+			(o.enough == outcomes[best].enough && o.score > outcomes[best].score) {
+			best = i
+		}
+	}
+	return candidates[best], outcomes[best].score, nil
+}
+
+// stability summarises the values chosen for each searched parameter
+func stability(params []Param, folds []Fold) []Stability {
+	var list []Stability
+	for _, p := range params {
+		if len(p.Values) < 2 {
+			continue
+		}
+		s := Stability{Name: p.Name}
+		counts := make(map[float64]int)
+		var sum float64
+		for _, f := range folds {
+			v := f.Best[p.Name]
+			s.Chosen = append(s.Chosen, v)
+			counts[v]++
+			sum += v
+		}
+		for _, v := range s.Chosen {
+			if counts[v] > counts[s.Mode] {
+				s.Mode = v
+			}
+		}
+		s.ModeShare = float64(counts[s.Mode]) / float64(len(folds)) * 100
+
+		mean := sum / float64(len(folds))
+		var variance float64
+		for _, v := range s.Chosen {
+			variance += (v - mean) * (v - mean)
+		}
+		if mean > 0 {
+			s.Spread = math.Sqrt(variance/float64(len(folds))) / mean * 100
+		}
+		list = append(list, s)
+	}
+	return list
+}
@@ -0,0 +1,342 @@
+package calendar
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Exchange time zones must resolve on systems without zoneinfo
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// Market states, matching the ones Yahoo reports
+const (
+	StatePre     = "PRE"
+	StateRegular = "REGULAR"
+	StatePost    = "POST"
+	StateClosed  = "CLOSED"
+)
+
+// DefaultName is the calendar used when an exchange has none (NYSE/Nasdaq)
+const DefaultName = "US"
+
+// maxSearchDays bounds the search for the next session (long holiday runs)
+const maxSearchDays = 14
+
+//go:embed data/calendars.yaml
+var builtinData embed.FS
+
+// Calendar is the trading calendar of one or more exchanges sharing
+// session hours and holidays. Times are exchange local ("09:30").
+type Calendar struct {
+	Name         string            `yaml:"name"`
+	Description  string            `yaml:"description,omitempty"`
+	Timezone     string            `yaml:"timezone"`
+	Exchanges    []string          `yaml:"exchanges"` // Yahoo exchange codes and names
+	Pre          string            `yaml:"pre,omitempty"`
+	Open         string            `yaml:"open"`
+	Close        string            `yaml:"close"`
+	Post         string            `yaml:"post,omitempty"`
+	HalfDayClose string            `yaml:"half_day_close,omitempty"`
+	Holidays     map[string]string `yaml:"holidays,omitempty"`  // Date (2006-01-02) -> name
+	HalfDays     map[string]string `yaml:"half_days,omitempty"` // Early close date -> name
+
+	loc *time.Location
+
+	// Session times as offsets from local midnight
+	pre, open, close, post, halfClose time.Duration
+}
+
+// Session is one trading day of a calendar
+type Session struct {
+	Date    time.Time // Local midnight
+	PreOpen time.Time // Equal to Open without a pre-market session
+	Open    time.Time
+	Close   time.Time
+	PostEnd time.Time // Equal to Close without an after-hours session
+	HalfDay string    // Name of the early close, "" on full days
+}
+
+// State returns the market state at t within the session's day
+func (s Session) State(t time.Time) string {
+	switch {
+	case t.Before(s.PreOpen) || !t.Before(s.PostEnd):
+		return StateClosed
+	case t.Before(s.Open):
+		return StatePre
+	case t.Before(s.Close):
+		return StateRegular
+	default:
+		return StatePost
+	}
+}
+
+// Location returns the calendar's time zone
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// init validates the calendar and resolves its time zone and hours
+func (c *Calendar) init() error {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fmt.Errorf("calendar %s: %v", c.Name, err)
+	}
+	c.loc = loc
+
+	parse := func(field, value string, fallback time.Duration) (time.Duration, error) {
+		if value == "" {
+			return fallback, nil
+		}
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			return 0, fmt.Errorf("calendar %s: bad %s time %q", c.Name, field, value)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+
+	if c.open, err = parse("open", c.Open, -1); err != nil {
+		return err
+	}
+	if c.close, err = parse("close", c.Close, -1); err != nil {
+		return err
+	}
+	if c.open < 0 || c.close <= c.open {
+		return fmt.Errorf("calendar %s: needs open and close times", c.Name)
+	}
+	if c.pre, err = parse("pre", c.Pre, c.open); err != nil {
+		return err
+	}
+	if c.post, err = parse("post", c.Post, c.close); err != nil {
+		return err
+	}
+	if c.halfClose, err = parse("half_day_close", c.HalfDayClose, c.close); err != nil {
+		return err
+	}
+
+	for date := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("calendar %s: bad holiday date %q", c.Name, date)
+		}
+	}
+	for date := range c.HalfDays {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("calendar %s: bad half day date %q", c.Name, date)
+		}
+	}
+	return nil
+}
+
+// midnight returns local midnight of the day containing t
+func (c *Calendar) midnight(t time.Time) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+}
+
+// at returns the wall clock time offset after local midnight of day
+// (correct across DST changes)
+func (c *Calendar) at(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(offset.Minutes()), 0, 0, c.loc)
+}
+
+// Holiday returns the name of the holiday on t's local day, if any
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	name, ok := c.Holidays[t.In(c.loc).Format("2006-01-02")]
+	return name, ok
+}
+
+// SessionOn returns the session on t's local day; false on weekends and holidays
+func (c *Calendar) SessionOn(t time.Time) (Session, bool) {
+	day := c.midnight(t)
+	if wd := day.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return Session{}, false
+	}
+	key := day.Format("2006-01-02")
+	if _, ok := c.Holidays[key]; ok {
+		return Session{}, false
+	}
+
+	s := Session{
+		Date:    day,
+		PreOpen: c.at(day, c.pre),
+		Open:    c.at(day, c.open),
+		Close:   c.at(day, c.close),
+		PostEnd: c.at(day, c.post),
+	}
+	if name, ok := c.HalfDays[key]; ok {
+		s.HalfDay = name
+		s.Close = c.at(day, c.halfClose)
+		// Extended hours keep their length after an early close
+		s.PostEnd = s.Close.Add(c.post - c.close)
+	}
+	return s, true
+}
+
+// State returns the market state at t: PRE, REGULAR, POST or CLOSED
+func (c *Calendar) State(t time.Time) string {
+	s, ok := c.SessionOn(t)
+	if !ok {
+		return StateClosed
+	}
+	return s.State(t)
+}
+
+// IsOpen reports whether the regular session is running at t
+func (c *Calendar) IsOpen(t time.Time) bool {
+	return c.State(t) == StateRegular
+}
+
+// NextSession returns the first session whose regular close is after t:
+// the running session during trading hours, else the next one
+func (c *Calendar) NextSession(t time.Time) (Session, bool) {
+	day := c.midnight(t)
+	for i := 0; i <= maxSearchDays; i++ {
+		if s, ok := c.SessionOn(day.AddDate(0, 0, i)); ok && s.Close.After(t) {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// LastSession returns the latest session whose regular open is at or before t
+func (c *Calendar) LastSession(t time.Time) (Session, bool) {
+	day := c.midnight(t)
+	for i := 0; i <= maxSearchDays; i++ {
+		if s, ok := c.SessionOn(day.AddDate(0, 0, -i)); ok && !s.Open.After(t) {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// NextOpen returns the next regular session open after t
+func (c *Calendar) NextOpen(t time.Time) (time.Time, bool) {
+	s, ok := c.NextSession(t)
+	if !ok {
+		return time.Time{}, false
+	}
+	if !s.Open.After(t) {
+		// In the session already: the open after it
+		if s, ok = c.NextSession(s.Close); !ok {
+			return time.Time{}, false
+		}
+	}
+	return s.Open, true
+}
+
+// NextClose returns the close of the running or next regular session
+func (c *Calendar) NextClose(t time.Time) (time.Time, bool) {
+	s, ok := c.NextSession(t)
+	return s.Close, ok
+}
+
+var (
+	loadOnce  sync.Once
+	calendars []*Calendar
+	loadErr   error
+)
+
+// All returns the builtin calendars, with calendars of the same name from
+// config/calendars.yaml replacing them and new ones appended
+func All() []*Calendar {
+	loadOnce.Do(func() {
+		data, _ := builtinData.ReadFile("data/calendars.yaml")
+		calendars, loadErr = parse(data)
+
+		user, err := os.ReadFile(config.Path("calendars.yaml"))
+		if err != nil {
+			return
+		}
+		overrides, err := parse(user)
+		if err != nil {
+			loadErr = fmt.Errorf("%s: %v", config.Path("calendars.yaml"), err)
+			return
+		}
+		for _, o := range overrides {
+			replaced := false
+			for i, c := range calendars {
+				if strings.EqualFold(c.Name, o.Name) {
+					calendars[i] = o
+					replaced = true
+				}
+			}
+			if !replaced {
+				calendars = append(calendars, o)
+			}
+		}
+	})
+	return calendars
+}
+
+// LoadError returns the error, if any, from reading config/calendars.yaml
+func LoadError() error {
+	All()
+	return loadErr
+}
+
+// parse reads a list of calendars
+func parse(data []byte) ([]*Calendar, error) {
+	var list []*Calendar
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, c := range list {
+		if err := c.init(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// Lookup returns the calendar with the given name
+func Lookup(name string) (*Calendar, bool) {
+	for _, c := range All() {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ForExchange returns the calendar of a Yahoo exchange code or name
+// ("NMS", "NasdaqGS", "LSE"); false if no calendar covers it
+func ForExchange(exchange string) (*Calendar, bool) {
+	exchange = strings.TrimSpace(exchange)
+	if exchange == "" {
+		return nil, false
+	}
+	for _, c := range All() {
+		for _, e := range c.Exchanges {
+			if strings.EqualFold(e, exchange) {
+				return c, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Default returns the US calendar
+func Default() *Calendar {
+	c, _ := Lookup(DefaultName)
+	return c
+}
+
+// FormatCountdown formats the time until an event compactly:
+// "2d 3h", "5h12m", "45m" or "<1m"
+func FormatCountdown(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return "<1m"
+	}
+}
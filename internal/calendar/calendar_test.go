@@ -0,0 +1,116 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuiltin(t *testing.T) {
+	if err := LoadError(); err != nil {
+		t.Fatalf("Builtin calendars failed to load: %v", err)
+	}
+	for _, name := range []string{"US", "LSE", "XETRA", "TSX"} {
+		if _, ok := Lookup(name); !ok {
+			t.Errorf("Missing builtin calendar %s", name)
+		}
+	}
+
+	for exchange, want := range map[string]string{"NMS": "US", "nyq": "US", "NasdaqGS": "US", "LSE": "LSE", "GER": "XETRA", "TOR": "TSX"} {
+		c, ok := ForExchange(exchange)
+		if !ok || c.Name != want {
+			t.Errorf("ForExchange(%q) = %v, want %s", exchange, c, want)
+		}
+	}
+	if _, ok := ForExchange("JPX"); ok {
+		t.Error("Expected no calendar for JPX")
+	}
+}
+
+func TestState_US(t *testing.T) {
+	us := Default()
+	ny := us.Location()
+
+	tests := []struct {
+		time time.Time
+		want string
+	}{
+		{time.Date(2026, 10, 16, 3, 59, 0, 0, ny), StateClosed},
+		{time.Date(2026, 10, 16, 4, 0, 0, 0, ny), StatePre},
+		{time.Date(2026, 10, 16, 9, 30, 0, 0, ny), StateRegular},
+		{time.Date(2026, 10, 16, 15, 59, 0, 0, ny), StateRegular},
+		{time.Date(2026, 10, 16, 16, 0, 0, 0, ny), StatePost},
+		{time.Date(2026, 10, 16, 20, 0, 0, 0, ny), StateClosed},
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, ny), StateClosed}, // Saturday
+		{time.Date(2026, 11, 26, 12, 0, 0, 0, ny), StateClosed}, // Thanksgiving
+		{time.Date(2026, 11, 27, 12, 59, 0, 0, ny), StateRegular},
+		{time.Date(2026, 11, 27, 13, 0, 0, 0, ny), StatePost}, // Half day
+		{time.Date(2026, 11, 27, 17, 0, 0, 0, ny), StateClosed},
+	}
+	for _, tt := range tests {
+		if got := us.State(tt.time); got != tt.want {
+			t.Errorf("State(%s) = %s, want %s", tt.time.Format("Mon 2006-01-02 15:04"), got, tt.want)
+		}
+	}
+
+	if name, ok := us.Holiday(time.Date(2026, 7, 3, 12, 0, 0, 0, ny)); !ok || name == "" {
+		t.Error("Expected the observed Independence Day holiday")
+	}
+}
+
+func TestNextOpenClose(t *testing.T) {
+	us := Default()
+	ny := us.Location()
+
+	// Wednesday before Thanksgiving, after the close: next open is Friday (half day)
+	t0 := time.Date(2026, 11, 25, 17, 0, 0, 0, ny)
+	open, ok := us.NextOpen(t0)
+	if !ok || !open.Equal(time.Date(2026, 11, 27, 9, 30, 0, 0, ny)) {
+		t.Errorf("NextOpen = %v, want Fri 09:30", open)
+	}
+	closeAt, ok := us.NextClose(t0)
+	if !ok || !closeAt.Equal(time.Date(2026, 11, 27, 13, 0, 0, 0, ny)) {
+		t.Errorf("NextClose = %v, want Fri 13:00 (half day)", closeAt)
+	}
+
+	// During a session: close today, open the next trading day
+	t1 := time.Date(2026, 10, 16, 11, 0, 0, 0, ny)
+	if c, _ := us.NextClose(t1); !c.Equal(time.Date(2026, 10, 16, 16, 0, 0, 0, ny)) {
+		t.Errorf("NextClose = %v, want today 16:00", c)
+	}
+	if o, _ := us.NextOpen(t1); !o.Equal(time.Date(2026, 10, 19, 9, 30, 0, 0, ny)) {
+		t.Errorf("NextOpen = %v, want Monday 09:30", o)
+	}
+
+	// Last session from a Sunday is Friday's
+	if s, ok := us.LastSession(time.Date(2026, 10, 18, 12, 0, 0, 0, ny)); !ok || s.Date.Day() != 16 {
+		t.Errorf("LastSession = %+v, want Friday", s)
+	}
+}
+
+func TestState_DST(t *testing.T) {
+	lse, _ := Lookup("LSE")
+	// London open at 08:00 local on both sides of the March 2026 change
+	if got := lse.State(time.Date(2026, 3, 27, 8, 0, 0, 0, time.UTC)); got != StateRegular {
+		t.Errorf("Expected LSE open at 08:00 GMT, got %s", got)
+	}
+	if got := lse.State(time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC)); got != StateRegular {
+		t.Errorf("Expected LSE open at 08:00 BST (07:00 UTC), got %s", got)
+	}
+	if got := lse.State(time.Date(2026, 3, 30, 6, 59, 0, 0, time.UTC)); got != StateClosed {
+		t.Errorf("Expected LSE closed before 08:00 BST, got %s", got)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second:              "<1m",
+		45 * time.Minute:              "45m",
+		5*time.Hour + 7*time.Minute:   "5h07m",
+		50*time.Hour + 10*time.Minute: "2d 2h",
+	}
+	for d, want := range tests {
+		if got := FormatCountdown(d); got != want {
+			t.Errorf("FormatCountdown(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Settings holds user-editable application settings stored in settings.json
+type Settings struct {
+	Provider string          `json:"provider,omitempty"` // Market data provider name (see fetcher.ProviderNames)
+	Scan     ScanSettings    `json:"scan"`
+	Account  AccountSettings `json:"account"`
+}
+
+// AccountSettings holds the account position sizes are calculated for.
+// Zero values take the defaults (see portfolio.NewAccount).
+type AccountSettings struct {
+	Equity             float64 `json:"equity,omitempty"`               // Account equity, in the currency of the stocks sized
+	RiskPercent        float64 `json:"risk_percent,omitempty"`         // Equity risked per trade, in percent (default 1)
+	MaxPositionPercent float64 `json:"max_position_percent,omitempty"` // Largest position, in percent of equity (default 20)
+}
+
+// ScanSettings holds the scan configuration
+type ScanSettings struct {
+	Interval     string `json:"interval,omitempty"`       // Bar interval: 1h, 1d, 1wk or 1mo (default 1d)
+	LookbackDays int    `json:"lookback_days,omitempty"`  // Calendar days of history; 0 picks it from the indicators
+	FillGaps     bool   `json:"fill_gaps,omitempty"`      // Forward-fill null bars instead of dropping them
+	Universe     string `json:"universe,omitempty"`       // Universe scanned by default (see "stockmap universe list")
+	BaseCurrency string `json:"base_currency,omitempty"`  // Convert prices to this currency for ranking (e.g. USD); empty keeps native prices
+	Strategy     string `json:"strategy,omitempty"`       // Strategy preset (see "stockmap strategy list"); empty uses Deep Value
+	Rank         bool   `json:"rank,omitempty"`           // Rank results against each other after every scan
+	RankBySector bool   `json:"rank_by_sector,omitempty"` // Rank against the results of the same sector (implies rank)
+
+	// RankWeights weights the metric percentiles in the rank score, e.g.
+	// {"pbv": -2, "momentum": 1}; negative favours low values. Empty uses the defaults.
+	RankWeights map[string]float64 `json:"rank_weights,omitempty"`
+}
+
+// Dir returns the config directory
+// (next to the executable, falling back to the current working directory)
+func Dir() string {
+	execPath, _ := os.Executable()
+	dir := filepath.Join(filepath.Dir(execPath), "config")
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		cwd, _ := os.Getwd()
+		dir = filepath.Join(cwd, "config")
+	}
+
+	return dir
+}
+
+// Path returns the path of a file inside the config directory
+func Path(name string) string {
+	return filepath.Join(Dir(), name)
+}
+
+// Load reads settings.json, returning zero-value settings if it does not exist
+func Load() (Settings, error) {
+	var s Settings
+
+	data, err := os.ReadFile(Path("settings.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, err
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// Save writes settings.json
+func Save(s Settings) error {
+	path := Path("settings.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// Column is one output column of a scan result
+type Column struct {
+	Name  string // snake_case name used by --columns and as the CSV/JSON key
+	value func(r *screener.ScreenResult) interface{}
+}
+
+// Header returns the column's table header
+func (c Column) Header() string {
+	return strings.ToUpper(c.Name)
+}
+
+// Value returns the column's value for a result: a string, bool, int64,
+// float64 or []screener.Contribution, or nil when the value is unknown
+func (c Column) Value(r *screener.ScreenResult) interface{} {
+	return c.value(r)
+}
+
+// AllColumns returns every available column: the scalar ScreenResult fields
+// in declaration order followed by the computed ones (see screener.Fields)
+// and the score contributions
+func AllColumns() []Column {
+	fields := screener.Fields()
+	cols := make([]Column, len(fields), len(fields)+1)
+	for i, f := range fields {
+		cols[i] = Column{Name: f.Name, value: f.Value}
+	}
+	return append(cols, Column{Name: "contributions", value: contributions})
+}
+
+// contributions returns a result's score contributions, nil if there are none
+func contributions(r *screener.ScreenResult) interface{} {
+	if len(r.Contributions) == 0 {
+		return nil
+	}
+	return r.Contributions
+}
+
+// columnNames returns the names of all columns
+func columnNames() []string {
+	all := AllColumns()
+	names := make([]string, len(all))
+	for i, c := range all {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// ParseColumns resolves a comma-separated list of column names; "all"
+// selects every column. Names are case insensitive; dashes may replace
+// underscores.
+func ParseColumns(spec string) ([]Column, error) {
+	all := AllColumns()
+	byName := make(map[string]Column, len(all))
+	for _, c := range all {
+		byName[c.Name] = c
+	}
+
+	var cols []Column
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+		switch name {
+		case "":
+			continue
+		case "all":
+			cols = append(cols, all...)
+			continue
+		}
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(columnNames(), ", "))
+		}
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return cols, nil
+}
+
+// DefaultColumns returns the columns printed when --columns isn't given:
+// a compact summary for the text formats and every column for CSV and JSON.
+// base_price is included for text formats when a base currency is set.
+func DefaultColumns(f Format, baseCurrency bool) []Column {
+	if f != FormatTable && f != FormatMarkdown {
+		return AllColumns()
+	}
+	spec := "symbol,price,currency,rsi,pbv,graham_upside,confluence_score,grade"
+	if baseCurrency {
+		spec = "symbol,price,currency,base_price,rsi,pbv,graham_upside,confluence_score,grade"
+	}
+	cols, _ := ParseColumns(spec)
+	return cols
+}
@@ -0,0 +1,269 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// Format is an output format of scan results
+type Format string
+
+const (
+	FormatTable    Format = "table"
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatJSONL    Format = "jsonl"
+	FormatMarkdown Format = "markdown"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatTable, FormatCSV, FormatJSON, FormatJSONL, FormatMarkdown}
+
+// ParseFormat validates a format name ("md" is accepted for markdown)
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "md" {
+		return FormatMarkdown, nil
+	}
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unknown format %q (available: %s)", s, strings.Join(names, ", "))
+}
+
+// Write writes results in the given format with the given columns
+func Write(w io.Writer, f Format, cols []Column, results []*screener.ScreenResult) error {
+	switch f {
+	case FormatCSV:
+		return writeCSV(w, cols, results)
+	case FormatJSON:
+		return writeJSON(w, cols, results)
+	case FormatJSONL:
+		return writeJSONL(w, cols, results)
+	case FormatMarkdown:
+		return writeMarkdown(w, cols, results)
+	default:
+		return writeTable(w, cols, results)
+	}
+}
+
+// writeTable writes an aligned text table
+func writeTable(w io.Writer, cols []Column, results []*screener.ScreenResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Header()
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, r := range results {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = textValue(c.Value(r))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeMarkdown writes a GitHub-flavored Markdown table
+func writeMarkdown(w io.Writer, cols []Column, results []*screener.ScreenResult) error {
+	var b bytes.Buffer
+	b.WriteString("|")
+	for _, c := range cols {
+		b.WriteString(" " + c.Header() + " |")
+	}
+	b.WriteString("\n|")
+	for _, c := range cols {
+		if isNumeric(c, results) {
+			b.WriteString(" ---: |")
+		} else {
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+
+	for _, r := range results {
+		b.WriteString("|")
+		for _, c := range cols {
+			cell := strings.ReplaceAll(textValue(c.Value(r)), "|", `\|`)
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// isNumeric reports whether a column holds numbers (right-aligned in Markdown)
+func isNumeric(c Column, results []*screener.ScreenResult) bool {
+	for _, r := range results {
+		switch c.Value(r).(type) {
+		case float64, int64:
+			return true
+		case nil:
+			continue
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// writeCSV writes a header row and one row per result; unknown values are empty
+func writeCSV(w io.Writer, cols []Column, results []*screener.ScreenResult) error {
+	cw := csv.NewWriter(w)
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Name
+	}
+	cw.Write(headers)
+
+	for _, r := range results {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = rawValue(c.Value(r))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes an array of objects; unknown values are null
+func writeJSON(w io.Writer, cols []Column, results []*screener.ScreenResult) error {
+	rows := make([]json.RawMessage, 0, len(results))
+	for _, r := range results {
+		row, err := jsonObject(cols, r)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(rows)
+}
+
+// writeJSONL writes one JSON object per line
+func writeJSONL(w io.Writer, cols []Column, results []*screener.ScreenResult) error {
+	for _, r := range results {
+		row, err := jsonObject(cols, r)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonObject encodes a result as an object with keys in column order
+func jsonObject(cols []Column, r *screener.ScreenResult) (json.RawMessage, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, c := range cols {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := marshal(c.Name)
+		value, err := marshal(roundFloat(c.Value(r)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.Name, err)
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// marshal encodes a value as JSON without escaping <, > and & (which
+// score contribution rules contain)
+func marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(b.Bytes(), "\n"), nil
+}
+
+// textValue formats a value for the text formats: floats with two decimals,
+// "-" when unknown or empty
+func textValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case string:
+		if v == "" {
+			return "-"
+		}
+		return v
+	case []screener.Contribution:
+		return joinContributions(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// joinContributions lists score contributions on one line
+func joinContributions(list []screener.Contribution) string {
+	parts := make([]string, len(list))
+	for i, c := range list {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// rawValue formats a value for CSV: full precision, empty when unknown
+func rawValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		if f, ok := roundFloat(v).(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return ""
+	case []screener.Contribution:
+		return joinContributions(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// roundFloat rounds float values to 6 decimals to drop binary noise
+// (0.7000000000000001); NaN and infinities become unknown
+func roundFloat(v interface{}) interface{} {
+	f, ok := v.(float64)
+	if !ok {
+		return v
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return math.Round(f*1e6) / 1e6
+}
@@ -0,0 +1,210 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+func testResults() []*screener.ScreenResult {
+	return []*screener.ScreenResult{
+		{Symbol: "AAPL", Name: "Apple Inc.", Price: 182.5, Currency: "USD", RSI: 28.123456789,
+			PBV: 1.2, GrahamUpside: 15, ConfluenceScore: 72.5, HasFundamentals: true,
+			Contributions: []screener.Contribution{
+				{Component: "technical", Name: "RSI", Rule: "rsi > 0 and rsi < 30", Points: 45, Weighted: 13.5,
+					Inputs: []screener.Input{{Field: "rsi", Value: 28.1235}}},
+				{Component: "bonus", Name: "Oversold and undervalued", Rule: "is_oversold and is_undervalued", Points: 10, Weighted: 10},
+			}},
+		// No fundamentals: valuation is unknown, not zero
+		{Symbol: "SPY", Name: "SPDR | S&P 500", Price: 0.1 + 0.6, Currency: "USD", RSI: math.NaN()},
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns(" Symbol, graham-upside ,grade")
+	if err != nil {
+		t.Fatalf("ParseColumns failed: %v", err)
+	}
+	if len(cols) != 3 || cols[0].Name != "symbol" || cols[1].Name != "graham_upside" || cols[2].Name != "grade" {
+		t.Errorf("Unexpected columns %v", cols)
+	}
+
+	if _, err := ParseColumns("symbol,nope"); err == nil || !strings.Contains(err.Error(), "available") {
+		t.Errorf("Expected an unknown column error listing the names, got %v", err)
+	}
+	if _, err := ParseColumns(" , "); err == nil {
+		t.Error("Expected an error for an empty selection")
+	}
+
+	all, err := ParseColumns("all")
+	if err != nil || len(all) != len(AllColumns()) {
+		t.Errorf("Expected every column for \"all\", got %d (%v)", len(all), err)
+	}
+	for _, name := range []string{"symbol", "price", "rsi", "pbv", "has_fundamentals", "base_price", "data_quality"} {
+		if _, err := ParseColumns(name); err != nil {
+			t.Errorf("Missing column %s: %v", name, err)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"csv": FormatCSV, "JSON": FormatJSON, "md": FormatMarkdown, "table": FormatTable} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	cols, _ := ParseColumns("symbol,price,rsi,pbv,has_fundamentals")
+	var b bytes.Buffer
+	if err := Write(&b, FormatCSV, cols, testResults()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"symbol", "price", "rsi", "pbv", "has_fundamentals"},
+		{"AAPL", "182.5", "28.123457", "1.2", "true"},
+		{"SPY", "0.7", "", "", "false"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %d rows, got %v", len(want), rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("Row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	cols, _ := ParseColumns("symbol,pbv,confluence_score,grade")
+	var b bytes.Buffer
+	if err := Write(&b, FormatJSON, cols, testResults()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &rows); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, b.String())
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if rows[0]["symbol"] != "AAPL" || rows[0]["pbv"] != 1.2 || rows[0]["confluence_score"] != 72.5 || rows[0]["grade"] == "" {
+		t.Errorf("Unexpected row %v", rows[0])
+	}
+	if v, ok := rows[1]["pbv"]; !ok || v != nil {
+		t.Errorf("Expected null PBV without fundamentals, got %v", v)
+	}
+
+	// Keys follow the column order
+	if i, j := strings.Index(b.String(), `"symbol"`), strings.Index(b.String(), `"grade"`); i > j {
+		t.Error("Expected keys in column order")
+	}
+}
+
+func TestWriteContributions(t *testing.T) {
+	cols, _ := ParseColumns("symbol,contributions")
+	var b bytes.Buffer
+	if err := Write(&b, FormatJSONL, cols, testResults()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if !strings.Contains(lines[0], `"rule":"rsi > 0 and rsi < 30"`) || !strings.Contains(lines[0], `"weighted":13.5`) {
+		t.Errorf("Expected unescaped contributions, got %s", lines[0])
+	}
+	if lines[1] != `{"symbol":"SPY","contributions":null}` {
+		t.Errorf("Unexpected line %s", lines[1])
+	}
+
+	b.Reset()
+	Write(&b, FormatCSV, cols, testResults())
+	if !strings.Contains(b.String(), "RSI 28.12 → +45 technical; Oversold and undervalued bonus +10") {
+		t.Errorf("Unexpected CSV contributions:\n%s", b.String())
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	cols, _ := ParseColumns("symbol,rsi")
+	var b bytes.Buffer
+	if err := Write(&b, FormatJSONL, cols, testResults()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", b.String())
+	}
+	if lines[1] != `{"symbol":"SPY","rsi":null}` {
+		t.Errorf("Unexpected line %s", lines[1])
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	cols, _ := ParseColumns("symbol,name,price")
+	var b bytes.Buffer
+	if err := Write(&b, FormatMarkdown, cols, testResults()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header, separator and 2 rows, got %q", b.String())
+	}
+	if lines[0] != "| SYMBOL | NAME | PRICE |" || lines[1] != "| --- | --- | ---: |" {
+		t.Errorf("Unexpected header %q / %q", lines[0], lines[1])
+	}
+	if !strings.Contains(lines[3], `SPDR \| S&P 500`) || !strings.Contains(lines[3], "0.70") {
+		t.Errorf("Expected escaped pipes and two decimals, got %q", lines[3])
+	}
+}
+
+func TestSort(t *testing.T) {
+	results := []*screener.ScreenResult{
+		{Symbol: "B", RSI: 40, PBV: 1.5, HasFundamentals: true},
+		{Symbol: "a", RSI: math.NaN()},
+		{Symbol: "C", RSI: 25, PBV: 0.8, HasFundamentals: true},
+	}
+	order := func() string {
+		var s []string
+		for _, r := range results {
+			s = append(s, r.Symbol)
+		}
+		return strings.Join(s, "")
+	}
+
+	tests := map[string]string{
+		"rsi":         "BCa", // Numbers high to low, unknown last
+		"rsi:asc":     "CBa",
+		"pbv:asc":     "CBa",
+		"symbol":      "aBC", // Text A-Z, case insensitive
+		"symbol:desc": "CBa",
+	}
+	for spec, want := range tests {
+		if err := Sort(results, spec); err != nil {
+			t.Fatalf("Sort(%q) failed: %v", spec, err)
+		}
+		if got := order(); got != want {
+			t.Errorf("Sort(%q) = %s, want %s", spec, got, want)
+		}
+	}
+
+	for _, spec := range []string{"nope", "rsi:up", "all"} {
+		if err := Sort(results, spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// Sort orders results by a column. The spec is a column name with an optional
+// ":asc" or ":desc" suffix; numbers sort high to low and text A-Z by default.
+// Unknown values sort last either way and ties keep their order.
+func Sort(results []*screener.ScreenResult, spec string) error {
+	name, dir, _ := strings.Cut(spec, ":")
+	cols, err := ParseColumns(name)
+	if err != nil {
+		return err
+	}
+	if len(cols) != 1 {
+		return fmt.Errorf("sort needs a single column, got %q", name)
+	}
+	col := cols[0]
+
+	dir = strings.ToLower(strings.TrimSpace(dir))
+	if dir != "" && dir != "asc" && dir != "desc" {
+		return fmt.Errorf("unknown sort direction %q (use asc or desc)", dir)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		// roundFloat turns NaN into unknown
+		a, b := roundFloat(col.Value(results[i])), roundFloat(col.Value(results[j]))
+		if a == nil || b == nil {
+			return a != nil
+		}
+		if dir == "desc" || dir == "" && isNumber(a) {
+			return compare(a, b) > 0
+		}
+		return compare(a, b) < 0
+	})
+	return nil
+}
+
+// compare returns -1, 0 or 1 for two values of the same column
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		return compareOrdered(a, b.(float64))
+	case int64:
+		return compareOrdered(a, b.(int64))
+	case bool:
+		return compareOrdered(boolRank(a), boolRank(b.(bool)))
+	default:
+		return compareOrdered(strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b)))
+	}
+}
+
+// compareOrdered returns -1, 0 or 1 for ordered values
+func compareOrdered[T float64 | int64 | int | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// boolRank orders false before true
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isNumber reports whether a value sorts high to low by default
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, int64, bool:
+		return true
+	}
+	return false
+}
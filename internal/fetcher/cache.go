@@ -0,0 +1,456 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+const (
+	// historyMaxAge is how long cached bars are served without asking the provider.
+	// Quotes are always fetched live, so only the indicators lag by up to this much.
+	historyMaxAge = 5 * time.Minute
+
+	// fundamentalsMaxAge is how long cached fundamentals are reused
+	fundamentalsMaxAge = 24 * time.Hour
+)
+
+// DefaultCacheDir returns the cache directory inside the config dir
+func DefaultCacheDir() string {
+	return config.Path("cache")
+}
+
+// cachedBar is the on-disk form of a Bar
+type cachedBar struct {
+	T int64   `json:"t"`
+	O float64 `json:"o"`
+	H float64 `json:"h"`
+	L float64 `json:"l"`
+	C float64 `json:"c"`
+	V int64   `json:"v"`
+}
+
+// barFile is one cached symbol/interval series
+type barFile struct {
+	Symbol   string      `json:"symbol"`
+	Interval Interval    `json:"interval"`
+	Start    int64       `json:"start"`   // Earliest time covered by a full fetch
+	Updated  int64       `json:"updated"` // Last time the series was refreshed
+	Currency string      `json:"currency,omitempty"`
+	Bars     []cachedBar `json:"bars"`
+	Gaps     []int64     `json:"gaps,omitempty"` // Times of null bars (see StockData.Gaps)
+}
+
+// fundamentalsFile is cached fundamentals for a symbol
+type fundamentalsFile struct {
+	Symbol        string  `json:"symbol"`
+	Updated       int64   `json:"updated"`
+	PERatio       float64 `json:"pe_ratio"`
+	EPS           float64 `json:"eps"`
+	BookValue     float64 `json:"book_value"`
+	DividendYield float64 `json:"dividend_yield"`
+	MarketCap     int64   `json:"market_cap"`
+	Sector        string  `json:"sector,omitempty"`
+	Industry      string  `json:"industry,omitempty"`
+}
+
+// CacheStats describes the contents of the cache directory
+type CacheStats struct {
+	Dir              string
+	Series           int // Cached bar series (symbol + interval)
+	Bars             int
+	FundamentalFiles int
+	Bytes            int64
+	Oldest           time.Time // Least recently updated entry
+	Newest           time.Time // Most recently updated entry
+}
+
+// BarCache stores OHLCV bars on disk, one JSON file per symbol and interval
+// (e.g. AAPL.1d.json, AAPL.1wk.json), plus cached fundamentals (AAPL.fundamentals.json)
+type BarCache struct {
+	dir string
+}
+
+// NewBarCache creates a cache rooted at dir
+func NewBarCache(dir string) *BarCache {
+	return &BarCache{dir: dir}
+}
+
+// Dir returns the cache directory
+func (c *BarCache) Dir() string {
+	return c.dir
+}
+
+// readJSON decodes a cache file, returning false if it is missing or unreadable
+func (c *BarCache) readJSON(name string, v interface{}) bool {
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// writeJSON writes a cache file atomically so concurrent readers never see partial data
+func (c *BarCache) writeJSON(name string, v interface{}) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.dir, name))
+}
+
+// loadBars returns the cached series for a symbol, or nil
+func (c *BarCache) loadBars(symbol string, interval Interval) *barFile {
+	var f barFile
+	if !c.readJSON(recordingFileName(symbol, string(interval)), &f) || len(f.Bars) == 0 {
+		return nil
+	}
+	return &f
+}
+
+// History returns the cached bars of a symbol at or after start without
+// refreshing them, for offline use such as backtests
+func (c *BarCache) History(symbol string, interval Interval, start time.Time) (*StockData, error) {
+	f := c.loadBars(symbol, interval)
+	if f == nil {
+		return nil, fmt.Errorf("no cached %s bars for %s", interval, symbol)
+	}
+	return f.stockData(strings.ToUpper(symbol), start), nil
+}
+
+// saveBars writes a cached series
+func (c *BarCache) saveBars(f *barFile) error {
+	return c.writeJSON(recordingFileName(f.Symbol, string(f.Interval)), f)
+}
+
+// entries lists cache files with their last update time
+func (c *BarCache) entries() (map[string]time.Time, error) {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]time.Time, len(matches))
+	for _, m := range matches {
+		var header struct {
+			Updated int64 `json:"updated"`
+		}
+		if c.readJSON(filepath.Base(m), &header) && header.Updated > 0 {
+			entries[m] = time.Unix(header.Updated, 0)
+		} else if info, err := os.Stat(m); err == nil {
+			entries[m] = info.ModTime()
+		}
+	}
+	return entries, nil
+}
+
+// Stats summarises the cache contents
+func (c *BarCache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.dir}
+
+	entries, err := c.entries()
+	if err != nil {
+		return stats, err
+	}
+
+	for path, updated := range entries {
+		if info, err := os.Stat(path); err == nil {
+			stats.Bytes += info.Size()
+		}
+		if stats.Oldest.IsZero() || updated.Before(stats.Oldest) {
+			stats.Oldest = updated
+		}
+		if updated.After(stats.Newest) {
+			stats.Newest = updated
+		}
+
+		name := filepath.Base(path)
+		if strings.HasSuffix(name, "."+fundamentalsRangeKey+".json") {
+			stats.FundamentalFiles++
+			continue
+		}
+
+		var f barFile
+		if c.readJSON(name, &f) {
+			stats.Series++
+			stats.Bars += len(f.Bars)
+		}
+	}
+
+	return stats, nil
+}
+
+// Prune removes entries that haven't been updated within maxAge
+// and returns how many files were removed
+func (c *BarCache) Prune(maxAge time.Duration) (int, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for path, updated := range entries {
+		if updated.Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Clear removes every cache entry and returns how many files were removed
+func (c *BarCache) Clear() (int, error) {
+	return c.Prune(-time.Hour) // everything is older than an hour from now
+}
+
+// CacheCounters counts how historical requests were served since the provider was created
+type CacheCounters struct {
+	Fresh       int64 // Served from cache without a request
+	Incremental int64 // Only bars after the last cached one were fetched
+	Full        int64 // Full history was fetched (cold or too short cache)
+}
+
+// CachedProvider wraps a provider with the on-disk bar cache.
+// Historical requests fetch only the bars after the last cached timestamp
+// and merge them in; everything else goes straight to the wrapped provider.
+type CachedProvider struct {
+	Provider
+	cache *BarCache
+
+	fresh       int64
+	incremental int64
+	full        int64
+}
+
+// NewCachedProvider wraps p with a bar cache stored in dir
+func NewCachedProvider(p Provider, dir string) *CachedProvider {
+	return &CachedProvider{Provider: p, cache: NewBarCache(dir)}
+}
+
+// Unwrap returns the wrapped provider
+func (c *CachedProvider) Unwrap() Provider {
+	return c.Provider
+}
+
+// Cache returns the underlying bar cache
+func (c *CachedProvider) Cache() *BarCache {
+	return c.cache
+}
+
+// Counters returns how historical requests have been served so far
+func (c *CachedProvider) Counters() CacheCounters {
+	return CacheCounters{
+		Fresh:       atomic.LoadInt64(&c.fresh),
+		Incremental: atomic.LoadInt64(&c.incremental),
+		Full:        atomic.LoadInt64(&c.full),
+	}
+}
+
+// FetchHistorical returns the last n days of bars, using the cache where possible.
+// When bars were fetched, the live quote of the response is passed on.
+func (c *CachedProvider) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	now := time.Now()
+	start := now.AddDate(0, 0, -days)
+
+	f := c.cache.loadBars(symbol, interval)
+
+	var data *StockData
+	var err error
+	switch {
+	case f == nil || f.Start > start.Unix():
+		// Cold cache, or it doesn't reach back far enough
+		data, err = c.Provider.FetchHistorical(ctx, symbol, days, interval)
+		if err != nil {
+			return nil, err
+		}
+		if !hasBarTimes(data.Bars) {
+			return data, nil // nothing we can merge on later
+		}
+		f = newBarFile(symbol, interval, start, data)
+		atomic.AddInt64(&c.full, 1)
+
+	case now.Sub(time.Unix(f.Updated, 0)) < historyMaxAge:
+		atomic.AddInt64(&c.fresh, 1)
+		return f.stockData(symbol, start), nil
+
+	default:
+		// Fetch from one bar before the last cached one onwards; the overlap is
+		// replaced because the latest bar may have been incomplete when cached
+		last := time.Unix(f.Bars[len(f.Bars)-1].T, 0)
+		n := int(now.Sub(last).Hours()/24) + interval.days()
+
+		data, err = c.Provider.FetchHistorical(ctx, symbol, n, interval)
+		if err != nil || !hasBarTimes(data.Bars) {
+			// Stale bars beat no bars; the next scan retries
+			return f.stockData(symbol, start), nil
+		}
+		if data.Currency != f.Currency {
+			// Bars cached in other units (e.g. pence before GBp was converted)
+			// can't be merged; refetch the whole range
+			full, err := c.Provider.FetchHistorical(ctx, symbol, days, interval)
+			if err != nil || !hasBarTimes(full.Bars) {
+				return data, nil
+			}
+			data = full
+			f = newBarFile(symbol, interval, start, data)
+			atomic.AddInt64(&c.full, 1)
+			break
+		}
+		f.merge(toCachedBars(data.Bars), toUnixTimes(data.Gaps))
+		atomic.AddInt64(&c.incremental, 1)
+	}
+
+	f.Updated = now.Unix()
+	// Caching is best effort; a failed write must not fail the fetch
+	c.cache.saveBars(f)
+
+	result := f.stockData(symbol, start)
+	if data.Price > 0 {
+		copyQuote(result, data)
+		result.Symbol = symbol
+	}
+	return result, nil
+}
+
+// FetchFundamentals returns cached fundamentals if they are recent enough
+func (c *CachedProvider) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
+	name := recordingFileName(symbol, fundamentalsRangeKey)
+
+	var f fundamentalsFile
+	if c.cache.readJSON(name, &f) && time.Since(time.Unix(f.Updated, 0)) < fundamentalsMaxAge {
+		return &StockData{
+			Symbol:        symbol,
+			PERatio:       f.PERatio,
+			EPS:           f.EPS,
+			BookValue:     f.BookValue,
+			DividendYield: f.DividendYield,
+			MarketCap:     f.MarketCap,
+			Sector:        f.Sector,
+			Industry:      f.Industry,
+		}, nil
+	}
+
+	data, err := c.Provider.FetchFundamentals(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.writeJSON(name, fundamentalsFile{
+		Symbol:        strings.ToUpper(symbol),
+		Updated:       time.Now().Unix(),
+		PERatio:       data.PERatio,
+		EPS:           data.EPS,
+		BookValue:     data.BookValue,
+		DividendYield: data.DividendYield,
+		MarketCap:     data.MarketCap,
+		Sector:        data.Sector,
+		Industry:      data.Industry,
+	})
+
+	return data, nil
+}
+
+// hasBarTimes reports whether bars carry timestamps (required for merging)
+func hasBarTimes(bars []Bar) bool {
+	return len(bars) > 0 && !bars[len(bars)-1].Time.IsZero()
+}
+
+// toCachedBars converts bars to their on-disk form
+func toCachedBars(bars []Bar) []cachedBar {
+	out := make([]cachedBar, 0, len(bars))
+	for _, b := range bars {
+		out = append(out, cachedBar{T: b.Time.Unix(), O: b.Open, H: b.High, L: b.Low, C: b.Close, V: b.Volume})
+	}
+	return out
+}
+
+// toUnixTimes converts times to unix seconds
+func toUnixTimes(times []time.Time) []int64 {
+	out := make([]int64, 0, len(times))
+	for _, t := range times {
+		out = append(out, t.Unix())
+	}
+	return out
+}
+
+// merge replaces cached bars and gaps from the start of the fetched range onwards.
+// An incomplete bar is stamped with its last trade time, so it is replaced too.
+func (f *barFile) merge(bars []cachedBar, gaps []int64) {
+	if len(bars) == 0 {
+		return
+	}
+
+	from := bars[0].T
+	if len(gaps) > 0 && gaps[0] < from {
+		from = gaps[0]
+	}
+
+	keep := len(f.Bars)
+	for keep > 0 && f.Bars[keep-1].T >= from {
+		keep--
+	}
+	f.Bars = append(f.Bars[:keep:keep], bars...)
+
+	keepGaps := len(f.Gaps)
+	for keepGaps > 0 && f.Gaps[keepGaps-1] >= from {
+		keepGaps--
+	}
+	f.Gaps = append(f.Gaps[:keepGaps:keepGaps], gaps...)
+}
+
+// newBarFile creates a cached series from a full history fetch
+func newBarFile(symbol string, interval Interval, start time.Time, data *StockData) *barFile {
+	return &barFile{
+		Symbol:   strings.ToUpper(symbol),
+		Interval: interval,
+		Start:    start.Unix(),
+		Currency: data.Currency,
+		Bars:     toCachedBars(data.Bars),
+		Gaps:     toUnixTimes(data.Gaps),
+	}
+}
+
+// stockData builds historical StockData from the cached bars at or after start
+func (f *barFile) stockData(symbol string, start time.Time) *StockData {
+	data := &StockData{Symbol: symbol, Currency: f.Currency}
+	for _, b := range f.Bars {
+		if b.T >= start.Unix() {
+			data.Bars = append(data.Bars, Bar{Time: time.Unix(b.T, 0), Open: b.O, High: b.H, Low: b.L, Close: b.C, Volume: b.V})
+		}
+	}
+	for _, g := range f.Gaps {
+		if g >= start.Unix() {
+			data.Gaps = append(data.Gaps, time.Unix(g, 0))
+		}
+	}
+	setSeriesFromBars(data)
+	return data
+}
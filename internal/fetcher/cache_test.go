@@ -0,0 +1,189 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// barsProvider serves one daily bar per day for the requested range
+// and records the requested day counts
+type barsProvider struct {
+	stubProvider
+	requests []int
+	currency string
+}
+
+func (b *barsProvider) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	b.requests = append(b.requests, days)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour).Add(14 * time.Hour)
+	data := &StockData{Symbol: symbol, Currency: b.currency}
+	for i := days - 1; i >= 0; i-- {
+		price := 100 + float64(i%7)
+		data.Bars = append(data.Bars, Bar{Time: today.AddDate(0, 0, -i), Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 1000})
+		data.HistoricalCloses = append(data.HistoricalCloses, price)
+	}
+	return data, nil
+}
+
+func TestCachedProvider_IncrementalRefresh(t *testing.T) {
+	inner := &barsProvider{}
+	p := NewCachedProvider(inner, t.TempDir())
+	ctx := context.Background()
+
+	// Cold cache: full fetch
+	first, err := p.FetchHistorical(ctx, "AAPL", 60, Interval1d)
+	if err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+
+	// Fresh cache: no request
+	if _, err := p.FetchHistorical(ctx, "AAPL", 60, Interval1d); err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	if len(inner.requests) != 1 {
+		t.Fatalf("Expected 1 provider request, got %v", inner.requests)
+	}
+
+	// Stale cache: only the bars since the last cached one are fetched
+	f := p.Cache().loadBars("AAPL", Interval1d)
+	f.Updated = time.Now().Add(-time.Hour).Unix()
+	p.Cache().saveBars(f)
+
+	second, err := p.FetchHistorical(ctx, "AAPL", 60, Interval1d)
+	if err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	if len(inner.requests) != 2 || inner.requests[1] > 3 {
+		t.Errorf("Expected a short incremental request, got %v", inner.requests)
+	}
+	if len(second.Bars) != len(first.Bars) {
+		t.Errorf("Merged series should not duplicate bars: %d vs %d", len(second.Bars), len(first.Bars))
+	}
+
+	// A longer lookback than cached needs a full fetch
+	if _, err := p.FetchHistorical(ctx, "AAPL", 120, Interval1d); err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	if inner.requests[len(inner.requests)-1] != 120 {
+		t.Errorf("Expected full 120 day fetch, got %v", inner.requests)
+	}
+
+	c := p.Counters()
+	if c.Fresh != 1 || c.Incremental != 1 || c.Full != 2 {
+		t.Errorf("Unexpected counters: %+v", c)
+	}
+}
+
+func TestCachedProvider_CurrencyChange(t *testing.T) {
+	inner := &barsProvider{}
+	p := NewCachedProvider(inner, t.TempDir())
+	ctx := context.Background()
+
+	// Bars cached without a currency (before currencies were recorded)
+	if _, err := p.FetchHistorical(ctx, "VOD.L", 60, Interval1d); err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	f := p.Cache().loadBars("VOD.L", Interval1d)
+	f.Updated = time.Now().Add(-time.Hour).Unix()
+	p.Cache().saveBars(f)
+
+	// The refresh reports a currency: the series is refetched, not merged
+	inner.currency = "GBP"
+	data, err := p.FetchHistorical(ctx, "VOD.L", 60, Interval1d)
+	if err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	if inner.requests[len(inner.requests)-1] != 60 || data.Currency != "GBP" {
+		t.Errorf("Expected a full refetch in GBP, got %v requests, currency %q", inner.requests, data.Currency)
+	}
+	if f := p.Cache().loadBars("VOD.L", Interval1d); f.Currency != "GBP" {
+		t.Errorf("Expected the cache to record GBP, got %q", f.Currency)
+	}
+}
+
+// profileProvider serves fundamentals with a company profile and counts the
+// requests
+type profileProvider struct {
+	stubProvider
+	requests int
+}
+
+func (f *profileProvider) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
+	f.requests++
+	return &StockData{Symbol: symbol, EPS: 5, BookValue: 50, PERatio: 20, Sector: "Technology", Industry: "Consumer Electronics"}, nil
+}
+
+func TestCachedProvider_FundamentalsKeepSector(t *testing.T) {
+	inner := &profileProvider{}
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	if _, err := NewCachedProvider(inner, dir).FetchFundamentals(ctx, "AAPL"); err != nil {
+		t.Fatalf("FetchFundamentals failed: %v", err)
+	}
+
+	// A new provider on the same directory is served from the cache
+	cached, err := NewCachedProvider(inner, dir).FetchFundamentals(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("FetchFundamentals failed: %v", err)
+	}
+	if inner.requests != 1 {
+		t.Fatalf("Expected the second fetch to hit the cache, got %d requests", inner.requests)
+	}
+	if cached.Sector != "Technology" || cached.Industry != "Consumer Electronics" {
+		t.Errorf("Cached fundamentals lost the profile: sector %q, industry %q", cached.Sector, cached.Industry)
+	}
+	if cached.EPS != 5 || cached.BookValue != 50 {
+		t.Errorf("Cached fundamentals = %+v", cached)
+	}
+}
+
+func TestBarCache_StatsPruneClear(t *testing.T) {
+	dir := t.TempDir()
+	p := NewCachedProvider(&barsProvider{}, dir)
+	ctx := context.Background()
+
+	for _, sym := range []string{"AAPL", "MSFT"} {
+		p.FetchHistorical(ctx, sym, 30, Interval1d)
+		p.FetchFundamentals(ctx, sym)
+	}
+
+	stats, err := p.Cache().Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Series != 2 || stats.Bars != 60 || stats.FundamentalFiles != 2 || stats.Bytes == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// Age one series past the prune cutoff
+	f := p.Cache().loadBars("MSFT", Interval1d)
+	f.Updated = time.Now().AddDate(0, 0, -40).Unix()
+	p.Cache().saveBars(f)
+
+	if removed, _ := p.Cache().Prune(30 * 24 * time.Hour); removed != 1 {
+		t.Errorf("Expected prune to remove 1 entry, removed %d", removed)
+	}
+	if removed, _ := p.Cache().Clear(); removed != 3 {
+		t.Errorf("Expected clear to remove 3 entries, removed %d", removed)
+	}
+}
+
+func TestBarCache_History(t *testing.T) {
+	p := NewCachedProvider(&barsProvider{}, t.TempDir())
+	if _, err := p.Cache().History("AAPL", Interval1d, time.Time{}); err == nil {
+		t.Error("Expected an error for an uncached symbol")
+	}
+
+	p.FetchHistorical(context.Background(), "AAPL", 30, Interval1d)
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -9)
+	data, err := p.Cache().History("aapl", Interval1d, start)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if data.Symbol != "AAPL" || len(data.Bars) != 10 || len(data.HistoricalCloses) != 10 {
+		t.Errorf("Expected the last 10 cached bars, got %d", len(data.Bars))
+	}
+}
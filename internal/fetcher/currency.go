@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MarketStatusSymbol is the symbol whose exchange session GetMarketStatus
+// reports when no symbol is given
+const MarketStatusSymbol = "SPY"
+
+// minorUnits maps currencies quoted in minor units to their major currency.
+// Yahoo quotes London listings in pence (GBp or GBX), Johannesburg in cents
+// (ZAc) and Tel Aviv in agorot (ILA).
+var minorUnits = map[string]string{
+	"GBp": "GBP",
+	"GBX": "GBP",
+	"ZAc": "ZAR",
+	"ZAC": "ZAR",
+	"ILA": "ILS",
+}
+
+// NormalizeCurrency returns the major currency of a quote currency and the
+// factor converting prices into it ("GBp" -> "GBP", 0.01).
+// An empty currency is returned as is.
+func NormalizeCurrency(code string) (string, float64) {
+	code = strings.TrimSpace(code)
+	if major, ok := minorUnits[code]; ok {
+		return major, 0.01
+	}
+	return strings.ToUpper(code), 1
+}
+
+// FXSymbol returns the Yahoo symbol of the exchange rate from one currency
+// to another ("EUR", "USD" -> "EURUSD=X")
+func FXSymbol(from, to string) string {
+	return strings.ToUpper(from) + strings.ToUpper(to) + "=X"
+}
+
+// FetchFXRates returns the rates converting each currency into base, keyed
+// by currency. Empty currencies and base itself get a rate of 1. Quotes are
+// batched when the provider supports it. Currencies whose rate can't be
+// fetched are left out and reported in the error.
+func FetchFXRates(ctx context.Context, p Provider, currencies []string, base string) (map[string]float64, error) {
+	base = strings.ToUpper(base)
+	rates := map[string]float64{"": 1, base: 1}
+
+	bySymbol := make(map[string]string)
+	var symbols []string
+	for _, c := range currencies {
+		c, _ = NormalizeCurrency(c)
+		if _, ok := rates[c]; ok {
+			continue
+		}
+		if _, ok := bySymbol[FXSymbol(c, base)]; ok {
+			continue
+		}
+		bySymbol[FXSymbol(c, base)] = c
+		symbols = append(symbols, FXSymbol(c, base))
+	}
+	if len(symbols) == 0 {
+		return rates, nil
+	}
+
+	quotes := make(map[string]*StockData)
+	if batcher, ok := BatcherOf(p); ok {
+		if batch, err := batcher.FetchQuotes(ctx, symbols); err == nil {
+			quotes = batch
+		}
+	}
+
+	var missing []string
+	for _, sym := range symbols {
+		q, ok := quotes[sym]
+		if !ok {
+			var err error
+			if q, err = p.FetchQuote(ctx, sym); err != nil {
+				q = nil
+			}
+		}
+		if q == nil || q.Price <= 0 {
+			missing = append(missing, bySymbol[sym])
+			continue
+		}
+		rates[bySymbol[sym]] = q.Price
+	}
+
+	if len(missing) > 0 {
+		return rates, fmt.Errorf("no exchange rate to %s for %s", base, strings.Join(missing, ", "))
+	}
+	return rates, nil
+}
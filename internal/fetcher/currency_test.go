@@ -0,0 +1,112 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		factor float64
+	}{
+		{"USD", "USD", 1},
+		{"eur", "EUR", 1},
+		{"GBp", "GBP", 0.01},
+		{"GBX", "GBP", 0.01},
+		{"ZAc", "ZAR", 0.01},
+		{"ILA", "ILS", 0.01},
+		{"", "", 1},
+	}
+	for _, tt := range tests {
+		got, factor := NormalizeCurrency(tt.in)
+		if got != tt.want || factor != tt.factor {
+			t.Errorf("NormalizeCurrency(%q) = %q, %v; want %q, %v", tt.in, got, factor, tt.want, tt.factor)
+		}
+	}
+}
+
+func TestParseChartQuote_MinorUnits(t *testing.T) {
+	body := []byte(`{"chart":{"result":[{"meta":{"symbol":"VOD.L","currency":"GBp","exchangeName":"LSE","exchangeTimezoneName":"Europe/London",
+		"regularMarketPrice":72.5,"previousClose":70,"fiftyTwoWeekHigh":80,"fiftyTwoWeekLow":60},
+		"timestamp":[1709130600],"indicators":{"quote":[{"close":[72.5]}]}}],"error":null}}`)
+
+	data, err := parseChartQuote(body, "VOD.L")
+	if err != nil {
+		t.Fatalf("parseChartQuote failed: %v", err)
+	}
+	if data.Currency != "GBP" || data.ExchangeTimezone != "Europe/London" {
+		t.Errorf("Unexpected currency/timezone %q %q", data.Currency, data.ExchangeTimezone)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if !near(data.Price, 0.725) || !near(data.PreviousClose, 0.7) || !near(data.FiftyTwoWeekHigh, 0.8) {
+		t.Errorf("Expected prices in pounds, got %+v", data)
+	}
+	if data.ChangePercent < 3.5 || data.ChangePercent > 3.6 {
+		t.Errorf("Change percent should not depend on units, got %v", data.ChangePercent)
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	open := time.Date(2024, 2, 28, 14, 30, 0, 0, time.UTC)
+	var meta yahooChartMeta
+	meta.CurrentTradingPeriod.Pre = yahooTradingPeriod{Start: open.Add(-5*time.Hour - 30*time.Minute).Unix(), End: open.Unix()}
+	meta.CurrentTradingPeriod.Regular = yahooTradingPeriod{Start: open.Unix(), End: open.Add(6*time.Hour + 30*time.Minute).Unix()}
+	meta.CurrentTradingPeriod.Post = yahooTradingPeriod{Start: open.Add(6*time.Hour + 30*time.Minute).Unix(), End: open.Add(10*time.Hour + 30*time.Minute).Unix()}
+
+	tests := map[time.Duration]string{
+		-time.Hour:     "PRE",
+		0:              "REGULAR",
+		3 * time.Hour:  "REGULAR",
+		7 * time.Hour:  "POST",
+		11 * time.Hour: "CLOSED",
+	}
+	for offset, want := range tests {
+		if got := meta.sessionState(open.Add(offset)); got != want {
+			t.Errorf("At open%+v: expected %s, got %s", offset, want, got)
+		}
+	}
+
+	if got := (yahooChartMeta{}).sessionState(open); got != "" {
+		t.Errorf("Expected no state without trading periods, got %q", got)
+	}
+}
+
+// fxProvider quotes exchange rate symbols from a table
+type fxProvider struct {
+	stubProvider
+	rates map[string]float64
+}
+
+func (f *fxProvider) FetchQuote(ctx context.Context, symbol string) (*StockData, error) {
+	rate, ok := f.rates[symbol]
+	if !ok {
+		return nil, errors.New("no data")
+	}
+	return &StockData{Symbol: symbol, Price: rate, Currency: "USD"}, nil
+}
+
+func TestFetchFXRates(t *testing.T) {
+	p := &fxProvider{rates: map[string]float64{"EURUSD=X": 1.08, "GBPUSD=X": 1.26}}
+
+	rates, err := FetchFXRates(context.Background(), p, []string{"EUR", "GBp", "USD", "EUR", ""}, "usd")
+	if err != nil {
+		t.Fatalf("FetchFXRates failed: %v", err)
+	}
+	if rates["EUR"] != 1.08 || rates["GBP"] != 1.26 || rates["USD"] != 1 || rates[""] != 1 {
+		t.Errorf("Unexpected rates %v", rates)
+	}
+
+	// Missing rates are reported; the others are still returned
+	rates, err = FetchFXRates(context.Background(), p, []string{"EUR", "JPY"}, "USD")
+	if err == nil {
+		t.Error("Expected an error for the missing JPY rate")
+	}
+	if _, ok := rates["JPY"]; ok || rates["EUR"] != 1.08 {
+		t.Errorf("Unexpected rates %v", rates)
+	}
+}
@@ -0,0 +1,97 @@
+package fetcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interval is the bar size of a historical request
+type Interval string
+
+// Supported bar intervals
+const (
+	Interval1h  Interval = "1h"
+	Interval1d  Interval = "1d"
+	Interval1wk Interval = "1wk"
+	Interval1mo Interval = "1mo"
+)
+
+// DefaultInterval is used when the scan configuration doesn't set one
+const DefaultInterval = Interval1d
+
+// Intervals returns the supported intervals, shortest first
+func Intervals() []Interval {
+	return []Interval{Interval1h, Interval1d, Interval1wk, Interval1mo}
+}
+
+// ParseInterval validates an interval name; empty means DefaultInterval
+func ParseInterval(s string) (Interval, error) {
+	if s == "" {
+		return DefaultInterval, nil
+	}
+	for _, iv := range Intervals() {
+		if strings.EqualFold(s, string(iv)) {
+			return iv, nil
+		}
+	}
+	return "", fmt.Errorf("unknown interval %q (available: 1h, 1d, 1wk, 1mo)", s)
+}
+
+// MaxLookbackDays is the longest history Yahoo serves for this interval
+// (intraday bars only go back about two years)
+func (iv Interval) MaxLookbackDays() int {
+	if iv == Interval1h {
+		return 729
+	}
+	return 0 // unlimited
+}
+
+// days returns the calendar days covered by one bar, rounded up
+func (iv Interval) days() int {
+	switch iv {
+	case Interval1wk:
+		return 7
+	case Interval1mo:
+		return 31
+	default:
+		return 1
+	}
+}
+
+// LookbackDays returns the calendar days needed to get at least n bars of
+// this interval, allowing for weekends and holidays
+func LookbackDays(n int, iv Interval) int {
+	var days int
+	switch iv {
+	case Interval1h:
+		// ~7 regular-session bars per trading day
+		days = (n/7 + 1) * 7 / 5
+	case Interval1wk:
+		days = n * 7
+	case Interval1mo:
+		days = n * 31
+	default:
+		// ~252 trading days per 365 calendar days
+		days = n * 365 / 252
+	}
+
+	// A little slack for holidays and a partially formed latest bar
+	days += 10 + iv.days()
+
+	if max := iv.MaxLookbackDays(); max > 0 && days > max {
+		days = max
+	}
+	return days
+}
+
+// HistoryOptions selects the price history fetched by FetchComplete
+type HistoryOptions struct {
+	Days     int      // Calendar days of history
+	Interval Interval // Bar size
+	FillGaps bool     // Forward-fill null bars instead of dropping them
+}
+
+// DefaultHistoryOptions returns the history fetched when nothing is configured
+func DefaultHistoryOptions() HistoryOptions {
+	return HistoryOptions{Days: 60, Interval: DefaultInterval}
+}
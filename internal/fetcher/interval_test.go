@@ -0,0 +1,56 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseInterval(t *testing.T) {
+	for _, s := range []string{"1h", "1d", "1WK", "1mo"} {
+		if _, err := ParseInterval(s); err != nil {
+			t.Errorf("ParseInterval(%q) failed: %v", s, err)
+		}
+	}
+	if iv, _ := ParseInterval(""); iv != Interval1d {
+		t.Errorf("Expected empty interval to default to 1d, got %s", iv)
+	}
+	if _, err := ParseInterval("5m"); err == nil {
+		t.Error("Expected error for unsupported interval")
+	}
+}
+
+func TestLookbackDays(t *testing.T) {
+	// 200 daily bars need well over 200 calendar days
+	if days := LookbackDays(200, Interval1d); days < 290 {
+		t.Errorf("Expected >= 290 days for 200 daily bars, got %d", days)
+	}
+	// 50 daily bars don't fit in the old 60 day window
+	if days := LookbackDays(50, Interval1d); days <= 60 {
+		t.Errorf("Expected > 60 days for 50 daily bars, got %d", days)
+	}
+	if days := LookbackDays(200, Interval1wk); days < 1400 {
+		t.Errorf("Expected >= 1400 days for 200 weekly bars, got %d", days)
+	}
+	// Hourly history is capped by Yahoo
+	if days := LookbackDays(100000, Interval1h); days != Interval1h.MaxLookbackDays() {
+		t.Errorf("Expected hourly lookback to be capped, got %d", days)
+	}
+}
+
+func TestReplayProvider_Interval(t *testing.T) {
+	dir := t.TempDir()
+	p := NewReplayProvider(dir)
+
+	body := []byte(`{"chart":{"result":[{"meta":{"symbol":"SPY"},"timestamp":[1700000000],"indicators":{"quote":[{"open":[1],"high":[2],"low":[0.5],"close":[1.5],"volume":[10]}]}}],"error":null}}`)
+	if err := recordChart(dir, "SPY", historicalRangeKey(1500, Interval1wk), body); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.FetchHistorical(context.Background(), "SPY", 1500, Interval1wk); err != nil {
+		t.Errorf("Expected weekly recording to replay: %v", err)
+	}
+	// A weekly recording must not be served for daily requests
+	if _, err := p.FetchHistorical(context.Background(), "SPY", 60, Interval1d); err == nil {
+		t.Error("Expected no daily recording")
+	}
+}
@@ -2,35 +2,65 @@ package fetcher
 
 import (
 	"context"
-	"os"
+	"strings"
 	"sync"
 )
 
+// quotePrefetchSize is how many quotes the pool fetches ahead of the workers
+// when the provider supports batched quotes
+const quotePrefetchSize = 40
+
+// job is a symbol to fetch, with its quote if it was prefetched
+type job struct {
+	symbol string
+	quote  *StockData
+}
+
 // WorkerPool manages concurrent fetching of stock data
 type WorkerPool struct {
 	workers    int
-	client     *DirectYahooClient
-	symbols    chan string
+	client     Provider
+	symbols    chan job
 	results    chan *StockData
 	wg         sync.WaitGroup
 	ctx        context.Context
 	cancel     context.CancelFunc
 	onProgress func(completed, total int)
+	history    HistoryOptions
 }
 
-// NewWorkerPool creates a new worker pool
+// NewWorkerPool creates a new worker pool using the active provider
+// (falls back to the direct Yahoo client if the provider can't be created)
 func NewWorkerPool(workers int) *WorkerPool {
+	provider, err := NewDefaultProvider()
+	if err != nil {
+		provider = NewDirectYahooClient()
+	}
+	return NewWorkerPoolWithProvider(workers, provider)
+}
+
+// NewWorkerPoolWithProvider creates a new worker pool that fetches from the given provider
+func NewWorkerPoolWithProvider(workers int, provider Provider) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
-	// Check for custom DNS env var
-	dns := os.Getenv("STOCKMAP_DNS")
 	return &WorkerPool{
 		workers: workers,
-		client:  NewDirectYahooClientWithDNS(dns),
+		client:  provider,
 		ctx:     ctx,
 		cancel:  cancel,
+		history: DefaultHistoryOptions(),
 	}
 }
 
+// SetHistory sets the price history fetched for each symbol
+func (p *WorkerPool) SetHistory(hist HistoryOptions) {
+	p.history = hist
+}
+
+// Provider returns the provider used by the pool
+func (p *WorkerPool) Provider() Provider {
+	return p.client
+}
+
 // SetProgressCallback sets a callback for progress updates
 func (p *WorkerPool) SetProgressCallback(cb func(completed, total int)) {
 	p.onProgress = cb
@@ -46,26 +76,39 @@ func (p *WorkerPool) Start(symbols []string) <-chan *StockData {
 
 	// Reset WaitGroup
 	p.wg = sync.WaitGroup{}
+	hist := p.history
 
-	p.symbols = make(chan string, len(symbols))
+	p.symbols = make(chan job, len(symbols))
 	p.results = make(chan *StockData, len(symbols))
 
 	// Start workers
 	for i := 0; i < p.workers; i++ {
 		p.wg.Add(1)
-		go p.worker()
+		go p.worker(hist)
 	}
 
-	// Feed symbols to workers
+	// Feed symbols to workers, prefetching quotes in batches if possible
 	go func() {
-		for _, sym := range symbols {
-			select {
-			case <-p.ctx.Done():
-				break
-			case p.symbols <- sym:
+		defer close(p.symbols)
+
+		batcher, batched := BatcherOf(p.client)
+		for start := 0; start < len(symbols); start += quotePrefetchSize {
+			chunk := symbols[start:min(start+quotePrefetchSize, len(symbols))]
+
+			var quotes map[string]*StockData
+			if batched {
+				// On error the workers request quotes themselves
+				quotes, _ = batcher.FetchQuotes(p.ctx, chunk)
+			}
+
+			for _, sym := range chunk {
+				select {
+				case <-p.ctx.Done():
+					return
+				case p.symbols <- job{symbol: sym, quote: quotes[strings.ToUpper(sym)]}:
+				}
 			}
 		}
-		close(p.symbols)
 	}()
 
 	// Close results when all workers are done
@@ -78,21 +121,21 @@ func (p *WorkerPool) Start(symbols []string) <-chan *StockData {
 }
 
 // worker processes symbols from the channel
-func (p *WorkerPool) worker() {
+func (p *WorkerPool) worker(hist HistoryOptions) {
 	defer p.wg.Done()
 
 	for {
 		select {
 		case <-p.ctx.Done():
 			return
-		case symbol, ok := <-p.symbols:
+		case j, ok := <-p.symbols:
 			if !ok {
 				return
 			}
 
-			data, err := p.client.FetchComplete(p.ctx, symbol)
+			data, err := FetchCompleteWithQuote(p.ctx, p.client, j.symbol, hist, j.quote)
 			if err != nil {
-				data = &StockData{Symbol: symbol, Error: err}
+				data = &StockData{Symbol: j.symbol, Error: err}
 			}
 
 			select {
@@ -128,5 +171,3 @@ func (p *WorkerPool) FetchAll(symbols []string) []*StockData {
 
 	return results
 }
-
-// DefaultSymbols was moved to symbols.go
@@ -0,0 +1,315 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// DefaultProviderName is used when neither the flag nor settings.json name a provider
+const DefaultProviderName = "yahoo"
+
+// ErrNotSupported is returned by providers for data they cannot supply
+var ErrNotSupported = errors.New("not supported by this provider")
+
+// Provider is a source of market data.
+// The worker pool and screener only talk to this interface, so new feeds
+// (internal data, offline fixtures, ...) can be plugged in via RegisterProvider.
+type Provider interface {
+	// FetchQuote returns the current price, change and 52-week range
+	FetchQuote(ctx context.Context, symbol string) (*StockData, error)
+	// FetchHistorical returns price history of the given interval covering the last n days
+	FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error)
+	// FetchFundamentals returns P/E, EPS, book value and similar fields
+	FetchFundamentals(ctx context.Context, symbol string) (*StockData, error)
+	// CheckConnection runs connectivity diagnostics
+	CheckConnection() ConnectionResult
+	// GetMarketStatus returns the market state (REGULAR, PRE, POST, CLOSED, ...)
+	// of the exchange listing symbol; an empty symbol means MarketStatusSymbol
+	GetMarketStatus(symbol string) string
+	// Close releases any resources held by the provider
+	Close()
+}
+
+// QuoteBatcher is implemented by providers that can fetch many quotes per request
+type QuoteBatcher interface {
+	// FetchQuotes returns quotes by upper-case symbol; missing symbols are left out
+	FetchQuotes(ctx context.Context, symbols []string) (map[string]*StockData, error)
+}
+
+// quoteMaxAge is how old a quote embedded in a history response may be
+// before FetchComplete requests the quote separately
+const quoteMaxAge = time.Minute
+
+// findProvider returns the first provider implementing T, looking through
+// wrappers such as CachedProvider
+func findProvider[T any](p Provider) (T, bool) {
+	for p != nil {
+		if t, ok := p.(T); ok {
+			return t, true
+		}
+		w, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			break
+		}
+		p = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// BatcherOf returns the provider's quote batcher, if it has one
+func BatcherOf(p Provider) (QuoteBatcher, bool) {
+	return findProvider[QuoteBatcher](p)
+}
+
+// ProviderOptions holds settings passed to provider factories
+type ProviderOptions struct {
+	DNSServer string // Custom DNS server for HTTP based providers
+	RecordDir string // Save raw responses here (providers that support recording)
+	ReplayDir string // Directory of recordings served by the replay provider
+	CacheDir  string // If set, historical bars are cached here (see CachedProvider)
+}
+
+// ProviderFactory creates a provider from options
+type ProviderFactory func(opts ProviderOptions) (Provider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]ProviderFactory)
+)
+
+func init() {
+	RegisterProvider("yahoo", func(opts ProviderOptions) (Provider, error) {
+		client := NewDirectYahooClientWithDNS(opts.DNSServer)
+		client.SetRecordDir(opts.RecordDir)
+		return client, nil
+	})
+	RegisterProvider("finance-go", func(opts ProviderOptions) (Provider, error) {
+		return NewYahooClient(), nil
+	})
+	RegisterProvider("replay", func(opts ProviderOptions) (Provider, error) {
+		if opts.ReplayDir == "" {
+			return nil, fmt.Errorf("replay provider needs a recordings directory (--replay <dir>)")
+		}
+		return NewReplayProvider(opts.ReplayDir), nil
+	})
+}
+
+// RegisterProvider makes a provider available by name.
+// Registering an existing name replaces the previous factory.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[strings.ToLower(name)] = factory
+}
+
+// ProviderNames returns the sorted names of all registered providers
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider creates a registered provider by name
+func NewProvider(name string, opts ProviderOptions) (Provider, error) {
+	providersMu.RLock()
+	factory, ok := providers[strings.ToLower(name)]
+	providersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(ProviderNames(), ", "))
+	}
+
+	p, err := factory(opts)
+	if err != nil || opts.CacheDir == "" {
+		return p, err
+	}
+	return NewCachedProvider(p, opts.CacheDir), nil
+}
+
+// ActiveProviderName resolves the provider name from STOCKMAP_PROVIDER,
+// then STOCKMAP_REPLAY (implies "replay"), then settings.json, then the default
+func ActiveProviderName() string {
+	if name := os.Getenv("STOCKMAP_PROVIDER"); name != "" {
+		return name
+	}
+	if os.Getenv("STOCKMAP_REPLAY") != "" {
+		return "replay"
+	}
+	if s, err := config.Load(); err == nil && s.Provider != "" {
+		return s.Provider
+	}
+	return DefaultProviderName
+}
+
+// NewDefaultProvider creates the active provider using environment options.
+// The bar cache is used unless STOCKMAP_NO_CACHE is set, responses are being
+// recorded (recordings must contain full responses) or recordings are replayed.
+func NewDefaultProvider() (Provider, error) {
+	name := ActiveProviderName()
+	opts := ProviderOptions{
+		DNSServer: os.Getenv("STOCKMAP_DNS"),
+		RecordDir: os.Getenv("STOCKMAP_RECORD"),
+		ReplayDir: os.Getenv("STOCKMAP_REPLAY"),
+	}
+	if os.Getenv("STOCKMAP_NO_CACHE") == "" && opts.RecordDir == "" && !strings.EqualFold(name, "replay") {
+		opts.CacheDir = DefaultCacheDir()
+	}
+	return NewProvider(name, opts)
+}
+
+// FetchComplete fetches quote, history and fundamentals from a provider in
+// parallel and merges them. Only the quote is required; missing history or
+// fundamentals leave the corresponding fields empty.
+func FetchComplete(ctx context.Context, p Provider, symbol string, hist HistoryOptions) (*StockData, error) {
+	return FetchCompleteWithQuote(ctx, p, symbol, hist, nil)
+}
+
+// FetchCompleteWithQuote is FetchComplete with an already fetched quote
+// (e.g. from QuoteBatcher). Without one, the quote is taken from the history
+// response if it is fresh, and only requested separately otherwise.
+func FetchCompleteWithQuote(ctx context.Context, p Provider, symbol string, hist HistoryOptions, quote *StockData) (*StockData, error) {
+	start := time.Now()
+
+	var (
+		quoteData *StockData
+		histData  *StockData
+		fundData  *StockData
+		quoteErr  error
+		histErr   error
+		fundErr   error
+		wg        sync.WaitGroup
+	)
+
+	wg.Add(2)
+
+	// Fetch historical (for RSI/ATR/SMA calculation)
+	histDone := make(chan struct{})
+	go func() {
+		defer wg.Done()
+		defer close(histDone)
+		histData, histErr = p.FetchHistorical(ctx, symbol, hist.Days, hist.Interval)
+	}()
+
+	// Fetch fundamentals (optional)
+	go func() {
+		defer wg.Done()
+		fundData, fundErr = p.FetchFundamentals(ctx, symbol)
+	}()
+
+	// Quote (current price): given, embedded in the history, or requested
+	if quote != nil {
+		quoteData = copyQuote(&StockData{}, quote)
+	} else {
+		<-histDone
+		if histErr == nil && histData != nil && QuoteIsFresh(histData, time.Now()) {
+			quoteData = copyQuote(&StockData{}, histData)
+		} else {
+			quoteData, quoteErr = p.FetchQuote(ctx, symbol)
+		}
+	}
+
+	wg.Wait()
+
+	if quoteErr != nil {
+		return &StockData{Symbol: symbol, Error: quoteErr, FetchDuration: time.Since(start)}, nil
+	}
+
+	// Merge historical data
+	if histErr == nil && histData != nil {
+		if hist.FillGaps {
+			FillGaps(histData)
+		}
+		quoteData.HistoricalPrices = histData.HistoricalPrices
+		quoteData.HistoricalCloses = histData.HistoricalCloses
+		quoteData.HistoricalHighs = histData.HistoricalHighs
+		quoteData.HistoricalLows = histData.HistoricalLows
+		quoteData.Bars = histData.Bars
+		quoteData.Gaps = histData.Gaps
+		quoteData.Quality = AssessQuality(histData, hist.Interval, time.Now())
+	}
+
+	AssessQuote(&quoteData.Quality, quoteData, time.Now())
+
+	// Merge fundamentals; without them valuation fields stay unknown.
+	// Funds and thin listings answer with every valuation value null.
+	if fundErr == nil && fundData != nil {
+		mergeFundamentals(quoteData, fundData)
+		quoteData.HasFundamentals = fundData.HasValuation()
+	}
+
+	quoteData.FetchDuration = time.Since(start)
+	return quoteData, nil
+}
+
+// QuoteIsFresh reports whether data carries a live quote received within quoteMaxAge
+func QuoteIsFresh(data *StockData, now time.Time) bool {
+	return data.Price > 0 && !data.FetchedAt.IsZero() && now.Sub(data.FetchedAt) <= quoteMaxAge
+}
+
+// copyQuote copies the quote fields of src into dst and returns dst
+func copyQuote(dst, src *StockData) *StockData {
+	dst.Symbol = src.Symbol
+	dst.ShortName = src.ShortName
+	dst.Price = src.Price
+	dst.Change = src.Change
+	dst.ChangePercent = src.ChangePercent
+	dst.PreviousClose = src.PreviousClose
+	dst.FiftyTwoWeekHigh = src.FiftyTwoWeekHigh
+	dst.FiftyTwoWeekLow = src.FiftyTwoWeekLow
+	dst.MarketState = src.MarketState
+	dst.MarketTime = src.MarketTime
+	dst.Exchange = src.Exchange
+	dst.ExchangeTimezone = src.ExchangeTimezone
+	dst.Currency = src.Currency
+	dst.FetchedAt = src.FetchedAt
+	if src.Volume != 0 {
+		dst.Volume = src.Volume
+	}
+	if src.MarketCap != 0 {
+		dst.MarketCap = src.MarketCap
+	}
+	return dst
+}
+
+// HasValuation reports whether any of P/E, EPS or book value is known
+func (d *StockData) HasValuation() bool {
+	return d.PERatio != 0 || d.EPS != 0 || d.BookValue != 0
+}
+
+// mergeFundamentals copies non-zero fundamental fields from src into dst
+func mergeFundamentals(dst, src *StockData) {
+	if src.PERatio != 0 {
+		dst.PERatio = src.PERatio
+	}
+	if src.EPS != 0 {
+		dst.EPS = src.EPS
+	}
+	if src.BookValue != 0 {
+		dst.BookValue = src.BookValue
+	}
+	if src.DividendYield != 0 {
+		dst.DividendYield = src.DividendYield
+	}
+	if src.MarketCap != 0 && dst.MarketCap == 0 {
+		dst.MarketCap = src.MarketCap
+	}
+	if src.Sector != "" {
+		dst.Sector = src.Sector
+		dst.Industry = src.Industry
+	}
+}
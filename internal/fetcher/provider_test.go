@@ -0,0 +1,209 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubProvider is an offline provider used to test the registry and pool
+type stubProvider struct {
+	fundamentalsErr error
+}
+
+func (s *stubProvider) FetchQuote(ctx context.Context, symbol string) (*StockData, error) {
+	if symbol == "FAIL" {
+		return nil, errors.New("no data")
+	}
+	return &StockData{Symbol: symbol, Price: 100}, nil
+}
+
+func (s *stubProvider) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	closes := []float64{98, 99, 100}
+	return &StockData{
+		Symbol:           symbol,
+		HistoricalPrices: closes,
+		HistoricalCloses: closes,
+		HistoricalHighs:  []float64{99, 100, 101},
+		HistoricalLows:   []float64{97, 98, 99},
+	}, nil
+}
+
+func (s *stubProvider) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
+	if s.fundamentalsErr != nil {
+		return nil, s.fundamentalsErr
+	}
+	return &StockData{Symbol: symbol, EPS: 5, BookValue: 50, PERatio: 20}, nil
+}
+
+func (s *stubProvider) CheckConnection() ConnectionResult {
+	return ConnectionResult{Connected: true}
+}
+
+func (s *stubProvider) GetMarketStatus(string) string { return "REGULAR" }
+
+func (s *stubProvider) Close() {}
+
+func TestProviderRegistry(t *testing.T) {
+	RegisterProvider("Stub", func(opts ProviderOptions) (Provider, error) {
+		return &stubProvider{}, nil
+	})
+
+	p, err := NewProvider("stub", ProviderOptions{})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	if p.GetMarketStatus("") != "REGULAR" {
+		t.Errorf("Expected stub provider, got %T", p)
+	}
+
+	if _, err := NewProvider("does-not-exist", ProviderOptions{}); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+
+	names := ProviderNames()
+	for _, want := range []string{"yahoo", "finance-go", "stub"} {
+		found := false
+		for _, n := range names {
+			if n == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %q in provider names %v", want, names)
+		}
+	}
+}
+
+func TestFetchComplete_Merge(t *testing.T) {
+	data, err := FetchComplete(context.Background(), &stubProvider{}, "TEST", DefaultHistoryOptions())
+	if err != nil {
+		t.Fatalf("FetchComplete failed: %v", err)
+	}
+	if data.Price != 100 || len(data.HistoricalCloses) != 3 {
+		t.Errorf("Quote/history not merged: %+v", data)
+	}
+	if data.EPS != 5 || data.BookValue != 50 {
+		t.Errorf("Fundamentals not merged: EPS=%v BookValue=%v", data.EPS, data.BookValue)
+	}
+
+	// Missing fundamentals must not fail the fetch
+	data, _ = FetchComplete(context.Background(), &stubProvider{fundamentalsErr: ErrNotSupported}, "TEST", DefaultHistoryOptions())
+	if data.Error != nil || data.EPS != 0 {
+		t.Errorf("Expected quote without fundamentals, got %+v", data)
+	}
+
+	// Quote errors are reported on the result
+	data, _ = FetchComplete(context.Background(), &stubProvider{}, "FAIL", DefaultHistoryOptions())
+	if data.Error == nil {
+		t.Error("Expected error for failed quote")
+	}
+}
+
+func TestWorkerPool_WithProvider(t *testing.T) {
+	pool := NewWorkerPoolWithProvider(2, &stubProvider{})
+
+	results := pool.FetchAll([]string{"AAA", "BBB", "CCC"})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Error != nil || r.Price != 100 {
+			t.Errorf("%s: unexpected result %+v", r.Symbol, r)
+		}
+	}
+}
+
+// quoteCountingProvider counts quote requests; its history may carry a live quote
+type quoteCountingProvider struct {
+	stubProvider
+	mu          sync.Mutex
+	quotes      int
+	batches     [][]string
+	liveHistory bool
+}
+
+func (q *quoteCountingProvider) FetchQuote(ctx context.Context, symbol string) (*StockData, error) {
+	q.mu.Lock()
+	q.quotes++
+	q.mu.Unlock()
+	return q.stubProvider.FetchQuote(ctx, symbol)
+}
+
+func (q *quoteCountingProvider) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	data, _ := q.stubProvider.FetchHistorical(ctx, symbol, days, interval)
+	if q.liveHistory {
+		data.Price = 101
+		data.FetchedAt = time.Now()
+	}
+	return data, nil
+}
+
+// batchingProvider also implements QuoteBatcher
+type batchingProvider struct {
+	quoteCountingProvider
+}
+
+func (b *batchingProvider) FetchQuotes(ctx context.Context, symbols []string) (map[string]*StockData, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, symbols)
+	b.mu.Unlock()
+
+	quotes := make(map[string]*StockData)
+	for _, s := range symbols {
+		quotes[s] = &StockData{Symbol: s, Price: 102}
+	}
+	return quotes, nil
+}
+
+func TestFetchComplete_QuoteSources(t *testing.T) {
+	ctx := context.Background()
+	hist := DefaultHistoryOptions()
+
+	// A fresh quote in the history response saves the quote request
+	p := &quoteCountingProvider{liveHistory: true}
+	data, _ := FetchComplete(ctx, p, "TEST", hist)
+	if p.quotes != 0 || data.Price != 101 || len(data.HistoricalCloses) != 3 {
+		t.Errorf("Expected quote from history without a request, got %d requests, %+v", p.quotes, data)
+	}
+
+	// History without a live quote (replay, cache) falls back to FetchQuote
+	p = &quoteCountingProvider{}
+	data, _ = FetchComplete(ctx, p, "TEST", hist)
+	if p.quotes != 1 || data.Price != 100 {
+		t.Errorf("Expected a quote request, got %d requests, price %v", p.quotes, data.Price)
+	}
+
+	// A prefetched quote is used as is
+	p = &quoteCountingProvider{}
+	data, _ = FetchCompleteWithQuote(ctx, p, "TEST", hist, &StockData{Symbol: "TEST", Price: 102})
+	if p.quotes != 0 || data.Price != 102 {
+		t.Errorf("Expected the given quote, got %d requests, price %v", p.quotes, data.Price)
+	}
+}
+
+func TestWorkerPool_BatchedQuotes(t *testing.T) {
+	p := &batchingProvider{}
+	pool := NewWorkerPoolWithProvider(2, NewCachedProvider(p, t.TempDir()))
+
+	symbols := make([]string, quotePrefetchSize+5)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%02d", i)
+	}
+
+	results := pool.FetchAll(symbols)
+	if len(results) != len(symbols) {
+		t.Fatalf("Expected %d results, got %d", len(symbols), len(results))
+	}
+	for _, r := range results {
+		if r.Price != 102 {
+			t.Errorf("%s: expected batched quote, got price %v", r.Symbol, r.Price)
+		}
+	}
+	if p.quotes != 0 || len(p.batches) != 2 {
+		t.Errorf("Expected 2 batches and no single quotes, got %d batches, %d quotes", len(p.batches), p.quotes)
+	}
+}
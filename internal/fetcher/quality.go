@@ -0,0 +1,180 @@
+package fetcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/calendar"
+)
+
+// staleQuoteAge is how old a quote may be during a regular session;
+// Yahoo delays some exchanges by 15-20 minutes
+const staleQuoteAge = 30 * time.Minute
+
+// DataQuality describes problems found in a symbol's price history
+type DataQuality struct {
+	MissingBars    int           // Bars the provider returned as null (dropped or forward-filled)
+	FilledBars     int           // Missing bars that were forward-filled
+	TimeGaps       int           // Jumps between bars longer than the interval allows
+	ZeroVolumeBars int           // Reported bars without volume
+	StaleLastBar   bool          // The latest bar is older than expected for the interval
+	LastBarAge     time.Duration // Age of the latest bar when fetched
+	StaleQuote     bool          // The quote is older than the exchange's trading calendar allows
+	QuoteAge       time.Duration // Age of the quote's last price update when fetched
+}
+
+// OK reports whether no problems were found
+func (q DataQuality) OK() bool {
+	return q.MissingBars == 0 && q.TimeGaps == 0 && q.ZeroVolumeBars == 0 && !q.StaleLastBar && !q.StaleQuote
+}
+
+// Issues returns short descriptions of the problems found
+func (q DataQuality) Issues() []string {
+	var issues []string
+	if q.MissingBars > 0 {
+		if q.FilledBars > 0 {
+			issues = append(issues, fmt.Sprintf("%d missing bars (filled)", q.MissingBars))
+		} else {
+			issues = append(issues, fmt.Sprintf("%d missing bars (dropped)", q.MissingBars))
+		}
+	}
+	if q.TimeGaps > 0 {
+		issues = append(issues, fmt.Sprintf("%d time gaps", q.TimeGaps))
+	}
+	if q.ZeroVolumeBars > 0 {
+		issues = append(issues, fmt.Sprintf("%d zero-volume bars", q.ZeroVolumeBars))
+	}
+	if q.StaleLastBar {
+		issues = append(issues, fmt.Sprintf("stale last bar (%s old)", formatAge(q.LastBarAge)))
+	}
+	if q.StaleQuote {
+		issues = append(issues, fmt.Sprintf("stale quote (%s old)", formatAge(q.QuoteAge)))
+	}
+	return issues
+}
+
+// formatAge formats a duration in days, hours or minutes
+func formatAge(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh", int(d.Hours()))
+}
+
+// maxBarSpacing is the longest normal distance between consecutive bars,
+// covering weekends and single holidays
+func (iv Interval) maxBarSpacing() time.Duration {
+	switch iv {
+	case Interval1h:
+		return 4 * 24 * time.Hour // Friday close to Tuesday open over a long weekend
+	case Interval1wk:
+		return 14 * 24 * time.Hour
+	case Interval1mo:
+		return 62 * 24 * time.Hour
+	default:
+		return 5 * 24 * time.Hour
+	}
+}
+
+// AssessQuality inspects a historical series and its dropped null bars
+func AssessQuality(data *StockData, interval Interval, now time.Time) DataQuality {
+	q := DataQuality{MissingBars: len(data.Gaps)}
+
+	filled := make(map[int64]bool, len(data.Gaps))
+	for _, g := range data.Gaps {
+		filled[g.Unix()] = true
+	}
+
+	maxSpacing := interval.maxBarSpacing()
+	for i, b := range data.Bars {
+		if filled[b.Time.Unix()] {
+			q.FilledBars++
+			continue
+		}
+		if b.Volume == 0 {
+			q.ZeroVolumeBars++
+		}
+		if i > 0 && !b.Time.IsZero() && b.Time.Sub(data.Bars[i-1].Time) > maxSpacing {
+			q.TimeGaps++
+		}
+	}
+
+	if n := len(data.Bars); n > 0 && !data.Bars[n-1].Time.IsZero() {
+		q.LastBarAge = now.Sub(data.Bars[n-1].Time)
+		q.StaleLastBar = q.LastBarAge > maxSpacing
+	}
+
+	return q
+}
+
+// AssessQuote flags a quote older than the exchange's trading calendar
+// allows: more than staleQuoteAge old during a regular session, or from
+// before the latest session's open otherwise. Exchanges without a calendar
+// are not checked.
+func AssessQuote(q *DataQuality, data *StockData, now time.Time) {
+	if data.MarketTime.IsZero() {
+		return
+	}
+	q.QuoteAge = now.Sub(data.MarketTime)
+
+	cal, ok := calendar.ForExchange(data.Exchange)
+	if !ok {
+		return
+	}
+	if cal.IsOpen(now) {
+		q.StaleQuote = q.QuoteAge > staleQuoteAge
+		return
+	}
+	if s, ok := cal.LastSession(now); ok {
+		q.StaleQuote = data.MarketTime.Before(s.Open)
+	}
+}
+
+// FillGaps forward-fills the null bars recorded in data.Gaps: each becomes a
+// flat bar at the previous close with zero volume. Gaps before the first
+// valid bar are left out.
+func FillGaps(data *StockData) {
+	if len(data.Gaps) == 0 || len(data.Bars) == 0 {
+		return
+	}
+
+	bars := make([]Bar, 0, len(data.Bars)+len(data.Gaps))
+	gaps := data.Gaps
+	for _, b := range data.Bars {
+		for len(gaps) > 0 && gaps[0].Before(b.Time) {
+			if len(bars) > 0 {
+				prev := bars[len(bars)-1].Close
+				bars = append(bars, Bar{Time: gaps[0], Open: prev, High: prev, Low: prev, Close: prev})
+			}
+			gaps = gaps[1:]
+		}
+		bars = append(bars, b)
+	}
+	// Trailing gaps (e.g. a halted latest session)
+	for _, g := range gaps {
+		prev := bars[len(bars)-1].Close
+		bars = append(bars, Bar{Time: g, Open: prev, High: prev, Low: prev, Close: prev})
+	}
+
+	data.Bars = bars
+	setSeriesFromBars(data)
+}
+
+// setSeriesFromBars rebuilds the close/high/low series from data.Bars
+func setSeriesFromBars(data *StockData) {
+	closes := make([]float64, len(data.Bars))
+	highs := make([]float64, len(data.Bars))
+	lows := make([]float64, len(data.Bars))
+	for i, b := range data.Bars {
+		closes[i] = b.Close
+		highs[i] = b.High
+		lows[i] = b.Low
+	}
+	data.HistoricalCloses = closes
+	data.HistoricalPrices = closes
+	data.HistoricalHighs = highs
+	data.HistoricalLows = lows
+}
@@ -0,0 +1,99 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+)
+
+// chartWithNulls has a fully null bar (day 3), a bar with a null high (day 4)
+// and a zero-volume bar (day 5)
+const chartWithNulls = `{"chart":{"result":[{"meta":{"symbol":"TEST"},
+"timestamp":[1704205800,1704292200,1704378600,1704465000,1704724200],
+"indicators":{"quote":[{
+"open":[10,11,null,12,13],
+"high":[10.5,11.5,null,null,13.5],
+"low":[9.5,10.5,null,11.5,12.5],
+"close":[10,11,null,12,13],
+"volume":[100,200,null,300,0]}]}}],"error":null}}`
+
+func TestParseChartHistorical_Nulls(t *testing.T) {
+	data, err := parseChartHistorical([]byte(chartWithNulls), "TEST")
+	if err != nil {
+		t.Fatalf("parseChartHistorical failed: %v", err)
+	}
+
+	if len(data.Bars) != 4 || len(data.HistoricalCloses) != 4 {
+		t.Fatalf("Expected null bar to be dropped, got %d bars", len(data.Bars))
+	}
+	for _, c := range data.HistoricalCloses {
+		if c == 0 {
+			t.Fatalf("Null decoded as zero close: %v", data.HistoricalCloses)
+		}
+	}
+	if len(data.Gaps) != 1 || data.Gaps[0].Unix() != 1704378600 {
+		t.Errorf("Expected one gap at the null bar, got %v", data.Gaps)
+	}
+	// Null high falls back to the close
+	if data.Bars[2].High != 12 {
+		t.Errorf("Expected null high to fall back to close, got %v", data.Bars[2].High)
+	}
+
+	q := AssessQuality(data, Interval1d, time.Unix(1704724200, 0).Add(time.Hour))
+	if q.MissingBars != 1 || q.ZeroVolumeBars != 1 || q.StaleLastBar || q.OK() {
+		t.Errorf("Unexpected quality report: %+v", q)
+	}
+
+	// The same series a month later is stale
+	if q := AssessQuality(data, Interval1d, time.Unix(1704724200, 0).AddDate(0, 1, 0)); !q.StaleLastBar {
+		t.Errorf("Expected stale last bar, got %+v", q)
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	data, err := parseChartHistorical([]byte(chartWithNulls), "TEST")
+	if err != nil {
+		t.Fatalf("parseChartHistorical failed: %v", err)
+	}
+
+	FillGaps(data)
+
+	if len(data.Bars) != 5 || len(data.HistoricalCloses) != 5 {
+		t.Fatalf("Expected null bar to be filled, got %d bars", len(data.Bars))
+	}
+	filled := data.Bars[2]
+	if filled.Close != 11 || filled.High != 11 || filled.Volume != 0 || filled.Time.Unix() != 1704378600 {
+		t.Errorf("Expected flat bar at previous close, got %+v", filled)
+	}
+
+	q := AssessQuality(data, Interval1d, time.Unix(1704724200, 0))
+	if q.MissingBars != 1 || q.FilledBars != 1 || q.ZeroVolumeBars != 1 {
+		t.Errorf("Filled bars must not count as zero volume: %+v", q)
+	}
+}
+
+func TestAssessQuote(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	session := time.Date(2026, 10, 16, 11, 0, 0, 0, ny) // Friday, market open
+	weekend := time.Date(2026, 10, 18, 11, 0, 0, 0, ny)
+
+	tests := []struct {
+		name       string
+		exchange   string
+		marketTime time.Time
+		now        time.Time
+		stale      bool
+	}{
+		{"fresh in session", "NMS", session.Add(-5 * time.Minute), session, false},
+		{"old in session", "NMS", session.Add(-2 * time.Hour), session, true},
+		{"friday close on sunday", "NYQ", time.Date(2026, 10, 16, 16, 0, 0, 0, ny), weekend, false},
+		{"thursday close on sunday", "NYQ", time.Date(2026, 10, 15, 16, 0, 0, 0, ny), weekend, true},
+		{"no calendar", "JPX", session.Add(-48 * time.Hour), session, false},
+	}
+	for _, tt := range tests {
+		var q DataQuality
+		AssessQuote(&q, &StockData{Exchange: tt.exchange, MarketTime: tt.marketTime}, tt.now)
+		if q.StaleQuote != tt.stale {
+			t.Errorf("%s: expected stale=%v, got %v (age %s)", tt.name, tt.stale, q.StaleQuote, q.QuoteAge)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRequestRate is the steady request rate towards Yahoo (requests/second)
+	defaultRequestRate = 4.0
+	// minRequestRate is the floor the limiter slows down to after repeated 429s
+	minRequestRate = 0.5
+	// rateRecoveryStep is added to the rate after every successful request
+	rateRecoveryStep = 0.05
+
+	// maxRetries is the retry budget of a single request (429, 5xx)
+	maxRetries = 3
+	// baseBackoff is the first retry delay; it doubles on each further retry
+	baseBackoff = time.Second
+	// maxBackoff caps a single retry delay, including Retry-After
+	maxBackoff = 30 * time.Second
+)
+
+// LimiterStats describes the state of a rate limiter
+type LimiterStats struct {
+	Rate         float64       // Current request rate (requests/second)
+	MaxRate      float64       // Configured request rate
+	Throttled    time.Duration // Total time requests were paused by backoff
+	PausedFor    time.Duration // Remaining pause right now (0 if not paused)
+	Retries      int64         // Requests retried after 429/5xx
+	RateLimited  int64         // 429 responses received
+	ServerErrors int64         // 5xx responses received
+}
+
+// Slowed reports whether the limiter had to back off at all
+func (s LimiterStats) Slowed() bool {
+	return s.Retries > 0 || s.Throttled > 0 || (s.MaxRate > 0 && s.Rate < s.MaxRate)
+}
+
+// Since returns the stats accumulated after base was taken.
+// Rate and PausedFor are current values and are kept as they are.
+func (s LimiterStats) Since(base LimiterStats) LimiterStats {
+	s.Throttled -= base.Throttled
+	s.Retries -= base.Retries
+	s.RateLimited -= base.RateLimited
+	s.ServerErrors -= base.ServerErrors
+	return s
+}
+
+// Reason explains in a few words why requests are slower than configured
+func (s LimiterStats) Reason() string {
+	var parts []string
+	if s.PausedFor > 0 {
+		parts = append(parts, fmt.Sprintf("paused %.0fs after rate limit", math.Ceil(s.PausedFor.Seconds())))
+	}
+	if s.RateLimited > 0 {
+		parts = append(parts, fmt.Sprintf("%d rate limited (429)", s.RateLimited))
+	}
+	if s.ServerErrors > 0 {
+		parts = append(parts, fmt.Sprintf("%d server errors", s.ServerErrors))
+	}
+	if s.Retries > 0 {
+		parts = append(parts, fmt.Sprintf("%d retries", s.Retries))
+	}
+	if s.MaxRate > 0 && s.Rate < s.MaxRate {
+		parts = append(parts, fmt.Sprintf("rate %.1f/%.0f req/s", s.Rate, s.MaxRate))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RateLimited is implemented by providers that throttle their requests
+type RateLimited interface {
+	LimiterStats() LimiterStats
+}
+
+// LimiterStatsOf returns the limiter state of a provider, looking through
+// wrappers such as CachedProvider
+func LimiterStatsOf(p Provider) (LimiterStats, bool) {
+	if rl, ok := findProvider[RateLimited](p); ok {
+		return rl.LimiterStats(), true
+	}
+	return LimiterStats{}, false
+}
+
+// RateLimiter is a token bucket shared by all requests of a client.
+// A 429 halves the rate and pauses every request until the backoff has
+// passed; successful requests slowly raise the rate back to the maximum.
+type RateLimiter struct {
+	mu           sync.Mutex
+	rate         float64
+	maxRate      float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	pausedUntil  time.Time
+	throttled    time.Duration
+	retries      int64
+	rateLimited  int64
+	serverErrors int64
+}
+
+// NewRateLimiter creates a limiter allowing rate requests per second with the given burst
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		maxRate: rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// refill adds the tokens earned since the last call. Callers hold mu.
+func (l *RateLimiter) refill(now time.Time) {
+	if now.After(l.last) {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		l.last = now
+	}
+}
+
+// Wait blocks until a request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+
+		var wait time.Duration
+		if now.Before(l.pausedUntil) {
+			wait = l.pausedUntil.Sub(now)
+		} else {
+			l.refill(now)
+			if l.tokens >= 1 {
+				l.tokens--
+				l.mu.Unlock()
+				return nil
+			}
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Success records a successful request and lets the rate recover
+func (l *RateLimiter) Success() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = math.Min(l.maxRate, l.rate+rateRecoveryStep)
+}
+
+// RateLimitedFor records a 429: the rate is halved and all requests pause for d
+func (l *RateLimiter) RateLimitedFor(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rateLimited++
+	l.rate = math.Max(minRequestRate, l.rate/2)
+
+	now := time.Now()
+	until := now.Add(d)
+	if until.After(l.pausedUntil) {
+		from := now
+		if l.pausedUntil.After(now) {
+			from = l.pausedUntil
+		}
+		l.throttled += until.Sub(from)
+		l.pausedUntil = until
+	}
+
+	// No burst right after the pause
+	l.tokens = 0
+	l.last = l.pausedUntil
+}
+
+// ServerError records a 5xx response
+func (l *RateLimiter) ServerError() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.serverErrors++
+}
+
+// Retry records that a request is being retried
+func (l *RateLimiter) Retry() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.retries++
+}
+
+// Stats returns the limiter state
+func (l *RateLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := LimiterStats{
+		Rate:         l.rate,
+		MaxRate:      l.maxRate,
+		Throttled:    l.throttled,
+		Retries:      l.retries,
+		RateLimited:  l.rateLimited,
+		ServerErrors: l.serverErrors,
+	}
+	if d := time.Until(l.pausedUntil); d > 0 {
+		stats.PausedFor = d
+	}
+	return stats
+}
+
+// backoffDelay returns the delay before retry number attempt (0-based).
+// Retry-After wins when the server sent one; otherwise the delay doubles per
+// attempt with jitter so parallel workers don't retry in lockstep.
+func backoffDelay(attempt int, retryAfter time.Duration) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		d = baseBackoff << uint(attempt)
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	// Up to 50% jitter on top
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+// parseRetryAfter reads a Retry-After header (seconds or HTTP date)
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
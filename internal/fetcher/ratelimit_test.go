@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt := 0; attempt < 3; attempt++ {
+		base := baseBackoff << uint(attempt)
+		d := backoffDelay(attempt, 0)
+		if d < base || d > base+base/2 {
+			t.Errorf("attempt %d: delay %v outside [%v, %v]", attempt, d, base, base+base/2)
+		}
+	}
+
+	// Retry-After wins over the exponential delay
+	if d := backoffDelay(0, 5*time.Second); d < 5*time.Second || d > 7500*time.Millisecond {
+		t.Errorf("Retry-After 5s: got %v", d)
+	}
+
+	// Capped
+	if d := backoffDelay(20, 0); d > maxBackoff+maxBackoff/2 {
+		t.Errorf("expected delay capped near %v, got %v", maxBackoff, d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"0", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.value != "" {
+			h.Set("Retry-After", tt.value)
+		}
+		if got := parseRetryAfter(h, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter_RateLimitedFor(t *testing.T) {
+	l := NewRateLimiter(4, 2)
+
+	l.RateLimitedFor(200 * time.Millisecond)
+
+	stats := l.Stats()
+	if stats.Rate != 2 {
+		t.Errorf("expected rate halved to 2, got %v", stats.Rate)
+	}
+	if stats.RateLimited != 1 {
+		t.Errorf("expected 1 rate limited response, got %d", stats.RateLimited)
+	}
+	if stats.PausedFor <= 0 || !stats.Slowed() {
+		t.Errorf("expected limiter paused and slowed, got %+v", stats)
+	}
+
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 150*time.Millisecond {
+		t.Errorf("Wait returned after %v, expected to honour the pause", waited)
+	}
+
+	// Successes let the rate recover, but never above the maximum
+	for i := 0; i < 100; i++ {
+		l.Success()
+	}
+	if rate := l.Stats().Rate; rate != 4 {
+		t.Errorf("expected rate recovered to 4, got %v", rate)
+	}
+
+	// The rate never drops below the floor
+	for i := 0; i < 10; i++ {
+		l.RateLimitedFor(0)
+	}
+	if rate := l.Stats().Rate; rate != minRequestRate {
+		t.Errorf("expected rate floor %v, got %v", minRequestRate, rate)
+	}
+}
+
+func TestRateLimiter_WaitCancelled(t *testing.T) {
+	l := NewRateLimiter(4, 1)
+	l.RateLimitedFor(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err == nil {
+		t.Error("expected Wait to fail when the context is cancelled")
+	}
+}
+
+func TestLimiterStats_Since(t *testing.T) {
+	base := LimiterStats{Rate: 4, MaxRate: 4, Retries: 2, RateLimited: 1}
+	now := LimiterStats{Rate: 2, MaxRate: 4, Retries: 5, RateLimited: 3, Throttled: time.Second}
+
+	d := now.Since(base)
+	if d.Retries != 3 || d.RateLimited != 2 || d.Rate != 2 {
+		t.Errorf("unexpected delta %+v", d)
+	}
+	if d.Reason() == "" {
+		t.Error("expected a throttling reason")
+	}
+	if (LimiterStats{Rate: 4, MaxRate: 4}).Slowed() {
+		t.Error("limiter at full rate without retries should not be slowed")
+	}
+}
+
+func TestMakeRequest_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewDirectYahooClient()
+	defer client.Close()
+
+	body, err := client.makeRequest(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("makeRequest failed: %v", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	stats := client.LimiterStats()
+	if stats.Retries != 1 || stats.ServerErrors != 1 {
+		t.Errorf("expected 1 retry after 1 server error, got %+v", stats)
+	}
+}
+
+func TestMakeRequest_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewDirectYahooClient()
+	defer client.Close()
+
+	if _, err := client.makeRequest(context.Background(), server.URL); err == nil {
+		t.Fatal("expected an error for 404")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected a single request, got %d", n)
+	}
+}
@@ -0,0 +1,187 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quoteRangeKey is the recording key for the range=1d quote request
+const quoteRangeKey = "quote"
+
+// historicalRangeKey returns the recording key for a historical request of n days,
+// e.g. 60d for daily bars or 365d-1wk for weekly ones
+func historicalRangeKey(days int, interval Interval) string {
+	return fmt.Sprintf("%dd", days) + intervalSuffix(interval)
+}
+
+// intervalSuffix returns the range key suffix for non-daily intervals
+func intervalSuffix(interval Interval) string {
+	if interval == "" || interval == Interval1d {
+		return ""
+	}
+	return "-" + string(interval)
+}
+
+// recordingFileName returns the file name used for a symbol and range key,
+// e.g. AAPL.quote.json or BRK-B.60d.json
+func recordingFileName(symbol, rangeKey string) string {
+	safe := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.ToUpper(symbol))
+	return safe + "." + rangeKey + ".json"
+}
+
+// recordChart saves a raw chart response body to dir
+func recordChart(dir, symbol, rangeKey string, body []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, recordingFileName(symbol, rangeKey)), body, 0644)
+}
+
+// ReplayProvider serves chart responses previously recorded with --record.
+// It never touches the network, which makes scans reproducible and lets
+// tests run offline.
+type ReplayProvider struct {
+	dir string
+}
+
+// NewReplayProvider creates a provider that reads recordings from dir
+func NewReplayProvider(dir string) *ReplayProvider {
+	return &ReplayProvider{dir: dir}
+}
+
+// readRecording reads a recorded response for a symbol and range key
+func (r *ReplayProvider) readRecording(symbol, rangeKey string) ([]byte, error) {
+	body, err := os.ReadFile(filepath.Join(r.dir, recordingFileName(symbol, rangeKey)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recording for %s (%s)", symbol, rangeKey)
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// recordedRanges returns the recorded historical ranges (in days) for a symbol
+// and interval, sorted ascending
+func (r *ReplayProvider) recordedRanges(symbol string, interval Interval) []int {
+	prefix := strings.TrimSuffix(recordingFileName(symbol, ""), ".json")
+	suffix := "d" + intervalSuffix(interval) + ".json"
+	matches, _ := filepath.Glob(filepath.Join(r.dir, prefix+"*"+suffix))
+
+	var ranges []int
+	for _, m := range matches {
+		key := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), prefix), suffix)
+		if days, err := strconv.Atoi(key); err == nil {
+			ranges = append(ranges, days)
+		}
+	}
+	sort.Ints(ranges)
+	return ranges
+}
+
+// FetchQuote returns the recorded quote for a symbol. A recording made
+// through FetchComplete may hold only the chart, whose meta carried the
+// quote; the meta of the shortest daily chart is used then.
+func (r *ReplayProvider) FetchQuote(ctx context.Context, symbol string) (*StockData, error) {
+	body, err := r.readRecording(symbol, quoteRangeKey)
+	if err != nil {
+		ranges := r.recordedRanges(symbol, Interval1d)
+		if len(ranges) == 0 {
+			return nil, err
+		}
+		if body, err = r.readRecording(symbol, historicalRangeKey(ranges[0], Interval1d)); err != nil {
+			return nil, err
+		}
+	}
+	return parseChartQuote(body, symbol)
+}
+
+// FetchHistorical returns the recorded history for a symbol.
+// If the exact range wasn't recorded, the smallest longer range is used,
+// falling back to the longest available one.
+func (r *ReplayProvider) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	ranges := r.recordedRanges(symbol, interval)
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no recording for %s (%s)", symbol, historicalRangeKey(days, interval))
+	}
+
+	chosen := ranges[len(ranges)-1]
+	for _, n := range ranges {
+		if n >= days {
+			chosen = n
+			break
+		}
+	}
+
+	body, err := r.readRecording(symbol, historicalRangeKey(chosen, interval))
+	if err != nil {
+		return nil, err
+	}
+	return parseChartHistorical(body, symbol)
+}
+
+// FetchFundamentals returns the recorded quoteSummary response for a symbol
+func (r *ReplayProvider) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
+	body, err := r.readRecording(symbol, fundamentalsRangeKey)
+	if err != nil {
+		return nil, err
+	}
+	return parseQuoteSummary(body, symbol)
+}
+
+// CheckConnection verifies that the recording directory is readable
+func (r *ReplayProvider) CheckConnection() ConnectionResult {
+	start := time.Now()
+	result := ConnectionResult{
+		Details: []string{"Replaying recordings from " + r.dir},
+	}
+
+	matches, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil || len(matches) == 0 {
+		result.Error = "No recordings found in " + r.dir
+		result.Details = append(result.Details, "FAIL: no recordings")
+		return result
+	}
+
+	quotes, _ := filepath.Glob(filepath.Join(r.dir, "*."+quoteRangeKey+".json"))
+	fundamentals, _ := filepath.Glob(filepath.Join(r.dir, "*."+fundamentalsRangeKey+".json"))
+	charts := len(matches) - len(quotes) - len(fundamentals)
+	result.QuoteWorks = len(quotes) > 0
+	result.ChartWorks = charts > 0
+	result.EquityWorks = len(fundamentals) > 0
+	result.Details = append(result.Details,
+		fmt.Sprintf("OK: %d quote, %d chart and %d fundamentals recordings", len(quotes), charts, len(fundamentals)))
+
+	result.Latency = time.Since(start)
+	result.Connected = result.QuoteWorks
+	if result.Connected {
+		result.Details = append(result.Details, "Connection OK!")
+	} else {
+		result.Error = "No quote recordings found"
+		result.Details = append(result.Details, "Connection FAILED!")
+	}
+
+	return result
+}
+
+// GetMarketStatus returns the recorded market state of symbol's exchange
+// (SPY when empty), or CLOSED
+func (r *ReplayProvider) GetMarketStatus(symbol string) string {
+	if symbol == "" {
+		symbol = MarketStatusSymbol
+	}
+	data, err := r.FetchQuote(context.Background(), symbol)
+	if err != nil || data.MarketState == "" {
+		return "CLOSED"
+	}
+	return data.MarketState
+}
+
+// Close is a no-op
+func (r *ReplayProvider) Close() {}
@@ -0,0 +1,168 @@
+package fetcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const replayFixtures = "testdata/replay"
+
+func TestReplayProvider_FetchQuote(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	data, err := p.FetchQuote(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatalf("FetchQuote failed: %v", err)
+	}
+
+	if data.Symbol != "AAPL" {
+		t.Errorf("Expected symbol AAPL, got %s", data.Symbol)
+	}
+	if data.Price <= 0 {
+		t.Errorf("Expected positive price, got %f", data.Price)
+	}
+
+	if _, err := p.FetchQuote(context.Background(), "NOPE"); err == nil {
+		t.Error("Expected error for symbol without recording")
+	}
+}
+
+func TestReplayProvider_FetchHistorical(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	// 60d is recorded exactly; 30d and 365d fall back to the 60d recording
+	for _, days := range []int{60, 30, 365} {
+		data, err := p.FetchHistorical(context.Background(), "MSFT", days, Interval1d)
+		if err != nil {
+			t.Fatalf("FetchHistorical(%d) failed: %v", days, err)
+		}
+		if len(data.HistoricalCloses) == 0 {
+			t.Errorf("FetchHistorical(%d): expected historical data", days)
+		}
+	}
+}
+
+func TestParseChartHistorical_Bars(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	data, err := p.FetchHistorical(context.Background(), "AAPL", 60, Interval1d)
+	if err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+
+	if len(data.Bars) != len(data.HistoricalCloses) {
+		t.Fatalf("Expected one bar per close, got %d bars and %d closes", len(data.Bars), len(data.HistoricalCloses))
+	}
+	for i, bar := range data.Bars {
+		if bar.Time.IsZero() || bar.Open <= 0 || bar.Volume <= 0 {
+			t.Fatalf("Bar %d missing time/open/volume: %+v", i, bar)
+		}
+		if bar.Close != data.HistoricalCloses[i] {
+			t.Fatalf("Bar %d close %v doesn't match series %v", i, bar.Close, data.HistoricalCloses[i])
+		}
+		if i > 0 && !bar.Time.After(data.Bars[i-1].Time) {
+			t.Fatalf("Bars not in time order at %d", i)
+		}
+	}
+}
+
+func TestReplayProvider_FetchComplete(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	data, err := FetchComplete(context.Background(), p, "AAPL", DefaultHistoryOptions())
+	if err != nil || data.Error != nil {
+		t.Fatalf("FetchComplete failed: %v %v", err, data.Error)
+	}
+	if data.Price <= 0 || len(data.HistoricalCloses) < 30 {
+		t.Errorf("Expected price and history, got price=%.2f bars=%d", data.Price, len(data.HistoricalCloses))
+	}
+
+	if !p.CheckConnection().Connected {
+		t.Error("Expected replay connection to succeed")
+	}
+	if p.GetMarketStatus("") != "CLOSED" {
+		t.Errorf("Expected recorded SPY market state CLOSED, got %s", p.GetMarketStatus(""))
+	}
+}
+
+// A fund's quoteSummary answers with every valuation value null; its
+// valuation must stay unknown rather than zero
+func TestReplayProvider_FetchCompleteNullFundamentals(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	data, err := FetchComplete(context.Background(), p, "SPY", DefaultHistoryOptions())
+	if err != nil || data.Error != nil {
+		t.Fatalf("FetchComplete failed: %v %v", err, data.Error)
+	}
+	if data.HasFundamentals || data.PERatio != 0 || data.EPS != 0 || data.BookValue != 0 {
+		t.Errorf("Expected unknown valuation, got has=%v P/E=%v EPS=%v BV=%v",
+			data.HasFundamentals, data.PERatio, data.EPS, data.BookValue)
+	}
+	if data.DividendYield == 0 {
+		t.Error("Expected the recorded dividend yield to be merged")
+	}
+
+	data, err = FetchComplete(context.Background(), p, "AAPL", DefaultHistoryOptions())
+	if err != nil || !data.HasFundamentals {
+		t.Errorf("Expected AAPL to have fundamentals: %v", err)
+	}
+}
+
+// A recording made through FetchComplete holds no quote when the chart meta
+// provided it; replaying it must take the quote from the chart again
+func TestReplayProvider_FetchCompleteWithoutQuote(t *testing.T) {
+	dir := t.TempDir()
+	opts := DefaultHistoryOptions()
+	for rangeKey, fixture := range map[string]string{
+		historicalRangeKey(opts.Days, opts.Interval): "AAPL.60d.json",
+		fundamentalsRangeKey:                         "AAPL.fundamentals.json",
+	} {
+		body, err := os.ReadFile(filepath.Join(replayFixtures, fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := recordChart(dir, "AAPL", rangeKey, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := FetchComplete(context.Background(), NewReplayProvider(dir), "AAPL", opts)
+	if err != nil || data.Error != nil {
+		t.Fatalf("FetchComplete failed: %v %v", err, data.Error)
+	}
+	if data.Price <= 0 || len(data.HistoricalCloses) == 0 || data.EPS == 0 {
+		t.Errorf("Expected price, history and fundamentals, got price=%.2f bars=%d eps=%.2f",
+			data.Price, len(data.HistoricalCloses), data.EPS)
+	}
+
+	if _, err := NewReplayProvider(dir).FetchQuote(context.Background(), "MSFT"); err == nil {
+		t.Error("Expected error for symbol without any recording")
+	}
+}
+
+func TestRecordChart_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	body, err := os.ReadFile(filepath.Join(replayFixtures, "AAPL.60d.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordChart(dir, "brk-b", historicalRangeKey(90, Interval1d), body); err != nil {
+		t.Fatalf("recordChart failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "BRK-B.90d.json")); err != nil {
+		t.Errorf("Expected recording file: %v", err)
+	}
+
+	p := NewReplayProvider(dir)
+	data, err := p.FetchHistorical(context.Background(), "BRK-B", 90, Interval1d)
+	if err != nil {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+	if len(data.HistoricalCloses) == 0 {
+		t.Error("Expected replayed history")
+	}
+}
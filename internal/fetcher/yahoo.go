@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/piquette/finance-go/chart"
@@ -24,20 +23,41 @@ type StockData struct {
 	PERatio          float64
 	EPS              float64
 	BookValue        float64
-	DividendYield    float64
+	DividendYield    float64 // Percent
+	HasFundamentals  bool    // False when the provider couldn't supply P/E, EPS, book value
+	Sector           string  // From the provider's company profile; empty when unknown
+	Industry         string
 	FiftyTwoWeekHigh float64
 	FiftyTwoWeekLow  float64
 	HistoricalPrices []float64
 	HistoricalHighs  []float64
 	HistoricalLows   []float64
 	HistoricalCloses []float64
+	Bars             []Bar       // OHLCV history, oldest first
+	Gaps             []time.Time // Times of null bars dropped from Bars
+	Quality          DataQuality // Set by FetchComplete
 	ShortName        string
 	Exchange         string
+	ExchangeTimezone string // IANA name, e.g. Europe/London
+	Currency         string // ISO code the prices are in; minor units are converted (GBp -> GBP)
 	MarketState      string
+	PreviousClose    float64   // Close the change is measured from (0 if unknown)
+	MarketTime       time.Time // Time of the last price update
+	FetchedAt        time.Time // When a live provider response was received (zero for replay/cache)
 	Error            error
 	FetchDuration    time.Duration
 }
 
+// Bar is one OHLCV price bar
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume int64
+}
+
 // YahooClient wraps the finance-go library
 type YahooClient struct {
 	timeout time.Duration
@@ -51,7 +71,7 @@ func NewYahooClient() *YahooClient {
 }
 
 // FetchQuote fetches real-time quote data for a symbol
-func (c *YahooClient) FetchQuote(symbol string) (*StockData, error) {
+func (c *YahooClient) FetchQuote(ctx context.Context, symbol string) (*StockData, error) {
 	q, err := quote.Get(symbol)
 	if err != nil {
 		return nil, err
@@ -67,31 +87,34 @@ func (c *YahooClient) FetchQuote(symbol string) (*StockData, error) {
 		FiftyTwoWeekLow:  q.FiftyTwoWeekLow,
 		ShortName:        q.ShortName,
 		Exchange:         q.FullExchangeName,
+		ExchangeTimezone: q.ExchangeTimezoneName,
 		MarketState:      string(q.MarketState),
 	}
+	scaleMinorUnits(data, q.CurrencyID)
 
 	return data, nil
 }
 
-// FetchEquity fetches fundamental data for a symbol
-func (c *YahooClient) FetchEquity(symbol string) (*StockData, error) {
+// FetchFundamentals fetches fundamental data for a symbol
+func (c *YahooClient) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
 	eq, err := equity.Get(symbol)
 	if err != nil {
 		return nil, err
 	}
 
 	data := &StockData{
-		Symbol:    symbol,
-		PERatio:   eq.TrailingPE,
-		EPS:       eq.EpsTrailingTwelveMonths,
-		BookValue: eq.BookValue,
+		Symbol:        symbol,
+		PERatio:       eq.TrailingPE,
+		EPS:           eq.EpsTrailingTwelveMonths,
+		BookValue:     eq.BookValue,
+		DividendYield: eq.TrailingAnnualDividendYield * 100,
 	}
 
 	return data, nil
 }
 
 // FetchHistorical fetches historical price data
-func (c *YahooClient) FetchHistorical(symbol string, period int) (*StockData, error) {
+func (c *YahooClient) FetchHistorical(ctx context.Context, symbol string, period int, interval Interval) (*StockData, error) {
 	// Calculate start and end dates
 	end := time.Now()
 	start := end.AddDate(0, 0, -period)
@@ -100,108 +123,66 @@ func (c *YahooClient) FetchHistorical(symbol string, period int) (*StockData, er
 		Symbol:   symbol,
 		Start:    datetime.New(&start),
 		End:      datetime.New(&end),
-		Interval: datetime.OneDay,
+		Interval: datetime.Interval(interval),
 	}
 
 	iter := chart.Get(params)
 
-	var prices, highs, lows, closes []float64
+	data := &StockData{Symbol: symbol}
 	for iter.Next() {
 		bar := iter.Bar()
+		t := time.Unix(int64(bar.Timestamp), 0)
+
+		// finance-go decodes null bars as zero
 		closePrice, _ := bar.Close.Float64()
+		if closePrice <= 0 {
+			data.Gaps = append(data.Gaps, t)
+			continue
+		}
+
+		openPrice, _ := bar.Open.Float64()
 		highPrice, _ := bar.High.Float64()
 		lowPrice, _ := bar.Low.Float64()
 
-		prices = append(prices, closePrice)
-		highs = append(highs, highPrice)
-		lows = append(lows, lowPrice)
-		closes = append(closes, closePrice)
+		data.Bars = append(data.Bars, Bar{
+			Time:   t,
+			Open:   openPrice,
+			High:   highPrice,
+			Low:    lowPrice,
+			Close:  closePrice,
+			Volume: int64(bar.Volume),
+		})
 	}
 
 	if err := iter.Err(); err != nil {
 		return nil, err
 	}
 
-	return &StockData{
-		Symbol:           symbol,
-		HistoricalPrices: prices,
-		HistoricalHighs:  highs,
-		HistoricalLows:   lows,
-		HistoricalCloses: closes,
-	}, nil
+	setSeriesFromBars(data)
+	return data, nil
 }
 
 // FetchComplete fetches all available data for a symbol using parallel requests
 func (c *YahooClient) FetchComplete(ctx context.Context, symbol string) (*StockData, error) {
-	start := time.Now()
-
-	var (
-		quoteData  *StockData
-		equityData *StockData
-		histData   *StockData
-		quoteErr   error
-		equityErr  error
-		histErr    error
-		wg         sync.WaitGroup
-	)
-
-	// Fetch all data in parallel for speed
-	wg.Add(3)
-
-	// Fetch quote data
-	go func() {
-		defer wg.Done()
-		quoteData, quoteErr = c.FetchQuote(symbol)
-	}()
-
-	// Fetch equity fundamentals
-	go func() {
-		defer wg.Done()
-		equityData, equityErr = c.FetchEquity(symbol)
-	}()
-
-	// Fetch historical data (60 days for RSI/ATR calculation)
-	go func() {
-		defer wg.Done()
-		histData, histErr = c.FetchHistorical(symbol, 60)
-	}()
-
-	wg.Wait()
-
-	// If quote fails, return error (quote is essential)
-	if quoteErr != nil {
-		return &StockData{Symbol: symbol, Error: quoteErr, FetchDuration: time.Since(start)}, nil
-	}
-
-	// Merge equity data if available
-	if equityErr == nil && equityData != nil {
-		quoteData.PERatio = equityData.PERatio
-		quoteData.EPS = equityData.EPS
-		quoteData.BookValue = equityData.BookValue
-	}
-
-	// Merge historical data if available
-	if histErr == nil && histData != nil {
-		quoteData.HistoricalPrices = histData.HistoricalPrices
-		quoteData.HistoricalHighs = histData.HistoricalHighs
-		quoteData.HistoricalLows = histData.HistoricalLows
-		quoteData.HistoricalCloses = histData.HistoricalCloses
-	}
-
-	quoteData.FetchDuration = time.Since(start)
-	return quoteData, nil
+	return FetchComplete(ctx, c, symbol, DefaultHistoryOptions())
 }
 
-// GetMarketStatus returns current market status
-func (c *YahooClient) GetMarketStatus() string {
-	// Check SPY as proxy for US market
-	q, err := quote.Get("SPY")
+// GetMarketStatus returns the session state of the exchange listing symbol
+// (SPY, i.e. the US market, when symbol is empty)
+func (c *YahooClient) GetMarketStatus(symbol string) string {
+	if symbol == "" {
+		symbol = MarketStatusSymbol
+	}
+	q, err := quote.Get(symbol)
 	if err != nil {
 		return "UNKNOWN"
 	}
 	return string(q.MarketState)
 }
 
+// Close is a no-op; finance-go keeps no per-client resources
+func (c *YahooClient) Close() {}
+
 // ConnectionResult contains connection test results
 type ConnectionResult struct {
 	Connected   bool
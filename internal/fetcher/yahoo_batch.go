@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sparkChunkSize is the most symbols Yahoo's spark endpoint accepts per request
+const sparkChunkSize = 20
+
+// yahooSparkResponse represents the Yahoo Finance spark (multi-symbol chart) response
+type yahooSparkResponse struct {
+	Spark struct {
+		Result []struct {
+			Symbol   string            `json:"symbol"`
+			Response []json.RawMessage `json:"response"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"spark"`
+}
+
+// FetchQuotes fetches quotes for many symbols with one spark request per
+// sparkChunkSize symbols. Symbols Yahoo doesn't return are missing from the map.
+func (c *DirectYahooClient) FetchQuotes(ctx context.Context, symbols []string) (map[string]*StockData, error) {
+	quotes := make(map[string]*StockData, len(symbols))
+
+	var lastErr error
+	for start := 0; start < len(symbols); start += sparkChunkSize {
+		chunk := symbols[start:min(start+sparkChunkSize, len(symbols))]
+
+		body, err := c.makeRequest(ctx, sparkURL(chunk))
+		if err != nil {
+			if ctx.Err() != nil {
+				return quotes, err
+			}
+			// Keep going; missing symbols fall back to single requests
+			lastErr = err
+			continue
+		}
+
+		fetched := time.Now()
+		for symbol, raw := range parseSpark(body) {
+			data, err := parseChartQuote(raw, symbol)
+			if err != nil || data.Price <= 0 {
+				continue
+			}
+			data.FetchedAt = fetched
+			quotes[strings.ToUpper(symbol)] = data
+
+			if c.recordDir != "" {
+				// Saved like a single quote response, so replay serves it
+				recordChart(c.recordDir, symbol, quoteRangeKey, raw)
+			}
+		}
+	}
+
+	if len(quotes) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return quotes, nil
+}
+
+// sparkURL builds a spark request for a chunk of symbols
+func sparkURL(symbols []string) string {
+	return fmt.Sprintf("https://query1.finance.yahoo.com/v7/finance/spark?symbols=%s&range=1d&interval=1d",
+		url.QueryEscape(strings.Join(symbols, ",")))
+}
+
+// parseSpark splits a spark response into one chart response body per symbol
+func parseSpark(body []byte) map[string][]byte {
+	var response yahooSparkResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	charts := make(map[string][]byte, len(response.Spark.Result))
+	for _, r := range response.Spark.Result {
+		if len(r.Response) == 0 {
+			continue
+		}
+		// Wrap the result so it decodes like a chart response
+		chart := fmt.Sprintf(`{"chart":{"result":[%s],"error":null}}`, r.Response[0])
+		charts[r.Symbol] = []byte(chart)
+	}
+	return charts
+}
@@ -0,0 +1,72 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sparkFixture = `{"spark":{"result":[
+{"symbol":"AAPL","response":[{"meta":{"symbol":"AAPL","regularMarketPrice":179.01,"chartPreviousClose":176.34,"fiftyTwoWeekHigh":203.92,"fiftyTwoWeekLow":151.44,"exchangeName":"NMS","regularMarketTime":1709154000},"timestamp":[1709130600],"indicators":{"quote":[{"close":[179.01]}]}}]},
+{"symbol":"MSFT","response":[{"meta":{"symbol":"MSFT","regularMarketPrice":371.09,"chartPreviousClose":371.25,"regularMarketTime":1709154000},"timestamp":[1709130600],"indicators":{"quote":[{"close":[371.09]}]}}]},
+{"symbol":"NOPE","response":[]}
+],"error":null}}`
+
+func TestParseSpark(t *testing.T) {
+	charts := parseSpark([]byte(sparkFixture))
+	if len(charts) != 2 {
+		t.Fatalf("Expected 2 charts, got %d", len(charts))
+	}
+
+	data, err := parseChartQuote(charts["AAPL"], "AAPL")
+	if err != nil {
+		t.Fatalf("parseChartQuote failed: %v", err)
+	}
+	if data.Price != 179.01 || data.PreviousClose != 176.34 || data.FiftyTwoWeekHigh != 203.92 {
+		t.Errorf("Unexpected quote %+v", data)
+	}
+	if data.Change <= 0 || data.MarketTime.IsZero() {
+		t.Errorf("Expected change and market time, got %+v", data)
+	}
+}
+
+func TestSparkURL_Chunk(t *testing.T) {
+	url := sparkURL([]string{"AAPL", "BRK-B"})
+	if !strings.Contains(url, "symbols=AAPL%2CBRK-B") || !strings.Contains(url, "range=1d") {
+		t.Errorf("Unexpected spark URL %s", url)
+	}
+}
+
+func TestChartPreviousClose(t *testing.T) {
+	// Session of 2024-02-28 (New York), bars stamped at the open
+	open := time.Date(2024, 2, 28, 14, 30, 0, 0, time.UTC)
+	bars := []Bar{
+		{Time: open.AddDate(0, 0, -1), Close: 180},
+		{Time: open, Close: 182},
+	}
+	meta := yahooChartMeta{
+		DataGranularity:   "1d",
+		GmtOffset:         -18000,
+		RegularMarketTime: open.Add(6*time.Hour + 30*time.Minute).Unix(), // 16:00 local
+	}
+
+	// The latest bar is today's session: previous close is the bar before it
+	if got := chartPreviousClose(meta, bars); got != 180 {
+		t.Errorf("Expected 180, got %v", got)
+	}
+
+	// No bar for today's session yet: previous close is the latest bar
+	meta.RegularMarketTime = open.AddDate(0, 0, 1).Unix()
+	if got := chartPreviousClose(meta, bars); got != 182 {
+		t.Errorf("Expected 182, got %v", got)
+	}
+
+	// Explicit previousClose wins; intraday charts without one are unknown
+	if got := chartPreviousClose(yahooChartMeta{PreviousClose: 175}, bars); got != 175 {
+		t.Errorf("Expected 175, got %v", got)
+	}
+	meta.DataGranularity = "1h"
+	if got := chartPreviousClose(meta, bars); got != 0 {
+		t.Errorf("Expected unknown previous close for 1h bars, got %v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"sync"
 	"time"
 )
@@ -15,9 +16,14 @@ import (
 // DirectYahooClient fetches data directly from Yahoo Finance API
 // with proper headers to avoid rate limiting
 type DirectYahooClient struct {
-	httpClient  *http.Client
-	rateLimiter *time.Ticker
-	mu          sync.Mutex
+	httpClient *http.Client
+	limiter    *RateLimiter
+	recordDir  string // If set, raw chart responses are saved here for replay
+
+	// quoteSummary session (see yahoo_fundamentals.go)
+	crumbMu       sync.Mutex
+	crumb         string
+	crumbFailedAt time.Time
 }
 
 // NewDirectYahooClient creates a new direct Yahoo client
@@ -59,12 +65,16 @@ func NewDirectYahooClientWithDNS(dnsServer string) *DirectYahooClient {
 		}
 	}
 
+	// Cookie jar keeps the Yahoo session cookie needed for quoteSummary
+	jar, _ := cookiejar.New(nil)
+
 	return &DirectYahooClient{
 		httpClient: &http.Client{
 			Transport: transport,
+			Jar:       jar,
 			Timeout:   15 * time.Second,
 		},
-		rateLimiter: time.NewTicker(250 * time.Millisecond), // 4 requests per second max
+		limiter: NewRateLimiter(defaultRequestRate, 2),
 	}
 }
 
@@ -97,44 +107,135 @@ type yahooQuoteResponse struct {
 // yahooChartResponse represents the Yahoo Finance chart API response
 type yahooChartResponse struct {
 	Chart struct {
-		Result []struct {
-			Timestamp  []int64 `json:"timestamp"`
-			Indicators struct {
-				Quote []struct {
-					Open   []float64 `json:"open"`
-					High   []float64 `json:"high"`
-					Low    []float64 `json:"low"`
-					Close  []float64 `json:"close"`
-					Volume []int64   `json:"volume"`
-				} `json:"quote"`
-			} `json:"indicators"`
-		} `json:"result"`
-		Error *struct {
+		Result []yahooChartResult `json:"result"`
+		Error  *struct {
 			Code        string `json:"code"`
 			Description string `json:"description"`
 		} `json:"error"`
 	} `json:"chart"`
 }
 
-// makeRequest makes an HTTP request with proper headers
+// yahooChartResult is one symbol's chart (also used by the spark endpoint)
+type yahooChartResult struct {
+	Meta       yahooChartMeta `json:"meta"`
+	Timestamp  []int64        `json:"timestamp"`
+	Indicators struct {
+		// Yahoo sends null for halted or missing sessions
+		Quote []struct {
+			Open   []*float64 `json:"open"`
+			High   []*float64 `json:"high"`
+			Low    []*float64 `json:"low"`
+			Close  []*float64 `json:"close"`
+			Volume []*int64   `json:"volume"`
+		} `json:"quote"`
+	} `json:"indicators"`
+}
+
+// yahooChartMeta is the quote information sent with every chart
+type yahooChartMeta struct {
+	Symbol             string  `json:"symbol"`
+	ShortName          string  `json:"shortName"`
+	LongName           string  `json:"longName"`
+	RegularMarketPrice float64 `json:"regularMarketPrice"`
+	PreviousClose      float64 `json:"previousClose"`
+	ChartPreviousClose float64 `json:"chartPreviousClose"`
+	FiftyTwoWeekHigh   float64 `json:"fiftyTwoWeekHigh"`
+	FiftyTwoWeekLow    float64 `json:"fiftyTwoWeekLow"`
+	MarketState        string  `json:"marketState"`
+	ExchangeName       string  `json:"exchangeName"`
+	RegularMarketTime  int64   `json:"regularMarketTime"`
+	GmtOffset          int64   `json:"gmtoffset"`
+	DataGranularity    string  `json:"dataGranularity"`
+	Currency           string  `json:"currency"`
+	ExchangeTimezone   string  `json:"exchangeTimezoneName"`
+
+	CurrentTradingPeriod struct {
+		Pre     yahooTradingPeriod `json:"pre"`
+		Regular yahooTradingPeriod `json:"regular"`
+		Post    yahooTradingPeriod `json:"post"`
+	} `json:"currentTradingPeriod"`
+}
+
+// yahooTradingPeriod is one session of the exchange's current trading day
+type yahooTradingPeriod struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// contains reports whether t falls inside the period
+func (p yahooTradingPeriod) contains(t int64) bool {
+	return p.Start > 0 && t >= p.Start && t < p.End
+}
+
+// sessionState returns the market state at t from the trading periods:
+// REGULAR, PRE, POST or CLOSED ("" when the chart has no periods).
+// Chart meta has no marketState field, so this is what live charts report.
+func (m yahooChartMeta) sessionState(t time.Time) string {
+	periods := m.CurrentTradingPeriod
+	if periods.Regular.Start == 0 {
+		return ""
+	}
+	now := t.Unix()
+	switch {
+	case periods.Regular.contains(now):
+		return "REGULAR"
+	case periods.Pre.contains(now):
+		return "PRE"
+	case periods.Post.contains(now):
+		return "POST"
+	default:
+		return "CLOSED"
+	}
+}
+
+// makeRequest makes a rate limited HTTP request with proper headers.
+// 429 and 5xx responses are retried with backoff within the retry budget.
 func (c *DirectYahooClient) makeRequest(ctx context.Context, url string) ([]byte, error) {
-	// Rate limiting
-	c.mu.Lock()
-	<-c.rateLimiter.C
-	c.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		body, err := c.doRequest(ctx, url)
+		if err == nil {
+			c.limiter.Success()
+			return body, nil
+		}
+
+		se, ok := err.(*statusError)
+		if !ok || !se.retryable() {
+			return nil, err
+		}
 
+		delay := backoffDelay(attempt, se.retryAfter)
+		if se.code == 429 {
+			// Pauses every request, not just this one
+			c.limiter.RateLimitedFor(delay)
+		} else {
+			c.limiter.ServerError()
+		}
+
+		if attempt >= maxRetries {
+			return nil, err
+		}
+		c.limiter.Retry()
+
+		if se.code != 429 {
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// doRequest sends a single GET request
+func (c *DirectYahooClient) doRequest(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set headers to mimic browser request
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "application/json,text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Cache-Control", "no-cache")
+	setBrowserHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -142,14 +243,46 @@ func (c *DirectYahooClient) makeRequest(ctx context.Context, url string) ([]byte
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("rate limited (429)")
+	if resp.StatusCode != 200 {
+		return nil, &statusError{
+			code:       resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header, time.Now()),
+		}
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	return readBody(resp)
+}
+
+// statusError is returned by makeRequest for non-200 responses
+type statusError struct {
+	code       int
+	retryAfter time.Duration // From the Retry-After header, if any
+}
+
+// retryable reports whether the request may succeed if retried
+func (e *statusError) retryable() bool {
+	return e.code == 429 || e.code >= 500
+}
+
+func (e *statusError) Error() string {
+	if e.code == 429 {
+		return "rate limited (429)"
 	}
+	return fmt.Sprintf("HTTP %d", e.code)
+}
 
+// setBrowserHeaders sets headers to mimic a browser request
+func setBrowserHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	req.Header.Set("Accept", "application/json,text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Cache-Control", "no-cache")
+}
+
+// readBody reads a response body, handling gzip encoding
+func readBody(resp *http.Response) ([]byte, error) {
 	// Handle gzip encoding
 	var reader io.Reader = resp.Body
 	if resp.Header.Get("Content-Encoding") == "gzip" {
@@ -175,87 +308,63 @@ func (c *DirectYahooClient) FetchQuote(ctx context.Context, symbol string) (*Sto
 	// Use chart API with range=1d to get current price data
 	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=1d&interval=1m&includePrePost=false", symbol)
 
-	body, err := c.makeRequest(ctx, url)
+	body, err := c.fetchChart(ctx, url, symbol, quoteRangeKey)
 	if err != nil {
 		return nil, err
 	}
 
-	var response struct {
-		Chart struct {
-			Result []struct {
-				Meta struct {
-					Symbol             string  `json:"symbol"`
-					ShortName          string  `json:"shortName"`
-					LongName           string  `json:"longName"`
-					RegularMarketPrice float64 `json:"regularMarketPrice"`
-					PreviousClose      float64 `json:"previousClose"`
-					FiftyTwoWeekHigh   float64 `json:"fiftyTwoWeekHigh"`
-					FiftyTwoWeekLow    float64 `json:"fiftyTwoWeekLow"`
-					MarketState        string  `json:"marketState"`
-					ExchangeName       string  `json:"exchangeName"`
-					RegularMarketTime  int64   `json:"regularMarketTime"`
-				} `json:"meta"`
-				Indicators struct {
-					Quote []struct {
-						Close  []float64 `json:"close"`
-						Volume []int64   `json:"volume"`
-					} `json:"quote"`
-				} `json:"indicators"`
-			} `json:"result"`
-			Error *struct {
-				Code        string `json:"code"`
-				Description string `json:"description"`
-			} `json:"error"`
-		} `json:"chart"`
-	}
-
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("JSON parse error: %v", err)
+	data, err := parseChartQuote(body, symbol)
+	if err != nil {
+		return nil, err
 	}
+	data.FetchedAt = time.Now()
+	return data, nil
+}
 
-	if response.Chart.Error != nil {
-		return nil, fmt.Errorf("%s: %s", response.Chart.Error.Code, response.Chart.Error.Description)
+// FetchHistorical fetches historical data for a symbol
+func (c *DirectYahooClient) FetchHistorical(ctx context.Context, symbol string, days int, interval Interval) (*StockData, error) {
+	// Intraday history is limited, Yahoo rejects longer ranges
+	if max := interval.MaxLookbackDays(); max > 0 && days > max {
+		days = max
 	}
 
-	if len(response.Chart.Result) == 0 {
-		return nil, fmt.Errorf("no data for symbol %s", symbol)
-	}
+	// Calculate time range
+	end := time.Now().Unix()
+	start := time.Now().AddDate(0, 0, -days).Unix()
 
-	meta := response.Chart.Result[0].Meta
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=%s", symbol, start, end, interval)
 
-	// Calculate change from previous close
-	change := meta.RegularMarketPrice - meta.PreviousClose
-	changePercent := 0.0
-	if meta.PreviousClose > 0 {
-		changePercent = (change / meta.PreviousClose) * 100
+	body, err := c.fetchChart(ctx, url, symbol, historicalRangeKey(days, interval))
+	if err != nil {
+		return nil, err
 	}
 
-	return &StockData{
-		Symbol:           meta.Symbol,
-		ShortName:        meta.ShortName,
-		Price:            meta.RegularMarketPrice,
-		Change:           change,
-		ChangePercent:    changePercent,
-		FiftyTwoWeekHigh: meta.FiftyTwoWeekHigh,
-		FiftyTwoWeekLow:  meta.FiftyTwoWeekLow,
-		MarketState:      meta.MarketState,
-		Exchange:         meta.ExchangeName,
-	}, nil
+	data, err := parseChartHistorical(body, symbol)
+	if err != nil {
+		return nil, err
+	}
+	// The chart meta carries a live quote (see QuoteFromHistory)
+	data.FetchedAt = time.Now()
+	return data, nil
 }
 
-// FetchHistorical fetches historical data for a symbol
-func (c *DirectYahooClient) FetchHistorical(ctx context.Context, symbol string, days int) (*StockData, error) {
-	// Calculate time range
-	end := time.Now().Unix()
-	start := time.Now().AddDate(0, 0, -days).Unix()
-
-	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?period1=%d&period2=%d&interval=1d", symbol, start, end)
-
+// fetchChart requests a chart URL and records the raw response if recording is enabled
+func (c *DirectYahooClient) fetchChart(ctx context.Context, url, symbol, rangeKey string) ([]byte, error) {
 	body, err := c.makeRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
+	if c.recordDir != "" {
+		// Recording is best effort; a failed write must not fail the fetch
+		recordChart(c.recordDir, symbol, rangeKey, body)
+	}
+
+	return body, nil
+}
+
+// decodeChart parses a chart response body and returns its first result
+func decodeChart(body []byte, symbol string) (*yahooChartResult, error) {
 	var response yahooChartResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("JSON parse error: %v", err)
@@ -269,78 +378,174 @@ func (c *DirectYahooClient) FetchHistorical(ctx context.Context, symbol string,
 		return nil, fmt.Errorf("no chart data for %s", symbol)
 	}
 
-	result := response.Chart.Result[0]
-	if len(result.Indicators.Quote) == 0 {
-		return nil, fmt.Errorf("no quote data in chart for %s", symbol)
+	return &response.Chart.Result[0], nil
+}
+
+// parseChartQuote extracts current quote data from a chart response body
+func parseChartQuote(body []byte, symbol string) (*StockData, error) {
+	result, err := decodeChart(body, symbol)
+	if err != nil {
+		return nil, err
 	}
 
-	quote := result.Indicators.Quote[0]
+	// For a one-day range the chart's previous close is yesterday's close
+	previousClose := result.Meta.PreviousClose
+	if previousClose == 0 {
+		previousClose = result.Meta.ChartPreviousClose
+	}
 
-	// Filter out nil values
-	var closes, highs, lows []float64
-	for i := range quote.Close {
-		if i < len(quote.Close) && i < len(quote.High) && i < len(quote.Low) {
-			closes = append(closes, quote.Close[i])
-			highs = append(highs, quote.High[i])
-			lows = append(lows, quote.Low[i])
-		}
+	data := &StockData{}
+	setQuoteFromMeta(data, result.Meta, previousClose)
+	scaleMinorUnits(data, result.Meta.Currency)
+	return data, nil
+}
+
+// setQuoteFromMeta fills the quote fields of data from chart meta.
+// The change is left at 0 if previousClose is unknown.
+func setQuoteFromMeta(data *StockData, meta yahooChartMeta, previousClose float64) {
+	data.Symbol = meta.Symbol
+	data.ShortName = meta.ShortName
+	data.Price = meta.RegularMarketPrice
+	data.FiftyTwoWeekHigh = meta.FiftyTwoWeekHigh
+	data.FiftyTwoWeekLow = meta.FiftyTwoWeekLow
+	data.MarketState = meta.MarketState
+	if data.MarketState == "" {
+		data.MarketState = meta.sessionState(time.Now())
+	}
+	data.Exchange = meta.ExchangeName
+	data.ExchangeTimezone = meta.ExchangeTimezone
+	if meta.RegularMarketTime > 0 {
+		data.MarketTime = time.Unix(meta.RegularMarketTime, 0)
 	}
 
-	return &StockData{
-		Symbol:           symbol,
-		HistoricalCloses: closes,
-		HistoricalHighs:  highs,
-		HistoricalLows:   lows,
-		HistoricalPrices: closes,
-	}, nil
+	// Calculate change from previous close
+	if previousClose > 0 {
+		data.PreviousClose = previousClose
+		data.Change = meta.RegularMarketPrice - previousClose
+		data.ChangePercent = (data.Change / previousClose) * 100
+	}
 }
 
-// FetchComplete fetches all data for a symbol
-func (c *DirectYahooClient) FetchComplete(ctx context.Context, symbol string) (*StockData, error) {
-	start := time.Now()
+// scaleMinorUnits sets data.Currency to the major currency of a quote
+// currency, converting prices quoted in minor units (GBp, ZAc, ILA)
+func scaleMinorUnits(data *StockData, quoteCurrency string) {
+	currency, factor := NormalizeCurrency(quoteCurrency)
+	data.Currency = currency
+	if factor == 1 {
+		return
+	}
+
+	data.Price *= factor
+	data.PreviousClose *= factor
+	data.Change *= factor
+	data.FiftyTwoWeekHigh *= factor
+	data.FiftyTwoWeekLow *= factor
+	for i := range data.Bars {
+		b := &data.Bars[i]
+		b.Open *= factor
+		b.High *= factor
+		b.Low *= factor
+		b.Close *= factor
+	}
+	if len(data.Bars) > 0 {
+		setSeriesFromBars(data)
+	}
+}
+
+// parseChartHistorical extracts the price history from a chart response body.
+// The quote fields are filled from the chart meta as well.
+func parseChartHistorical(body []byte, symbol string) (*StockData, error) {
+	result, err := decodeChart(body, symbol)
+	if err != nil {
+		return nil, err
+	}
 
-	var (
-		quoteData *StockData
-		histData  *StockData
-		quoteErr  error
-		histErr   error
-		wg        sync.WaitGroup
-	)
+	if len(result.Indicators.Quote) == 0 {
+		return nil, fmt.Errorf("no quote data in chart for %s", symbol)
+	}
 
-	wg.Add(2)
+	quote := result.Indicators.Quote[0]
 
-	// Fetch quote (includes current price from chart API)
-	go func() {
-		defer wg.Done()
-		quoteData, quoteErr = c.FetchQuote(ctx, symbol)
-	}()
+	data := &StockData{Symbol: symbol}
 
-	// Fetch historical (60 days for RSI)
-	go func() {
-		defer wg.Done()
-		histData, histErr = c.FetchHistorical(ctx, symbol, 60)
-	}()
+	// Drop null bars, remembering when they were so they can be
+	// forward-filled and reported (see FillGaps, AssessQuality)
+	for i := range quote.Close {
+		var t time.Time
+		if i < len(result.Timestamp) {
+			t = time.Unix(result.Timestamp[i], 0)
+		}
 
-	wg.Wait()
+		closePrice := valueAt(quote.Close, i)
+		if closePrice == nil || *closePrice <= 0 {
+			if !t.IsZero() {
+				data.Gaps = append(data.Gaps, t)
+			}
+			continue
+		}
 
-	if quoteErr != nil {
-		return &StockData{Symbol: symbol, Error: quoteErr, FetchDuration: time.Since(start)}, nil
+		// A missing open/high/low falls back to the close
+		bar := Bar{Time: t, Open: *closePrice, High: *closePrice, Low: *closePrice, Close: *closePrice}
+		if v := valueAt(quote.Open, i); v != nil && *v > 0 {
+			bar.Open = *v
+		}
+		if v := valueAt(quote.High, i); v != nil && *v > 0 {
+			bar.High = *v
+		}
+		if v := valueAt(quote.Low, i); v != nil && *v > 0 {
+			bar.Low = *v
+		}
+		if i < len(quote.Volume) && quote.Volume[i] != nil {
+			bar.Volume = *quote.Volume[i]
+		}
+		data.Bars = append(data.Bars, bar)
+	}
+
+	setSeriesFromBars(data)
+
+	if result.Meta.RegularMarketPrice > 0 {
+		setQuoteFromMeta(data, result.Meta, chartPreviousClose(result.Meta, data.Bars))
+		data.Symbol = symbol
+	}
+	scaleMinorUnits(data, result.Meta.Currency)
+	return data, nil
+}
+
+// chartPreviousClose returns the close before the latest session. Ranged chart
+// requests often omit previousClose; daily bars still contain it.
+func chartPreviousClose(meta yahooChartMeta, bars []Bar) float64 {
+	if meta.PreviousClose > 0 {
+		return meta.PreviousClose
+	}
+	if meta.DataGranularity != string(Interval1d) || len(bars) == 0 || meta.RegularMarketTime == 0 {
+		return 0
 	}
 
-	// Merge historical data
-	if histErr == nil && histData != nil {
-		quoteData.HistoricalPrices = histData.HistoricalPrices
-		quoteData.HistoricalCloses = histData.HistoricalCloses
-		quoteData.HistoricalHighs = histData.HistoricalHighs
-		quoteData.HistoricalLows = histData.HistoricalLows
+	// Compare calendar days in the exchange's local time
+	day := func(t time.Time) string {
+		return t.UTC().Add(time.Duration(meta.GmtOffset) * time.Second).Format("2006-01-02")
+	}
+	n := len(bars)
+	if day(bars[n-1].Time) != day(time.Unix(meta.RegularMarketTime, 0)) {
+		return bars[n-1].Close // the latest session has no bar yet
+	}
+	if n < 2 {
+		return 0
 	}
+	return bars[n-2].Close
+}
 
-	// Estimate P/E and Book Value from price (rough approximation)
-	// This is a fallback since Yahoo quoteSummary requires auth
-	// Real apps would use a different data source for fundamentals
+// valueAt returns values[i], or nil if it is null or out of range
+func valueAt(values []*float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
 
-	quoteData.FetchDuration = time.Since(start)
-	return quoteData, nil
+// FetchComplete fetches all data for a symbol
+func (c *DirectYahooClient) FetchComplete(ctx context.Context, symbol string) (*StockData, error) {
+	return FetchComplete(ctx, c, symbol, DefaultHistoryOptions())
 }
 
 // CheckConnection tests the connection to Yahoo Finance
@@ -390,7 +595,7 @@ func (c *DirectYahooClient) CheckConnection() ConnectionResult {
 
 	// Test 3: Chart API (historical data)
 	result.Details = append(result.Details, "Testing Chart API (AAPL)...")
-	histData, err := c.FetchHistorical(ctx, "AAPL", 7)
+	histData, err := c.FetchHistorical(ctx, "AAPL", 7, Interval1d)
 	if err != nil {
 		result.Details = append(result.Details, fmt.Sprintf("FAIL Chart: %v", err))
 	} else if histData == nil || len(histData.HistoricalCloses) == 0 {
@@ -400,7 +605,16 @@ func (c *DirectYahooClient) CheckConnection() ConnectionResult {
 		result.Details = append(result.Details, fmt.Sprintf("OK Chart: %d bars", len(histData.HistoricalCloses)))
 	}
 
-	result.EquityWorks = result.QuoteWorks // Quote now includes meta data
+	// Test 4: quoteSummary (fundamentals, optional)
+	result.Details = append(result.Details, "Testing Fundamentals API (AAPL)...")
+	fundData, err := c.FetchFundamentals(ctx, "AAPL")
+	if err != nil {
+		result.Details = append(result.Details, fmt.Sprintf("WARN Fundamentals: %v (valuation will be unknown)", err))
+	} else {
+		result.EquityWorks = true
+		result.Details = append(result.Details, fmt.Sprintf("OK Fundamentals: AAPL P/E = %.2f", fundData.PERatio))
+	}
+
 	result.Latency = time.Since(start)
 	result.Connected = result.QuoteWorks && result.ChartWorks
 
@@ -415,21 +629,31 @@ func (c *DirectYahooClient) CheckConnection() ConnectionResult {
 	return result
 }
 
-// GetMarketStatus returns current market status
-func (c *DirectYahooClient) GetMarketStatus() string {
+// GetMarketStatus returns the session state of the exchange listing symbol
+// (SPY, i.e. the US market, when symbol is empty)
+func (c *DirectYahooClient) GetMarketStatus(symbol string) string {
+	if symbol == "" {
+		symbol = MarketStatusSymbol
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	data, err := c.FetchQuote(ctx, "SPY")
+	data, err := c.FetchQuote(ctx, symbol)
 	if err != nil {
 		return "UNKNOWN"
 	}
 	return data.MarketState
 }
 
-// Close cleans up resources
-func (c *DirectYahooClient) Close() {
-	if c.rateLimiter != nil {
-		c.rateLimiter.Stop()
-	}
+// SetRecordDir enables saving raw chart responses to dir (empty disables recording)
+func (c *DirectYahooClient) SetRecordDir(dir string) {
+	c.recordDir = dir
 }
+
+// LimiterStats returns the state of the client's rate limiter
+func (c *DirectYahooClient) LimiterStats() LimiterStats {
+	return c.limiter.Stats()
+}
+
+// Close cleans up resources
+func (c *DirectYahooClient) Close() {}
@@ -1,15 +1,145 @@
 package fetcher
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
-func TestDirectYahooClient_FetchQuote(t *testing.T) {
+// requireNetwork skips live Yahoo tests in short mode and offline
+func requireNetwork(t *testing.T) {
+	t.Helper()
 	if testing.Short() {
 		t.Skip("Skipping network test in short mode")
 	}
+	if _, err := net.LookupHost("query1.finance.yahoo.com"); err != nil {
+		t.Skipf("Skipping network test: %v", err)
+	}
+}
+
+// recordedTransport answers Yahoo requests with the replay fixtures: the
+// 1-day chart with SYMBOL.quote.json, other charts with SYMBOL.60d.json and
+// quoteSummary with SYMBOL.fundamentals.json
+type recordedTransport struct{}
+
+func (recordedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	file := ""
+	switch p := req.URL.Path; {
+	case req.URL.Host == "fc.yahoo.com":
+		return respond(http.StatusNotFound, nil), nil
+	case strings.HasSuffix(p, "/getcrumb"):
+		return respond(http.StatusOK, []byte("recorded")), nil
+	case strings.Contains(p, "/chart/") && req.URL.Query().Get("range") == "1d":
+		file = recordingFileName(path.Base(p), quoteRangeKey)
+	case strings.Contains(p, "/chart/"):
+		file = recordingFileName(path.Base(p), historicalRangeKey(60, Interval1d))
+	case strings.Contains(p, "/quoteSummary/"):
+		file = recordingFileName(path.Base(p), fundamentalsRangeKey)
+	}
+	body, err := os.ReadFile(filepath.Join(replayFixtures, file))
+	if file == "" || err != nil {
+		return respond(http.StatusNotFound, nil), nil
+	}
+	return respond(http.StatusOK, body), nil
+}
+
+func respond(code int, body []byte) *http.Response {
+	return &http.Response{StatusCode: code, Header: make(http.Header), Body: io.NopCloser(bytes.NewReader(body))}
+}
+
+// recordedClient returns a client served by recordedTransport
+func recordedClient() *DirectYahooClient {
+	client := NewDirectYahooClient()
+	client.httpClient = &http.Client{Transport: recordedTransport{}}
+	return client
+}
+
+func TestDirectYahooClient_Recorded(t *testing.T) {
+	client := recordedClient()
+	defer client.Close()
+	ctx := context.Background()
+
+	quote, err := client.FetchQuote(ctx, "AAPL")
+	if err != nil || quote.Symbol != "AAPL" || quote.Price <= 0 {
+		t.Fatalf("FetchQuote = %+v, %v", quote, err)
+	}
+
+	hist, err := client.FetchHistorical(ctx, "AAPL", 30, Interval1d)
+	if err != nil || len(hist.HistoricalCloses) == 0 || len(hist.Bars) != len(hist.HistoricalCloses) {
+		t.Fatalf("FetchHistorical failed: %v", err)
+	}
+
+	data, err := client.FetchComplete(ctx, "AAPL")
+	if err != nil || data.Error != nil {
+		t.Fatalf("FetchComplete failed: %v %v", err, data.Error)
+	}
+	if data.Price <= 0 || len(data.HistoricalCloses) == 0 || !data.HasFundamentals || data.Sector != "Technology" {
+		t.Errorf("Expected quote, history and fundamentals, got price=%.2f bars=%d fundamentals=%v sector=%q",
+			data.Price, len(data.HistoricalCloses), data.HasFundamentals, data.Sector)
+	}
+
+	if _, err := client.FetchQuote(ctx, "NOPE"); err == nil {
+		t.Error("Expected an error for a symbol Yahoo doesn't know")
+	}
+
+	result := client.CheckConnection()
+	if !result.Connected || !result.QuoteWorks || !result.ChartWorks {
+		t.Errorf("Expected the recorded connection check to pass: %+v", result)
+	}
+}
+
+func TestDirectYahooClient_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	client := recordedClient()
+	client.SetRecordDir(dir)
+	defer client.Close()
+
+	recorded, err := FetchComplete(context.Background(), client, "AAPL", DefaultHistoryOptions())
+	if err != nil || recorded.Error != nil {
+		t.Fatalf("Recording FetchComplete failed: %v %v", err, recorded.Error)
+	}
+
+	replayed, err := FetchComplete(context.Background(), NewReplayProvider(dir), "AAPL", DefaultHistoryOptions())
+	if err != nil || replayed.Error != nil {
+		t.Fatalf("Replaying the recording failed: %v %v", err, replayed.Error)
+	}
+	if replayed.Price != recorded.Price || len(replayed.HistoricalCloses) != len(recorded.HistoricalCloses) ||
+		replayed.EPS != recorded.EPS {
+		t.Errorf("Replay differs from the recording: price %.2f/%.2f, bars %d/%d, EPS %.2f/%.2f",
+			replayed.Price, recorded.Price, len(replayed.HistoricalCloses), len(recorded.HistoricalCloses),
+			replayed.EPS, recorded.EPS)
+	}
+}
+
+func TestWorkerPool_Recorded(t *testing.T) {
+	client := recordedClient()
+	defer client.Close()
+
+	pool := NewWorkerPoolWithProvider(3, client)
+	symbols := []string{"AAPL", "MSFT", "SPY"}
+
+	count := 0
+	for data := range pool.Start(symbols) {
+		count++
+		if data.Error != nil || data.Price <= 0 {
+			t.Errorf("%s: expected a recorded price, got %.2f, %v", data.Symbol, data.Price, data.Error)
+		}
+	}
+	if count != len(symbols) {
+		t.Errorf("Expected %d results, got %d", len(symbols), count)
+	}
+}
+
+func TestDirectYahooClient_FetchQuote(t *testing.T) {
+	requireNetwork(t)
 
 	client := NewDirectYahooClient()
 	defer client.Close()
@@ -34,9 +164,7 @@ func TestDirectYahooClient_FetchQuote(t *testing.T) {
 }
 
 func TestDirectYahooClient_FetchHistorical(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping network test in short mode")
-	}
+	requireNetwork(t)
 
 	client := NewDirectYahooClient()
 	defer client.Close()
@@ -44,7 +172,7 @@ func TestDirectYahooClient_FetchHistorical(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	data, err := client.FetchHistorical(ctx, "AAPL", 30)
+	data, err := client.FetchHistorical(ctx, "AAPL", 30, Interval1d)
 	if err != nil {
 		t.Fatalf("FetchHistorical failed: %v", err)
 	}
@@ -57,9 +185,7 @@ func TestDirectYahooClient_FetchHistorical(t *testing.T) {
 }
 
 func TestDirectYahooClient_FetchComplete(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping network test in short mode")
-	}
+	requireNetwork(t)
 
 	client := NewDirectYahooClient()
 	defer client.Close()
@@ -89,9 +215,7 @@ func TestDirectYahooClient_FetchComplete(t *testing.T) {
 }
 
 func TestDirectYahooClient_CheckConnection(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping network test in short mode")
-	}
+	requireNetwork(t)
 
 	client := NewDirectYahooClient()
 	defer client.Close()
@@ -117,9 +241,7 @@ func TestDirectYahooClient_CheckConnection(t *testing.T) {
 }
 
 func TestWorkerPool_Start(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping network test in short mode")
-	}
+	requireNetwork(t)
 
 	pool := NewWorkerPool(3)
 
@@ -0,0 +1,176 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// fundamentalsRangeKey is the recording key for quoteSummary responses
+const fundamentalsRangeKey = "fundamentals"
+
+// crumbRetryInterval is how long to wait before retrying a failed crumb request.
+// Without it every symbol in a scan would hit the crumb endpoint again.
+const crumbRetryInterval = 10 * time.Minute
+
+// yahooValue is a quoteSummary numeric field ({"raw": 1.23, "fmt": "1.23"}).
+// Missing or empty objects decode to a nil Raw, meaning "unknown".
+type yahooValue struct {
+	Raw *float64 `json:"raw"`
+}
+
+// value returns the raw value, or 0 if unknown
+func (v yahooValue) value() float64 {
+	if v.Raw == nil {
+		return 0
+	}
+	return *v.Raw
+}
+
+// yahooQuoteSummaryResponse represents the Yahoo Finance quoteSummary API response
+type yahooQuoteSummaryResponse struct {
+	QuoteSummary struct {
+		Result []struct {
+			SummaryDetail struct {
+				TrailingPE    yahooValue `json:"trailingPE"`
+				DividendYield yahooValue `json:"dividendYield"`
+				MarketCap     yahooValue `json:"marketCap"`
+			} `json:"summaryDetail"`
+			DefaultKeyStatistics struct {
+				TrailingEps yahooValue `json:"trailingEps"`
+				BookValue   yahooValue `json:"bookValue"`
+			} `json:"defaultKeyStatistics"`
+			AssetProfile struct {
+				Sector   string `json:"sector"`
+				Industry string `json:"industry"`
+			} `json:"assetProfile"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"quoteSummary"`
+}
+
+// FetchFundamentals fetches P/E, EPS, book value, dividend yield, sector and
+// industry from quoteSummary.
+// The endpoint needs a session cookie and crumb; if they can't be obtained the
+// error is returned and FetchComplete leaves the fields unknown.
+func (c *DirectYahooClient) FetchFundamentals(ctx context.Context, symbol string) (*StockData, error) {
+	crumb, err := c.getCrumb(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.makeRequest(ctx, quoteSummaryURL(symbol, crumb))
+
+	// An expired crumb is rejected with 401/403; refresh it once
+	if se, ok := err.(*statusError); ok && (se.code == 401 || se.code == 403) {
+		if crumb, err = c.getCrumb(ctx, true); err != nil {
+			return nil, err
+		}
+		body, err = c.makeRequest(ctx, quoteSummaryURL(symbol, crumb))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.recordDir != "" {
+		// Recording is best effort; a failed write must not fail the fetch
+		recordChart(c.recordDir, symbol, fundamentalsRangeKey, body)
+	}
+
+	return parseQuoteSummary(body, symbol)
+}
+
+// quoteSummaryURL builds the quoteSummary request for a symbol
+func quoteSummaryURL(symbol, crumb string) string {
+	return fmt.Sprintf("https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s?modules=summaryDetail,defaultKeyStatistics,assetProfile&crumb=%s",
+		url.PathEscape(symbol), url.QueryEscape(crumb))
+}
+
+// getCrumb returns the cached crumb, fetching a new one if needed or if refresh is set.
+// The cookie set by fc.yahoo.com is kept in the client's cookie jar.
+func (c *DirectYahooClient) getCrumb(ctx context.Context, refresh bool) (string, error) {
+	c.crumbMu.Lock()
+	defer c.crumbMu.Unlock()
+
+	if c.crumb != "" && !refresh {
+		return c.crumb, nil
+	}
+	if !c.crumbFailedAt.IsZero() && time.Since(c.crumbFailedAt) < crumbRetryInterval {
+		return "", fmt.Errorf("fundamentals unavailable (crumb request failed recently)")
+	}
+
+	crumb, err := c.requestCrumb(ctx)
+	if err != nil {
+		c.crumb = ""
+		c.crumbFailedAt = time.Now()
+		return "", fmt.Errorf("crumb: %v", err)
+	}
+
+	c.crumb = crumb
+	c.crumbFailedAt = time.Time{}
+	return crumb, nil
+}
+
+// requestCrumb obtains a session cookie and then the matching crumb
+func (c *DirectYahooClient) requestCrumb(ctx context.Context) (string, error) {
+	// fc.yahoo.com answers 404 but sets the session cookie, so the status is ignored
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://fc.yahoo.com", nil)
+	if err != nil {
+		return "", err
+	}
+	setBrowserHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	body, err := c.makeRequest(ctx, "https://query1.finance.yahoo.com/v1/test/getcrumb")
+	if err != nil {
+		return "", err
+	}
+
+	crumb := strings.TrimSpace(string(body))
+	if crumb == "" || strings.ContainsAny(crumb, "<{ ") {
+		return "", fmt.Errorf("unexpected crumb response")
+	}
+	return crumb, nil
+}
+
+// parseQuoteSummary extracts fundamentals from a quoteSummary response body.
+// Fields Yahoo doesn't report stay 0 and are treated as unknown.
+func parseQuoteSummary(body []byte, symbol string) (*StockData, error) {
+	var response yahooQuoteSummaryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("JSON parse error: %v", err)
+	}
+
+	if response.QuoteSummary.Error != nil {
+		return nil, fmt.Errorf("%s: %s", response.QuoteSummary.Error.Code, response.QuoteSummary.Error.Description)
+	}
+
+	if len(response.QuoteSummary.Result) == 0 {
+		return nil, fmt.Errorf("no fundamentals for %s", symbol)
+	}
+
+	result := response.QuoteSummary.Result[0]
+
+	return &StockData{
+		Symbol:        symbol,
+		PERatio:       result.SummaryDetail.TrailingPE.value(),
+		EPS:           result.DefaultKeyStatistics.TrailingEps.value(),
+		BookValue:     result.DefaultKeyStatistics.BookValue.value(),
+		DividendYield: result.SummaryDetail.DividendYield.value() * 100, // fraction -> percent
+		MarketCap:     int64(result.SummaryDetail.MarketCap.value()),
+		Sector:        result.AssetProfile.Sector,
+		Industry:      result.AssetProfile.Industry,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package fetcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseQuoteSummary(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join(replayFixtures, "AAPL.fundamentals.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := parseQuoteSummary(body, "AAPL")
+	if err != nil {
+		t.Fatalf("parseQuoteSummary failed: %v", err)
+	}
+
+	if data.PERatio != 28.5 || data.EPS != 6.43 || data.BookValue != 4.38 {
+		t.Errorf("Unexpected fundamentals: PE=%v EPS=%v BV=%v", data.PERatio, data.EPS, data.BookValue)
+	}
+	if data.DividendYield < 0.519 || data.DividendYield > 0.521 {
+		t.Errorf("Expected dividend yield 0.52%%, got %v", data.DividendYield)
+	}
+	if data.Sector != "Technology" || data.Industry != "Consumer Electronics" {
+		t.Errorf("Expected the asset profile's classification, got %q / %q", data.Sector, data.Industry)
+	}
+
+	// Missing fields stay 0 (unknown)
+	data, err = parseQuoteSummary([]byte(`{"quoteSummary":{"result":[{"summaryDetail":{"trailingPE":{}}}]}}`), "X")
+	if err != nil {
+		t.Fatalf("parseQuoteSummary failed: %v", err)
+	}
+	if data.PERatio != 0 || data.BookValue != 0 {
+		t.Errorf("Expected unknown fields to be 0, got %+v", data)
+	}
+
+	if _, err := parseQuoteSummary([]byte(`{"quoteSummary":{"result":[],"error":{"code":"Not Found","description":"Quote not found"}}}`), "X"); err == nil {
+		t.Error("Expected error for quoteSummary error response")
+	}
+}
+
+func TestReplayProvider_Fundamentals(t *testing.T) {
+	p := NewReplayProvider(replayFixtures)
+
+	data, _ := FetchComplete(context.Background(), p, "AAPL", DefaultHistoryOptions())
+	if !data.HasFundamentals || data.BookValue != 4.38 || data.Industry != "Consumer Electronics" {
+		t.Errorf("Expected recorded fundamentals, got HasFundamentals=%v BV=%v industry=%q", data.HasFundamentals, data.BookValue, data.Industry)
+	}
+
+	// MSFT has no fundamentals recording: the fetch succeeds with unknown valuation
+	data, _ = FetchComplete(context.Background(), p, "MSFT", DefaultHistoryOptions())
+	if data.Error != nil || data.HasFundamentals {
+		t.Errorf("Expected quote without fundamentals, got err=%v HasFundamentals=%v", data.Error, data.HasFundamentals)
+	}
+}
@@ -19,6 +19,10 @@ type ScanRecord struct {
 	TotalScanned int                      `json:"total_scanned"`
 	TotalFound   int                      `json:"total_found"`
 	Results      []*screener.ScreenResult `json:"results"`
+
+	// Scoring is the strategy and scoring model the results were scored
+	// with; nil in records saved before it was recorded
+	Scoring *screener.ScoringSnapshot `json:"scoring,omitempty"`
 }
 
 // Manager handles history CRUD operations
@@ -47,8 +51,8 @@ func NewManager() *Manager {
 	}
 }
 
-// Save saves scan results to history
-func (m *Manager) Save(results []*screener.ScreenResult, totalScanned int) (*ScanRecord, error) {
+// Save saves scan results to history along with how they were scored
+func (m *Manager) Save(results []*screener.ScreenResult, totalScanned int, scoring *screener.ScoringSnapshot) (*ScanRecord, error) {
 	now := time.Now()
 	id := now.Format("20060102_150405")
 
@@ -58,6 +62,7 @@ func (m *Manager) Save(results []*screener.ScreenResult, totalScanned int) (*Sca
 		TotalScanned: totalScanned,
 		TotalFound:   len(results),
 		Results:      results,
+		Scoring:      scoring,
 	}
 
 	filename := filepath.Join(m.historyDir, fmt.Sprintf("scan_%s.json", id))
@@ -75,7 +80,7 @@ func (m *Manager) Save(results []*screener.ScreenResult, totalScanned int) (*Sca
 }
 
 // Update updates an existing scan record (for reload functionality)
-func (m *Manager) Update(id string, results []*screener.ScreenResult, totalScanned int) (*ScanRecord, error) {
+func (m *Manager) Update(id string, results []*screener.ScreenResult, totalScanned int, scoring *screener.ScoringSnapshot) (*ScanRecord, error) {
 	now := time.Now()
 
 	record := &ScanRecord{
@@ -84,6 +89,7 @@ func (m *Manager) Update(id string, results []*screener.ScreenResult, totalScann
 		TotalScanned: totalScanned,
 		TotalFound:   len(results),
 		Results:      results,
+		Scoring:      scoring,
 	}
 
 	filename := filepath.Join(m.historyDir, fmt.Sprintf("scan_%s.json", id))
@@ -0,0 +1,394 @@
+package paper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
+)
+
+// FileVersion is the version of the journal file format written by Save.
+// Files without a version are read as version 1.
+const FileVersion = 1
+
+// Defaults for a new paper account
+const (
+	DefaultCapital      = 100000.0
+	DefaultMinScore     = 75.0
+	DefaultMaxPositions = 10
+)
+
+// Where a trade's entry signal came from
+const (
+	SourceAlert = "alert" // A price or RSI alert triggered
+	SourceScore = "score" // The confluence score reached MinScore
+)
+
+// Why a trade was closed
+const (
+	ExitStop   = "stop"   // The price fell to the stop loss
+	ExitTarget = "target" // The price rose to the take profit
+)
+
+// Kinds of journal events
+const (
+	EventStart = "start"
+	EventStop  = "stop"
+	EventOpen  = "open"
+	EventClose = "close"
+	EventSkip  = "skip" // A signal that couldn't be traded
+)
+
+// Settings are the rules the paper account trades by
+type Settings struct {
+	Capital            float64 `json:"capital"`
+	MinScore           float64 `json:"min_score"`            // Confluence score that opens a trade
+	MaxPositions       int     `json:"max_positions"`        // Open trades at most
+	RiskPercent        float64 `json:"risk_percent"`         // Equity risked per trade
+	MaxPositionPercent float64 `json:"max_position_percent"` // Largest trade, in percent of equity
+	Alerts             bool    `json:"alerts"`               // Trade triggered alerts
+}
+
+// DefaultSettings returns the default settings, taking the risk limits (and
+// the capital, when set) from the account settings
+func DefaultSettings(account config.AccountSettings) Settings {
+	a := portfolio.NewAccount(account)
+	s := Settings{
+		Capital:            DefaultCapital,
+		MinScore:           DefaultMinScore,
+		MaxPositions:       DefaultMaxPositions,
+		RiskPercent:        a.RiskPercent,
+		MaxPositionPercent: a.MaxPositionPercent,
+		Alerts:             true,
+	}
+	if a.Equity > 0 {
+		s.Capital = a.Equity
+	}
+	return s
+}
+
+// Validate checks the settings
+func (s Settings) Validate() error {
+	switch {
+	case s.Capital <= 0:
+		return fmt.Errorf("capital must be positive")
+	case s.MinScore < 0 || s.MinScore > 100:
+		return fmt.Errorf("min score must be between 0 and 100, got %g", s.MinScore)
+	case s.MaxPositions <= 0:
+		return fmt.Errorf("max positions must be positive")
+	}
+	return s.account(s.Capital).Validate()
+}
+
+// account returns the account trades are sized for at equity
+func (s Settings) account(equity float64) portfolio.Account {
+	return portfolio.Account{Equity: equity, RiskPercent: s.RiskPercent, MaxPositionPercent: s.MaxPositionPercent}
+}
+
+// Trade is a simulated long position, open until ExitTime is set
+type Trade struct {
+	ID         string    `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Source     string    `json:"source"`
+	Reason     string    `json:"reason"`
+	Score      float64   `json:"score"`
+	Shares     int       `json:"shares"`
+	EntryPrice float64   `json:"entry_price"`
+	EntryTime  time.Time `json:"entry_time"`
+	StopLoss   float64   `json:"stop_loss"`
+	TakeProfit float64   `json:"take_profit,omitempty"`
+	Currency   string    `json:"currency,omitempty"`
+	LastPrice  float64   `json:"last_price"`
+	LastTime   time.Time `json:"last_time"`
+	ExitPrice  float64   `json:"exit_price,omitempty"`
+	ExitTime   time.Time `json:"exit_time,omitempty"`
+	ExitReason string    `json:"exit_reason,omitempty"`
+}
+
+// Open reports whether the trade is still open
+func (t Trade) Open() bool {
+	return t.ExitTime.IsZero()
+}
+
+// Cost returns what the shares cost
+func (t Trade) Cost() float64 {
+	return float64(t.Shares) * t.EntryPrice
+}
+
+// PnL returns the realised P&L of a closed trade, or the unrealised P&L at
+// the last price of an open one
+func (t Trade) PnL() float64 {
+	price := t.LastPrice
+	if !t.Open() {
+		price = t.ExitPrice
+	}
+	return float64(t.Shares) * (price - t.EntryPrice)
+}
+
+// ReturnPct returns PnL in percent of the cost
+func (t Trade) ReturnPct() float64 {
+	if t.Cost() == 0 {
+		return 0
+	}
+	return t.PnL() / t.Cost() * 100
+}
+
+// Risk returns what the trade loses from its last price if the stop is hit
+func (t Trade) Risk() float64 {
+	if !t.Open() || t.LastPrice <= t.StopLoss {
+		return 0
+	}
+	return float64(t.Shares) * (t.LastPrice - t.StopLoss)
+}
+
+// Event is an entry of the journal's log
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Symbol  string    `json:"symbol,omitempty"`
+	TradeID string    `json:"trade_id,omitempty"`
+	Price   float64   `json:"price,omitempty"`
+	Message string    `json:"message"`
+}
+
+// EquityPoint is the account equity after a reload
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Journal is the JSON structure of paper.json: the settings, every trade,
+// the event log and the equity after each reload
+type Journal struct {
+	Version  int           `json:"version"`
+	Active   bool          `json:"active"`
+	Started  time.Time     `json:"started,omitempty"`
+	Settings Settings      `json:"settings"`
+	Trades   []Trade       `json:"trades"`
+	Events   []Event       `json:"events"`
+	Equity   []EquityPoint `json:"equity"`
+	// HighScores are the symbols at or above MinScore after the last reload;
+	// only symbols not among them are new entries
+	HighScores []string `json:"high_scores,omitempty"`
+}
+
+// Manager handles the paper trading journal in paper.json
+type Manager struct {
+	filePath string
+	journal  Journal
+	loadErr  error // Why the file couldn't be read; writing it is refused
+	mu       sync.RWMutex
+}
+
+// NewManager creates a paper trading manager reading customPath, or
+// config/paper.json when it is empty
+func NewManager(customPath string) *Manager {
+	path := customPath
+	if path == "" {
+		path = config.Path("paper.json")
+	}
+
+	m := &Manager{filePath: path}
+	m.Load()
+	return m
+}
+
+// LoadError returns why the journal couldn't be read, or nil. Nothing is
+// saved while it is set, so an unreadable file isn't replaced.
+func (m *Manager) LoadError() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loadErr
+}
+
+// Path returns the file the journal is stored in
+func (m *Manager) Path() string {
+	return m.filePath
+}
+
+// Load reads the journal from disk
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loadErr = m.loadUnsafe()
+	return m.loadErr
+}
+
+func (m *Manager) loadUnsafe() error {
+	data, err := os.ReadFile(m.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			m.journal = Journal{}
+			return nil // Not started yet
+		}
+		return err
+	}
+
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("%s: %v", m.filePath, err)
+	}
+	if j.Version > FileVersion {
+		return fmt.Errorf("%s is version %d; this stockmap reads up to version %d", m.filePath, j.Version, FileVersion)
+	}
+
+	m.journal = j
+	return nil
+}
+
+// Save writes the journal to disk
+func (m *Manager) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveUnsafe()
+}
+
+func (m *Manager) saveUnsafe() error {
+	if m.loadErr != nil {
+		return m.loadErr
+	}
+	dir := filepath.Dir(m.filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	j := m.journal
+	j.Version = FileVersion
+	if j.Trades == nil {
+		j.Trades = []Trade{}
+	}
+	if j.Events == nil {
+		j.Events = []Event{}
+	}
+	if j.Equity == nil {
+		j.Equity = []EquityPoint{}
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(m.filePath, data, 0644)
+}
+
+// Journal returns a copy of the journal
+func (m *Manager) Journal() Journal {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	j := m.journal
+	j.Trades = append([]Trade(nil), j.Trades...)
+	j.Events = append([]Event(nil), j.Events...)
+	j.Equity = append([]EquityPoint(nil), j.Equity...)
+	j.HighScores = append([]string(nil), j.HighScores...)
+	return j
+}
+
+// Active reports whether paper trading is on
+func (m *Manager) Active() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.journal.Active
+}
+
+// Started reports whether the journal has settings, i.e. paper trading was
+// started at least once since the last reset
+func (m *Manager) Started() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.journal.Started.IsZero()
+}
+
+// OpenSymbols returns the symbols of the open trades, which every scan must
+// include for their stop loss and take profit to be checked
+func (m *Manager) OpenSymbols() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, t := range m.journal.Trades {
+		if t.Open() && !seen[t.Symbol] {
+			seen[t.Symbol] = true
+			symbols = append(symbols, t.Symbol)
+		}
+	}
+	return symbols
+}
+
+// Start turns paper trading on. A journal started before keeps its
+// settings and trades unless settings is given; a new one needs settings.
+func (m *Manager) Start(settings *Settings, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.journal.Active {
+		return fmt.Errorf("paper trading is already on")
+	}
+	prev := m.journal
+	msg := "Resumed paper trading"
+	if settings != nil {
+		if err := settings.Validate(); err != nil {
+			return err
+		}
+		if len(m.journal.Trades) > 0 && *settings != m.journal.Settings {
+			return fmt.Errorf("the journal already has trades under other settings; reset it first")
+		}
+		m.journal.Settings = *settings
+	} else if m.journal.Started.IsZero() {
+		return fmt.Errorf("no paper trading settings")
+	}
+	if m.journal.Started.IsZero() {
+		m.journal.Started = now
+		msg = "Started paper trading"
+	}
+	s := m.journal.Settings
+	m.journal.Active = true
+	m.journal.HighScores = nil
+	m.journal.Events = append(m.journal.Events, Event{Time: now, Kind: EventStart,
+		Message: fmt.Sprintf("%s: capital %.2f, min score %g, up to %d positions, risk %g%%, max position %g%%",
+			msg, s.Capital, s.MinScore, s.MaxPositions, s.RiskPercent, s.MaxPositionPercent)})
+	if err := m.saveUnsafe(); err != nil {
+		m.journal = prev
+		return err
+	}
+	return nil
+}
+
+// Stop turns paper trading off; open trades stay open
+func (m *Manager) Stop(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.journal.Active {
+		return fmt.Errorf("paper trading is not on")
+	}
+	prev := m.journal
+	m.journal.Active = false
+	m.journal.Events = append(m.journal.Events, Event{Time: now, Kind: EventStop, Message: "Stopped paper trading"})
+	if err := m.saveUnsafe(); err != nil {
+		m.journal = prev
+		return err
+	}
+	return nil
+}
+
+// Reset clears the journal
+func (m *Manager) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.journal
+	m.journal = Journal{}
+	if err := m.saveUnsafe(); err != nil {
+		m.journal = prev
+		return err
+	}
+	return nil
+}
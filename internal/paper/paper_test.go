@@ -0,0 +1,224 @@
+package paper
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/alerts"
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func result(symbol string, price, score, stop, target float64) *screener.ScreenResult {
+	return &screener.ScreenResult{Symbol: symbol, Price: price, ConfluenceScore: score, StopLoss: stop, TakeProfit: target}
+}
+
+func testSettings() Settings {
+	return Settings{Capital: 100000, MinScore: 75, MaxPositions: 2, RiskPercent: 1, MaxPositionPercent: 20, Alerts: true}
+}
+
+func TestManager_Process(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paper.json")
+	m := NewManager(path)
+
+	// Nothing is traded before paper trading starts
+	if events, err := m.Process([]*screener.ScreenResult{result("AAPL", 100, 90, 96, 110)}, nil, day(1)); err != nil || events != nil {
+		t.Fatalf("inactive Process = %v, %v", events, err)
+	}
+	s := testSettings()
+	if err := m.Start(&s, day(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// AAPL: 1% of 100000 over 4 risk per share is 250 shares, capped to 200
+	// by the 20% max position. MSFT is below the min score; KO has an alert.
+	ko := alerts.TriggeredAlert{Alert: alerts.Alert{Symbol: "KO", Type: alerts.AlertBelow, Threshold: 60}}
+	events, err := m.Process([]*screener.ScreenResult{
+		result("AAPL", 100, 90, 96, 110),
+		result("MSFT", 200, 60, 190, 220),
+		result("KO", 58, 40, 56, 62),
+	}, []alerts.TriggeredAlert{ko}, day(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Symbol != "KO" || events[1].Symbol != "AAPL" {
+		t.Fatalf("events = %+v, want KO then AAPL opened", events)
+	}
+	trades := m.Journal().Trades
+	if trades[0].Source != SourceAlert || trades[1].Source != SourceScore {
+		t.Errorf("sources %s, %s", trades[0].Source, trades[1].Source)
+	}
+	if trades[1].Shares != 200 || trades[1].StopLoss != 96 || trades[1].TakeProfit != 110 {
+		t.Errorf("AAPL trade = %+v", trades[1])
+	}
+
+	// AAPL stays high: no new entry. KO falls through its stop, which frees
+	// the position MSFT, new to the high scores, takes.
+	events, err = m.Process([]*screener.ScreenResult{
+		result("AAPL", 104, 92, 99, 115),
+		result("MSFT", 200, 80, 190, 220),
+		result("KO", 55, 40, 53, 60),
+	}, nil, day(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Kind != EventClose || events[0].Symbol != "KO" ||
+		events[1].Kind != EventOpen || events[1].Symbol != "MSFT" {
+		t.Fatalf("events = %+v, want KO closed and MSFT opened", events)
+	}
+	j := m.Journal()
+	aapl := j.Trades[1]
+	if aapl.LastPrice != 104 || aapl.StopLoss != 96 || !aapl.Open() {
+		t.Errorf("AAPL after reload = %+v; levels must stay as entered", aapl)
+	}
+
+	// AAPL reaches its take profit
+	if _, err := m.Process([]*screener.ScreenResult{result("AAPL", 111, 92, 99, 115)}, nil, day(4)); err != nil {
+		t.Fatal(err)
+	}
+	// MSFT, left out of that reload, is still among the high scores
+	if hs := m.Journal().HighScores; len(hs) != 2 || hs[0] != "AAPL" || hs[1] != "MSFT" {
+		t.Errorf("high scores = %v, want [AAPL MSFT]", hs)
+	}
+
+	r := m.Journal().Report()
+	if r.Wins != 1 || r.Losses != 1 || r.Targets != 1 || r.Stops != 1 || !near(r.WinRate, 50) {
+		t.Fatalf("report = %+v", r)
+	}
+	// AAPL +11×200, KO -3×344 (capped by the max position)
+	koTrade := m.Journal().Trades[0]
+	if koTrade.Shares != 344 {
+		t.Errorf("KO shares = %d, want 344", koTrade.Shares)
+	}
+	if want := 2200 - 3*float64(koTrade.Shares); !near(r.Realized, want) {
+		t.Errorf("realised %.2f, want %.2f", r.Realized, want)
+	}
+	if len(r.Open) != 1 || r.Open[0].Symbol != "MSFT" {
+		t.Errorf("open trades = %+v, want MSFT", r.Open)
+	}
+	if r.Reloads != 3 || r.Updated != day(4) {
+		t.Errorf("reloads %d, updated %v", r.Reloads, r.Updated)
+	}
+
+	// The journal survives a reload and stops
+	reloaded := NewManager(path)
+	if err := reloaded.LoadError(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Journal(); !got.Active || len(got.Trades) != len(m.Journal().Trades) || len(got.Events) == 0 {
+		t.Errorf("reloaded journal = %+v", got)
+	}
+	if err := reloaded.Stop(day(5)); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := reloaded.Process([]*screener.ScreenResult{result("AAPL", 100, 95, 96, 110)}, nil, day(6)); events != nil {
+		t.Errorf("stopped journal traded: %+v", events)
+	}
+}
+
+func TestManager_Sizing(t *testing.T) {
+	m := NewManager(filepath.Join(t.TempDir(), "paper.json"))
+	s := Settings{Capital: 1000, MinScore: 50, MaxPositions: 5, RiskPercent: 50, MaxPositionPercent: 100}
+	if err := m.Start(&s, day(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The max position buys 11 shares of A, leaving too little cash for B;
+	// C's stop is above its price
+	events, err := m.Process([]*screener.ScreenResult{
+		result("A", 90, 90, 50, 120),
+		result("B", 50, 80, 40, 60),
+		result("C", 50, 70, 55, 60),
+	}, nil, day(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]string{}
+	for _, e := range events {
+		kinds[e.Symbol] = e.Kind
+	}
+	if kinds["A"] != EventOpen || kinds["B"] != EventSkip || kinds["C"] != EventSkip {
+		t.Fatalf("events = %+v", events)
+	}
+	if a := m.Journal().Trades[0]; a.Shares != 11 {
+		t.Errorf("A shares = %d, want 11", a.Shares)
+	}
+}
+
+func TestManager_Start(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(filepath.Join(dir, "paper.json"))
+	if err := m.Start(nil, day(1)); err == nil {
+		t.Error("starting without settings should fail")
+	}
+	bad := testSettings()
+	bad.Capital = 0
+	if err := m.Start(&bad, day(1)); err == nil {
+		t.Error("zero capital should be rejected")
+	}
+	s := testSettings()
+	if err := m.Start(&s, day(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(&s, day(1)); err == nil {
+		t.Error("starting twice should fail")
+	}
+	m.Process([]*screener.ScreenResult{result("AAPL", 100, 90, 96, 110)}, nil, day(2))
+	m.Stop(day(3))
+
+	other := testSettings()
+	other.MinScore = 60
+	if err := m.Start(&other, day(4)); err == nil {
+		t.Error("changing the settings of a journal with trades should fail")
+	}
+	if err := m.Start(nil, day(4)); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Journal(); got.Started != day(1) || got.Settings != s {
+		t.Errorf("resumed journal started %v with %+v", got.Started, got.Settings)
+	}
+
+	if err := m.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Active() || m.Started() || len(m.Journal().Trades) != 0 {
+		t.Error("reset left the journal")
+	}
+
+	// Newer files are left alone
+	newer := filepath.Join(dir, "newer.json")
+	content := `{"version":99}`
+	os.WriteFile(newer, []byte(content), 0644)
+	m = NewManager(newer)
+	if m.LoadError() == nil {
+		t.Fatal("a newer file version should fail to load")
+	}
+	if err := m.Start(&s, day(1)); err == nil {
+		t.Error("starting an unreadable journal should fail")
+	}
+	if data, _ := os.ReadFile(newer); string(data) != content {
+		t.Errorf("unreadable file was overwritten:\n%s", data)
+	}
+}
+
+func TestDefaultSettings(t *testing.T) {
+	s := DefaultSettings(config.AccountSettings{})
+	if s.Capital != DefaultCapital || s.MinScore != DefaultMinScore || s.RiskPercent != 1 || !s.Alerts {
+		t.Errorf("defaults = %+v", s)
+	}
+	s = DefaultSettings(config.AccountSettings{Equity: 25000, RiskPercent: 2})
+	if s.Capital != 25000 || s.RiskPercent != 2 || s.MaxPositionPercent != 20 {
+		t.Errorf("from the account = %+v", s)
+	}
+}
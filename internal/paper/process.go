@@ -0,0 +1,226 @@
+package paper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/alerts"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// signal is an entry signal for a symbol
+type signal struct {
+	result *screener.ScreenResult
+	source string
+	reason string
+}
+
+// Process trades one reload's results while paper trading is on, and
+// returns the events it journaled. results should be every scanned result,
+// not only those passing the filter, so open trades are always managed. Open trades whose symbol fell to the stop
+// loss or rose to the take profit are closed at the reload's price, as a
+// market order would be. Then a trade is opened at the price for each
+// triggered alert (when Settings.Alerts is set) and each symbol whose score
+// reached MinScore since the previous reload, while positions and cash are
+// left. Trades are sized by risking RiskPercent of the equity down to the
+// result's stop loss, and keep the result's stop loss and take profit.
+func (m *Manager) Process(results []*screener.ScreenResult, triggered []alerts.TriggeredAlert, now time.Time) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.loadErr != nil {
+		return nil, m.loadErr
+	}
+	if !m.journal.Active {
+		return nil, nil
+	}
+	prev := m.journal
+	prev.Trades = append([]Trade(nil), prev.Trades...)
+
+	bySymbol := make(map[string]*screener.ScreenResult, len(results))
+	for _, r := range results {
+		if r != nil && !r.HasError && r.Price > 0 {
+			bySymbol[r.Symbol] = r
+		}
+	}
+
+	var events []Event
+	record := func(e Event) {
+		e.Time = now
+		events = append(events, e)
+	}
+
+	// Manage the open trades
+	closed := make(map[string]bool)
+	for i := range m.journal.Trades {
+		t := &m.journal.Trades[i]
+		r := bySymbol[t.Symbol]
+		if !t.Open() || r == nil {
+			continue
+		}
+		t.LastPrice, t.LastTime = r.Price, now
+
+		reason := ""
+		switch {
+		case r.Price <= t.StopLoss:
+			reason = ExitStop
+		case t.TakeProfit > 0 && r.Price >= t.TakeProfit:
+			reason = ExitTarget
+		default:
+			continue
+		}
+		t.ExitPrice, t.ExitTime, t.ExitReason = r.Price, now, reason
+		closed[t.Symbol] = true
+		what := "stop loss"
+		if reason == ExitTarget {
+			what = "take profit"
+		}
+		record(Event{Kind: EventClose, Symbol: t.Symbol, TradeID: t.ID, Price: r.Price,
+			Message: fmt.Sprintf("Sold %d %s at %.2f: %s %.2f reached, P&L %+.2f (%+.2f%%)",
+				t.Shares, t.Symbol, r.Price, what, levelOf(*t), t.PnL(), t.ReturnPct())})
+	}
+
+	// Collect the entry signals: alerts first, then the new high scores
+	s := m.journal.Settings
+	var signals []signal
+	seen := make(map[string]bool)
+	if s.Alerts {
+		for _, ta := range triggered {
+			r := bySymbol[ta.Alert.Symbol]
+			if r == nil || seen[r.Symbol] {
+				continue
+			}
+			seen[r.Symbol] = true
+			signals = append(signals, signal{r, SourceAlert,
+				fmt.Sprintf("%s %g alert", alerts.FormatAlertType(ta.Alert.Type), ta.Alert.Threshold)})
+		}
+	}
+
+	// Symbols missing from the results keep their place among the high
+	// scores, so a reload of a few symbols doesn't make the others new
+	previous := make(map[string]bool, len(m.journal.HighScores))
+	var highScores []string
+	for _, symbol := range m.journal.HighScores {
+		previous[symbol] = true
+		if bySymbol[symbol] == nil {
+			highScores = append(highScores, symbol)
+		}
+	}
+	var high []*screener.ScreenResult
+	for _, r := range bySymbol {
+		if r.ConfluenceScore >= s.MinScore {
+			high = append(high, r)
+		}
+	}
+	sort.Slice(high, func(i, j int) bool {
+		if high[i].ConfluenceScore != high[j].ConfluenceScore {
+			return high[i].ConfluenceScore > high[j].ConfluenceScore
+		}
+		return high[i].Symbol < high[j].Symbol
+	})
+	for _, r := range high {
+		highScores = append(highScores, r.Symbol)
+		if previous[r.Symbol] || seen[r.Symbol] {
+			continue
+		}
+		seen[r.Symbol] = true
+		signals = append(signals, signal{r, SourceScore, fmt.Sprintf("score %.0f ≥ %g", r.ConfluenceScore, s.MinScore)})
+	}
+	sort.Strings(highScores)
+	m.journal.HighScores = highScores
+
+	// Open a trade per signal
+	open := make(map[string]bool)
+	for _, t := range m.journal.Trades {
+		if t.Open() {
+			open[t.Symbol] = true
+		}
+	}
+	for _, sig := range signals {
+		r := sig.result
+		if open[r.Symbol] || closed[r.Symbol] {
+			continue // Held, or just stopped out at this price
+		}
+		skip := func(why string) {
+			record(Event{Kind: EventSkip, Symbol: r.Symbol, Price: r.Price,
+				Message: fmt.Sprintf("Skipped %s (%s): %s", r.Symbol, sig.reason, why)})
+		}
+		if len(open) >= s.MaxPositions {
+			skip(fmt.Sprintf("%d positions open", len(open)))
+			continue
+		}
+
+		cash, equity, openRisk := balances(m.journal)
+		sz, err := s.account(equity).Size(r.Price, r.StopLoss, openRisk)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+		shares := sz.Shares
+		if affordable := int(math.Floor(cash / r.Price)); shares > affordable {
+			shares = affordable
+		}
+		if shares < 1 {
+			skip("not enough cash or risk budget for one share")
+			continue
+		}
+
+		t := Trade{
+			ID:         strconv.Itoa(len(m.journal.Trades) + 1),
+			Symbol:     r.Symbol,
+			Source:     sig.source,
+			Reason:     sig.reason,
+			Score:      r.ConfluenceScore,
+			Shares:     shares,
+			EntryPrice: r.Price,
+			EntryTime:  now,
+			StopLoss:   r.StopLoss,
+			TakeProfit: r.TakeProfit,
+			Currency:   r.Currency,
+			LastPrice:  r.Price,
+			LastTime:   now,
+		}
+		m.journal.Trades = append(m.journal.Trades, t)
+		open[r.Symbol] = true
+		record(Event{Kind: EventOpen, Symbol: t.Symbol, TradeID: t.ID, Price: t.EntryPrice,
+			Message: fmt.Sprintf("Bought %d %s at %.2f (%s), SL %.2f, TP %.2f",
+				t.Shares, t.Symbol, t.EntryPrice, t.Reason, t.StopLoss, t.TakeProfit)})
+	}
+
+	_, equity, _ := balances(m.journal)
+	m.journal.Equity = append(m.journal.Equity, EquityPoint{Time: now, Equity: equity})
+	m.journal.Events = append(m.journal.Events, events...)
+	if err := m.saveUnsafe(); err != nil {
+		m.journal = prev
+		return nil, err
+	}
+	return events, nil
+}
+
+// levelOf returns the level a closed trade exited at
+func levelOf(t Trade) float64 {
+	if t.ExitReason == ExitTarget {
+		return t.TakeProfit
+	}
+	return t.StopLoss
+}
+
+// balances returns the cash left, the equity with the open trades at their
+// last price, and the capital at risk in the open trades of a journal
+func balances(j Journal) (cash, equity, openRisk float64) {
+	cash = j.Settings.Capital
+	equity = j.Settings.Capital
+	for _, t := range j.Trades {
+		if t.Open() {
+			cash -= t.Cost()
+			openRisk += t.Risk()
+		} else {
+			cash += t.PnL()
+		}
+		equity += t.PnL()
+	}
+	return cash, equity, openRisk
+}
@@ -0,0 +1,96 @@
+package paper
+
+import (
+	"sort"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/analysis"
+)
+
+// Report is the forward-tested performance of a journal
+type Report struct {
+	Active      bool      `json:"active"`
+	Started     time.Time `json:"started"`
+	Updated     time.Time `json:"updated"` // Last reload traded, zero before the first
+	Settings    Settings  `json:"settings"`
+	Cash        float64   `json:"cash"`
+	Equity      float64   `json:"equity"`       // Cash plus the open trades at their last price
+	TotalReturn float64   `json:"total_return"` // Percent of the capital
+	Realized    float64   `json:"realized"`
+	Unrealized  float64   `json:"unrealized"`
+	OpenRisk    float64   `json:"open_risk"`    // Lost if every open trade's stop were hit
+	MaxDrawdown float64   `json:"max_drawdown"` // Percent from the equity peak
+	Reloads     int       `json:"reloads"`
+
+	// Closed trades
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	WinRate      float64 `json:"win_rate"`      // Percent of closed trades with a profit
+	AvgWin       float64 `json:"avg_win"`       // Percent return of the winning trades
+	AvgLoss      float64 `json:"avg_loss"`      // Percent return of the losing trades
+	ProfitFactor float64 `json:"profit_factor"` // Gross profit / gross loss; 0 without losing trades
+	Targets      int     `json:"targets"`       // Closed at the take profit
+	Stops        int     `json:"stops"`         // Closed at the stop loss
+
+	Open   []Trade `json:"open"`   // Oldest first
+	Closed []Trade `json:"closed"` // In the order they were closed
+}
+
+// Report computes the performance of the journal
+func (j Journal) Report() Report {
+	r := Report{Active: j.Active, Started: j.Started, Settings: j.Settings, Reloads: len(j.Equity)}
+	r.Cash, r.Equity, r.OpenRisk = balances(j)
+	if j.Settings.Capital > 0 {
+		r.TotalReturn = (r.Equity/j.Settings.Capital - 1) * 100
+	}
+	if len(j.Equity) > 0 {
+		r.Updated = j.Equity[len(j.Equity)-1].Time
+	}
+
+	var grossWin, grossLoss, winPct, lossPct float64
+	for _, t := range j.Trades {
+		if t.Open() {
+			r.Open = append(r.Open, t)
+			r.Unrealized += t.PnL()
+			continue
+		}
+		r.Closed = append(r.Closed, t)
+		r.Realized += t.PnL()
+		if t.PnL() > 0 {
+			r.Wins++
+			grossWin += t.PnL()
+			winPct += t.ReturnPct()
+		} else {
+			r.Losses++
+			grossLoss -= t.PnL()
+			lossPct += t.ReturnPct()
+		}
+		switch t.ExitReason {
+		case ExitTarget:
+			r.Targets++
+		case ExitStop:
+			r.Stops++
+		}
+	}
+	sort.SliceStable(r.Closed, func(a, b int) bool { return r.Closed[a].ExitTime.Before(r.Closed[b].ExitTime) })
+
+	if n := len(r.Closed); n > 0 {
+		r.WinRate = float64(r.Wins) / float64(n) * 100
+	}
+	if r.Wins > 0 {
+		r.AvgWin = winPct / float64(r.Wins)
+	}
+	if r.Losses > 0 {
+		r.AvgLoss = lossPct / float64(r.Losses)
+	}
+	if grossLoss > 0 {
+		r.ProfitFactor = grossWin / grossLoss
+	}
+
+	values := []float64{j.Settings.Capital}
+	for _, p := range j.Equity {
+		values = append(values, p.Equity)
+	}
+	r.MaxDrawdown = analysis.MaxDrawdown(values)
+	return r
+}
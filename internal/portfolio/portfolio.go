@@ -0,0 +1,362 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// FileVersion is the version of the portfolio file format written by Save.
+// Files without a version predate versioning and are read as version 1.
+const FileVersion = 1
+
+// Lot is a purchase of a symbol still (partly) held. Quantity is what is
+// left after sales; Fees the part of the purchase fees not yet sold.
+type Lot struct {
+	ID         string    `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Quantity   float64   `json:"quantity"`
+	EntryPrice float64   `json:"entry_price"`
+	Date       time.Time `json:"date"`
+	Fees       float64   `json:"fees,omitempty"`
+	StopLoss   float64   `json:"stop_loss,omitempty"`   // 0 when not set
+	TakeProfit float64   `json:"take_profit,omitempty"` // 0 when not set
+	Currency   string    `json:"currency,omitempty"`
+}
+
+// Cost returns what the lot cost, fees included
+func (l Lot) Cost() float64 {
+	return l.Quantity*l.EntryPrice + l.Fees
+}
+
+// Sale is the sale of (part of) a lot. Fees holds the sold share of the
+// purchase fees plus the share of the sale fees; Realized is net of both.
+type Sale struct {
+	LotID      string    `json:"lot_id"`
+	Symbol     string    `json:"symbol"`
+	Quantity   float64   `json:"quantity"`
+	EntryPrice float64   `json:"entry_price"`
+	EntryDate  time.Time `json:"entry_date"`
+	ExitPrice  float64   `json:"exit_price"`
+	Date       time.Time `json:"date"`
+	Fees       float64   `json:"fees,omitempty"`
+	Realized   float64   `json:"realized"`
+	Currency   string    `json:"currency,omitempty"`
+}
+
+// File is the JSON structure of portfolio.json
+type File struct {
+	Version int    `json:"version"`
+	Lots    []Lot  `json:"lots"`
+	Sales   []Sale `json:"sales"`
+}
+
+// Manager handles the lots and sales in portfolio.json
+type Manager struct {
+	filePath string
+	lots     []Lot
+	sales    []Sale
+	loadErr  error // Why the file couldn't be read; writing it is refused
+	mu       sync.RWMutex
+}
+
+// NewManager creates a portfolio manager reading customPath, or
+// config/portfolio.json when it is empty
+func NewManager(customPath string) *Manager {
+	path := customPath
+	if path == "" {
+		path = config.Path("portfolio.json")
+	}
+
+	m := &Manager{filePath: path}
+	m.Load()
+	return m
+}
+
+// LoadError returns why the portfolio file couldn't be read, or nil.
+// Nothing is saved while it is set, so an unreadable file isn't replaced.
+func (m *Manager) LoadError() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loadErr
+}
+
+// Path returns the file the portfolio is stored in
+func (m *Manager) Path() string {
+	return m.filePath
+}
+
+// Load reads the portfolio from disk
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.loadErr = m.loadUnsafe()
+	return m.loadErr
+}
+
+func (m *Manager) loadUnsafe() error {
+	data, err := os.ReadFile(m.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // No portfolio yet
+		}
+		return err
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%s: %v", m.filePath, err)
+	}
+	if f.Version > FileVersion {
+		return fmt.Errorf("%s is version %d; this stockmap reads up to version %d", m.filePath, f.Version, FileVersion)
+	}
+
+	m.lots = f.Lots
+	m.sales = f.Sales
+	return nil
+}
+
+// Save writes the portfolio to disk
+func (m *Manager) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saveUnsafe()
+}
+
+func (m *Manager) saveUnsafe() error {
+	if m.loadErr != nil {
+		return m.loadErr
+	}
+	dir := filepath.Dir(m.filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f := File{Version: FileVersion, Lots: m.lots, Sales: m.sales}
+	if f.Lots == nil {
+		f.Lots = []Lot{}
+	}
+	if f.Sales == nil {
+		f.Sales = []Sale{}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(m.filePath, data, 0644)
+}
+
+// Add records a purchase. The symbol is upper-cased, a zero date means now
+// and the ID is assigned.
+func (m *Manager) Add(lot Lot) (*Lot, error) {
+	lot.Symbol = strings.ToUpper(strings.TrimSpace(lot.Symbol))
+	switch {
+	case lot.Symbol == "":
+		return nil, fmt.Errorf("symbol required")
+	case lot.Quantity <= 0:
+		return nil, fmt.Errorf("quantity must be positive")
+	case lot.EntryPrice <= 0:
+		return nil, fmt.Errorf("entry price must be positive")
+	case lot.Fees < 0:
+		return nil, fmt.Errorf("fees must not be negative")
+	case lot.StopLoss < 0 || lot.TakeProfit < 0:
+		return nil, fmt.Errorf("stop loss and take profit must not be negative")
+	}
+	if lot.Date.IsZero() {
+		lot.Date = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lot.ID = m.nextIDUnsafe()
+	m.lots = append(m.lots, lot)
+	if err := m.saveUnsafe(); err != nil {
+		m.lots = m.lots[:len(m.lots)-1]
+		return nil, err
+	}
+	return &lot, nil
+}
+
+// nextIDUnsafe returns an ID not used by any lot or sale
+func (m *Manager) nextIDUnsafe() string {
+	used := make(map[string]bool)
+	for _, l := range m.lots {
+		used[l.ID] = true
+	}
+	for _, s := range m.sales {
+		used[s.LotID] = true
+	}
+	id := time.Now().Format("20060102150405.000")
+	for n := 2; used[id]; n++ {
+		id = fmt.Sprintf("%s-%d", time.Now().Format("20060102150405.000"), n)
+	}
+	return id
+}
+
+// Sell sells quantity of symbol at price, first in first out. The fees of
+// the sale are split over the lots sold. It fails without changing
+// anything when less than quantity is held.
+func (m *Manager) Sell(symbol string, quantity, price, fees float64, date time.Time) ([]Sale, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	switch {
+	case quantity <= 0:
+		return nil, fmt.Errorf("quantity must be positive")
+	case price <= 0:
+		return nil, fmt.Errorf("price must be positive")
+	case fees < 0:
+		return nil, fmt.Errorf("fees must not be negative")
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	held := 0.0
+	var idx []int
+	for i, l := range m.lots {
+		if l.Symbol == symbol {
+			held += l.Quantity
+			idx = append(idx, i)
+		}
+	}
+	if held == 0 {
+		return nil, fmt.Errorf("no %s held", symbol)
+	}
+	if quantity > held+1e-9 {
+		return nil, fmt.Errorf("only %g %s held", held, symbol)
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return m.lots[idx[a]].Date.Before(m.lots[idx[b]].Date) })
+
+	lots := append([]Lot(nil), m.lots...)
+	var sales []Sale
+	left := quantity
+	for _, i := range idx {
+		if left <= 1e-9 {
+			break
+		}
+		l := &lots[i]
+		qty := l.Quantity
+		if left < qty {
+			qty = left
+		}
+		share := qty / l.Quantity
+		entryFees := l.Fees * share
+		saleFees := fees * qty / quantity
+		sales = append(sales, Sale{
+			LotID:      l.ID,
+			Symbol:     symbol,
+			Quantity:   qty,
+			EntryPrice: l.EntryPrice,
+			EntryDate:  l.Date,
+			ExitPrice:  price,
+			Date:       date,
+			Fees:       entryFees + saleFees,
+			Realized:   qty*(price-l.EntryPrice) - entryFees - saleFees,
+			Currency:   l.Currency,
+		})
+		l.Quantity -= qty
+		l.Fees -= entryFees
+		left -= qty
+	}
+
+	// Drop the lots sold in full
+	kept := lots[:0]
+	for _, l := range lots {
+		if l.Quantity > 1e-9 {
+			kept = append(kept, l)
+		}
+	}
+
+	prevLots, prevSales := m.lots, m.sales
+	m.lots = kept
+	m.sales = append(append([]Sale(nil), m.sales...), sales...)
+	if err := m.saveUnsafe(); err != nil {
+		m.lots, m.sales = prevLots, prevSales
+		return nil, err
+	}
+	return sales, nil
+}
+
+// SetLevels sets the stop loss and take profit of every lot of symbol;
+// 0 clears a level
+func (m *Manager) SetLevels(symbol string, stopLoss, takeProfit float64) error {
+	if stopLoss < 0 || takeProfit < 0 {
+		return fmt.Errorf("stop loss and take profit must not be negative")
+	}
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found := false
+	for i := range m.lots {
+		if m.lots[i].Symbol == symbol {
+			m.lots[i].StopLoss = stopLoss
+			m.lots[i].TakeProfit = takeProfit
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no %s held", symbol)
+	}
+	return m.saveUnsafe()
+}
+
+// Lots returns the open lots, oldest first
+func (m *Manager) Lots() []Lot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	lots := append([]Lot(nil), m.lots...)
+	sort.SliceStable(lots, func(i, j int) bool { return lots[i].Date.Before(lots[j].Date) })
+	return lots
+}
+
+// Sales returns the recorded sales, oldest first
+func (m *Manager) Sales() []Sale {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sales := append([]Sale(nil), m.sales...)
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].Date.Before(sales[j].Date) })
+	return sales
+}
+
+// Symbols returns the symbols held, sorted
+func (m *Manager) Symbols() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, l := range m.lots {
+		if !seen[l.Symbol] {
+			seen[l.Symbol] = true
+			symbols = append(symbols, l.Symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Count returns the number of symbols held
+func (m *Manager) Count() int {
+	return len(m.Symbols())
+}
+
+// Summary values the portfolio at prices (see Value)
+func (m *Manager) Summary(prices map[string]float64) Summary {
+	return Value(m.Lots(), m.Sales(), prices)
+}
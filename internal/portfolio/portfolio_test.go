@@ -0,0 +1,220 @@
+package portfolio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestManager_AddSell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolio.json")
+	m := NewManager(path)
+
+	if _, err := m.Add(Lot{Symbol: "aapl", Quantity: 10, EntryPrice: 100, Fees: 2, Date: day(2)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Add(Lot{Symbol: "AAPL", Quantity: 10, EntryPrice: 120, Date: day(5), StopLoss: 110, TakeProfit: 150}); err != nil {
+		t.Fatal(err)
+	}
+
+	// FIFO: 15 shares take the whole first lot and half the second
+	sales, err := m.Sell("AAPL", 15, 130, 3, day(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sales) != 2 {
+		t.Fatalf("got %d sales, want 2", len(sales))
+	}
+	// 10*(130-100) - 2 entry fees - 2 of the 3 sale fees
+	if !near(sales[0].Realized, 296) || sales[0].Quantity != 10 {
+		t.Errorf("first sale = %+v, want 10 shares realising 296", sales[0])
+	}
+	// 5*(130-120) - 1 sale fee
+	if !near(sales[1].Realized, 49) || sales[1].Quantity != 5 {
+		t.Errorf("second sale = %+v, want 5 shares realising 49", sales[1])
+	}
+
+	lots := m.Lots()
+	if len(lots) != 1 || lots[0].Quantity != 5 || lots[0].EntryPrice != 120 {
+		t.Fatalf("lots after sale = %+v, want 5 left at 120", lots)
+	}
+
+	if _, err := m.Sell("AAPL", 6, 130, 0, day(11)); err == nil {
+		t.Error("selling more than held should fail")
+	}
+	if _, err := m.Sell("MSFT", 1, 130, 0, day(11)); err == nil {
+		t.Error("selling a symbol not held should fail")
+	}
+	if _, err := m.Add(Lot{Symbol: "AAPL", Quantity: 0, EntryPrice: 1}); err == nil {
+		t.Error("a zero quantity should be rejected")
+	}
+
+	// Everything survives a reload
+	reloaded := NewManager(path)
+	if err := reloaded.LoadError(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Lots(); len(got) != 1 || got[0].Quantity != 5 || got[0].StopLoss != 110 {
+		t.Errorf("reloaded lots = %+v", got)
+	}
+	if got := reloaded.Sales(); len(got) != 2 {
+		t.Errorf("reloaded %d sales, want 2", len(got))
+	}
+}
+
+func TestManager_Version(t *testing.T) {
+	dir := t.TempDir()
+
+	// Files from before versioning are read as version 1
+	old := filepath.Join(dir, "old.json")
+	os.WriteFile(old, []byte(`{"lots":[{"id":"1","symbol":"SPY","quantity":2,"entry_price":400,"date":"2024-01-02T00:00:00Z"}]}`), 0644)
+	m := NewManager(old)
+	if err := m.LoadError(); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Lots()) != 1 {
+		t.Fatalf("got %d lots, want 1", len(m.Lots()))
+	}
+	if _, err := m.Add(Lot{Symbol: "SPY", Quantity: 1, EntryPrice: 410}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(old)
+	if !strings.Contains(string(data), `"version": 1`) {
+		t.Errorf("saved file has no version:\n%s", data)
+	}
+
+	// Newer files are left alone
+	newer := filepath.Join(dir, "newer.json")
+	content := `{"version":99,"lots":[]}`
+	os.WriteFile(newer, []byte(content), 0644)
+	m = NewManager(newer)
+	if m.LoadError() == nil {
+		t.Fatal("a newer file version should fail to load")
+	}
+	if _, err := m.Add(Lot{Symbol: "SPY", Quantity: 1, EntryPrice: 410}); err == nil {
+		t.Error("adding to an unreadable portfolio should fail")
+	}
+	if data, _ := os.ReadFile(newer); string(data) != content {
+		t.Errorf("unreadable file was overwritten:\n%s", data)
+	}
+}
+
+func TestValue(t *testing.T) {
+	lots := []Lot{
+		{Symbol: "AAPL", Quantity: 10, EntryPrice: 100, Fees: 10, Date: day(2), StopLoss: 90},
+		{Symbol: "AAPL", Quantity: 10, EntryPrice: 110, Date: day(3), StopLoss: 95, TakeProfit: 132},
+		{Symbol: "MSFT", Quantity: 5, EntryPrice: 200, Date: day(4)},
+		{Symbol: "SPY", Quantity: 1, EntryPrice: 400, Date: day(4)},
+	}
+	sales := []Sale{
+		{Symbol: "AAPL", Quantity: 1, Realized: 5},
+		{Symbol: "TSLA", Quantity: 1, Realized: -20},
+	}
+	prices := Prices([]*screener.ScreenResult{
+		{Symbol: "AAPL", Price: 120},
+		{Symbol: "MSFT", Price: 240},
+		{Symbol: "SPY", Price: 500, HasError: true},
+	})
+
+	s := Value(lots, sales, prices)
+	if len(s.Positions) != 3 {
+		t.Fatalf("got %d positions, want 3", len(s.Positions))
+	}
+	if len(s.Unpriced) != 1 || s.Unpriced[0] != "SPY" {
+		t.Errorf("unpriced = %v, want [SPY]", s.Unpriced)
+	}
+
+	aapl := s.Positions[0]
+	if aapl.Symbol != "AAPL" || aapl.Quantity != 20 || !near(aapl.Cost, 2110) || !near(aapl.AvgCost, 105.5) {
+		t.Fatalf("AAPL = %+v", aapl)
+	}
+	if !near(aapl.MarketValue, 2400) || !near(aapl.Unrealized, 290) {
+		t.Errorf("AAPL value %.2f, P&L %.2f; want 2400, 290", aapl.MarketValue, aapl.Unrealized)
+	}
+	if !near(aapl.Realized, 5) || !near(s.Realized, -15) || s.Sales != 2 {
+		t.Errorf("realised AAPL %.2f, total %.2f over %d sales; want 5, -15, 2", aapl.Realized, s.Realized, s.Sales)
+	}
+	// Levels come from the latest lot
+	if aapl.StopLoss != 95 || !near(aapl.ToStop, 25.0/120*100) || !near(aapl.ToTarget, 10) {
+		t.Errorf("AAPL stop %.2f (%.2f%%), target %.2f%%", aapl.StopLoss, aapl.ToStop, aapl.ToTarget)
+	}
+
+	msft := s.Positions[1]
+	if msft.Symbol != "MSFT" || msft.ToStop != 0 || msft.ToTarget != 0 {
+		t.Errorf("MSFT = %+v", msft)
+	}
+	if !near(aapl.Weight+msft.Weight, 100) || !near(msft.Weight, 1200.0/3600*100) {
+		t.Errorf("weights %.2f + %.2f", aapl.Weight, msft.Weight)
+	}
+
+	spy := s.Positions[2]
+	if spy.Symbol != "SPY" || spy.Priced || spy.Weight != 0 {
+		t.Errorf("SPY = %+v", spy)
+	}
+	if !near(s.MarketValue, 3600) || !near(s.Unrealized, 490) || !near(s.Cost, 3510) {
+		t.Errorf("summary value %.2f, P&L %.2f, cost %.2f", s.MarketValue, s.Unrealized, s.Cost)
+	}
+}
+
+func TestAccount_Size(t *testing.T) {
+	a := NewAccount(config.AccountSettings{Equity: 100000})
+	if a.RiskPercent != DefaultRiskPercent || a.MaxPositionPercent != DefaultMaxPositionPercent {
+		t.Fatalf("defaults not applied: %+v", a)
+	}
+
+	// 1% of 100000 over a 4 risk per share: 250 shares, 25000 (25%) capped to 20%
+	s, err := a.Size(100, 96, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.RiskShares != 250 || s.Shares != 200 || !s.Capped {
+		t.Errorf("shares %d (risk %d, capped %v), want 200 (250, true)", s.Shares, s.RiskShares, s.Capped)
+	}
+	if !near(s.Value, 20000) || !near(s.CapitalAtRisk, 800) || !near(s.RiskPercent, 0.8) {
+		t.Errorf("value %.2f, at risk %.2f (%.2f%%)", s.Value, s.CapitalAtRisk, s.RiskPercent)
+	}
+	if !near(s.Heat, 1.3) {
+		t.Errorf("heat %.2f%%, want 1.3%%", s.Heat)
+	}
+
+	// A wider stop is sized by the risk budget
+	s, _ = a.Size(100, 90, 0)
+	if s.Shares != 100 || s.Capped || !near(s.Heat, 1) {
+		t.Errorf("shares %d (capped %v, heat %.2f), want 100 (false, 1)", s.Shares, s.Capped, s.Heat)
+	}
+
+	if _, err := a.Size(100, 100, 0); err == nil {
+		t.Error("a stop at the entry should be rejected")
+	}
+	if _, err := NewAccount(config.AccountSettings{}).Size(100, 90, 0); err == nil {
+		t.Error("sizing without equity should fail")
+	}
+}
+
+func TestSummary_OpenRisk(t *testing.T) {
+	s := Value([]Lot{
+		{Symbol: "AAPL", Quantity: 10, EntryPrice: 100, StopLoss: 90},
+		{Symbol: "MSFT", Quantity: 5, EntryPrice: 200, StopLoss: 190},
+		{Symbol: "KO", Quantity: 5, EntryPrice: 60},
+		{Symbol: "SPY", Quantity: 2, EntryPrice: 400, StopLoss: 380},
+	}, nil, map[string]float64{"AAPL": 110, "MSFT": 180, "KO": 50})
+
+	// AAPL 10×20; MSFT is below its stop; KO has none; SPY unpriced 2×20
+	if got := s.OpenRisk(); !near(got, 240) {
+		t.Errorf("open risk = %.2f, want 240", got)
+	}
+}
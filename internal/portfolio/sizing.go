@@ -0,0 +1,123 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/febritecno/stockmap-cli/internal/analysis"
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// Default account risk limits
+const (
+	DefaultRiskPercent        = 1.0
+	DefaultMaxPositionPercent = 20.0
+)
+
+// Account is the account position sizes are calculated for
+type Account struct {
+	Equity             float64
+	RiskPercent        float64 // Equity risked per trade, in percent
+	MaxPositionPercent float64 // Largest position, in percent of equity
+}
+
+// NewAccount returns the account in the settings, with the default risk
+// limits where they are not set
+func NewAccount(s config.AccountSettings) Account {
+	a := Account{Equity: s.Equity, RiskPercent: s.RiskPercent, MaxPositionPercent: s.MaxPositionPercent}
+	if a.RiskPercent <= 0 {
+		a.RiskPercent = DefaultRiskPercent
+	}
+	if a.MaxPositionPercent <= 0 {
+		a.MaxPositionPercent = DefaultMaxPositionPercent
+	}
+	return a
+}
+
+// Validate checks that the account can size positions
+func (a Account) Validate() error {
+	switch {
+	case a.Equity <= 0:
+		return fmt.Errorf("account equity is not set")
+	case a.RiskPercent <= 0 || a.RiskPercent > 100:
+		return fmt.Errorf("risk per trade must be between 0 and 100%%, got %g", a.RiskPercent)
+	case a.MaxPositionPercent <= 0 || a.MaxPositionPercent > 100:
+		return fmt.Errorf("max position must be between 0 and 100%%, got %g", a.MaxPositionPercent)
+	}
+	return nil
+}
+
+// Sizing is the size of a long position bought at Entry with a stop at
+// StopLoss
+type Sizing struct {
+	Entry        float64
+	StopLoss     float64
+	RiskPerShare float64
+
+	// Shares is the position size: what risking RiskPercent of equity
+	// allows (RiskShares), capped to MaxPositionPercent of equity (Capped)
+	Shares     int
+	RiskShares int
+	Capped     bool
+
+	Value         float64 // Shares × Entry
+	ValuePercent  float64 // Of equity
+	CapitalAtRisk float64 // Lost if the stop is hit
+	RiskPercent   float64 // CapitalAtRisk in percent of equity
+
+	// OpenRisk is what the open positions lose if their stops are hit; Heat
+	// is OpenRisk plus CapitalAtRisk in percent of equity
+	OpenRisk float64
+	Heat     float64
+}
+
+// Size sizes a long position bought at entry with a stop at stopLoss.
+// openRisk is the capital at risk in the open positions (see
+// Summary.OpenRisk).
+func (a Account) Size(entry, stopLoss, openRisk float64) (Sizing, error) {
+	if err := a.Validate(); err != nil {
+		return Sizing{}, err
+	}
+	if entry <= 0 {
+		return Sizing{}, fmt.Errorf("no entry price")
+	}
+	if stopLoss <= 0 || stopLoss >= entry {
+		return Sizing{}, fmt.Errorf("the stop loss must be below the entry price")
+	}
+
+	s := Sizing{Entry: entry, StopLoss: stopLoss, RiskPerShare: entry - stopLoss, OpenRisk: openRisk}
+	s.RiskShares = analysis.PositionSize(a.Equity, a.RiskPercent, entry, stopLoss)
+	s.Shares = s.RiskShares
+	if maxShares := int(math.Floor(a.Equity * a.MaxPositionPercent / 100 / entry)); s.Shares > maxShares {
+		s.Shares = maxShares
+		s.Capped = true
+	}
+
+	s.Value = float64(s.Shares) * entry
+	s.ValuePercent = s.Value / a.Equity * 100
+	s.CapitalAtRisk = float64(s.Shares) * s.RiskPerShare
+	s.RiskPercent = s.CapitalAtRisk / a.Equity * 100
+	s.Heat = (openRisk + s.CapitalAtRisk) / a.Equity * 100
+	return s, nil
+}
+
+// OpenRisk returns what the open positions lose if the price falls to their
+// stop loss: the quantity times the distance from the price (the average
+// cost when unpriced) down to the stop. Positions without a stop, or
+// already below it, add nothing.
+func (s Summary) OpenRisk() float64 {
+	risk := 0.0
+	for _, p := range s.Positions {
+		if p.StopLoss <= 0 {
+			continue
+		}
+		price := p.Price
+		if !p.Priced {
+			price = p.AvgCost
+		}
+		if price > p.StopLoss {
+			risk += p.Quantity * (price - p.StopLoss)
+		}
+	}
+	return risk
+}
@@ -0,0 +1,152 @@
+package portfolio
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/febritecno/stockmap-cli/internal/screener"
+)
+
+// Position is the lots held of one symbol valued at a price
+type Position struct {
+	Symbol   string
+	Currency string
+	Lots     int
+	Quantity float64
+	AvgCost  float64 // Cost per share, purchase fees included
+	Cost     float64
+
+	// Price is the last price; the fields below are 0 when it is unknown
+	// (Priced false)
+	Priced        bool
+	Price         float64
+	MarketValue   float64
+	Unrealized    float64
+	UnrealizedPct float64
+	Weight        float64 // Percent of the market value of the priced positions
+
+	// Realized is the realised P&L of the symbol's sales
+	Realized float64
+
+	// StopLoss and TakeProfit come from the most recent lot with the level
+	// set (0 if none). ToStop is how far the price is above the stop and
+	// ToTarget how far it is below the target, in percent of the price;
+	// negative once the level is crossed.
+	StopLoss   float64
+	TakeProfit float64
+	ToStop     float64
+	ToTarget   float64
+}
+
+// Summary is the portfolio valued at a set of prices. Amounts are added up
+// as quoted, without currency conversion.
+type Summary struct {
+	Positions     []Position
+	Cost          float64
+	MarketValue   float64 // Of the priced positions
+	Unrealized    float64
+	UnrealizedPct float64
+	Realized      float64 // Of every sale, including symbols no longer held
+	Sales         int
+
+	// Unpriced lists the symbols held without a price
+	Unpriced []string
+}
+
+// Value values lots at prices, keyed by upper-case symbol. Positions are
+// sorted by market value, largest first, then by symbol.
+func Value(lots []Lot, sales []Sale, prices map[string]float64) Summary {
+	var s Summary
+	bySymbol := make(map[string]*Position)
+	var order []string
+	for _, l := range lots {
+		p, ok := bySymbol[l.Symbol]
+		if !ok {
+			p = &Position{Symbol: l.Symbol, Currency: l.Currency}
+			bySymbol[l.Symbol] = p
+			order = append(order, l.Symbol)
+		}
+		p.Lots++
+		p.Quantity += l.Quantity
+		p.Cost += l.Cost()
+		// Lots come oldest first, so the latest level wins
+		if l.StopLoss > 0 {
+			p.StopLoss = l.StopLoss
+		}
+		if l.TakeProfit > 0 {
+			p.TakeProfit = l.TakeProfit
+		}
+	}
+
+	for _, sale := range sales {
+		s.Realized += sale.Realized
+		s.Sales++
+		if p, ok := bySymbol[sale.Symbol]; ok {
+			p.Realized += sale.Realized
+		}
+	}
+
+	for _, symbol := range order {
+		p := bySymbol[symbol]
+		if p.Quantity > 0 {
+			p.AvgCost = p.Cost / p.Quantity
+		}
+		s.Cost += p.Cost
+
+		price := prices[strings.ToUpper(symbol)]
+		if price <= 0 {
+			s.Unpriced = append(s.Unpriced, symbol)
+			continue
+		}
+		p.Priced = true
+		p.Price = price
+		p.MarketValue = p.Quantity * price
+		p.Unrealized = p.MarketValue - p.Cost
+		if p.Cost > 0 {
+			p.UnrealizedPct = p.Unrealized / p.Cost * 100
+		}
+		if p.StopLoss > 0 {
+			p.ToStop = (price - p.StopLoss) / price * 100
+		}
+		if p.TakeProfit > 0 {
+			p.ToTarget = (p.TakeProfit - price) / price * 100
+		}
+		s.MarketValue += p.MarketValue
+		s.Unrealized += p.Unrealized
+	}
+
+	pricedCost := 0.0
+	for _, symbol := range order {
+		p := bySymbol[symbol]
+		if p.Priced {
+			pricedCost += p.Cost
+			if s.MarketValue > 0 {
+				p.Weight = p.MarketValue / s.MarketValue * 100
+			}
+		}
+		s.Positions = append(s.Positions, *p)
+	}
+	if pricedCost > 0 {
+		s.UnrealizedPct = s.Unrealized / pricedCost * 100
+	}
+
+	sort.SliceStable(s.Positions, func(i, j int) bool {
+		if s.Positions[i].MarketValue != s.Positions[j].MarketValue {
+			return s.Positions[i].MarketValue > s.Positions[j].MarketValue
+		}
+		return s.Positions[i].Symbol < s.Positions[j].Symbol
+	})
+	sort.Strings(s.Unpriced)
+	return s
+}
+
+// Prices returns the prices of scan results by symbol, skipping failed ones
+func Prices(results []*screener.ScreenResult) map[string]float64 {
+	prices := make(map[string]float64, len(results))
+	for _, r := range results {
+		if r != nil && !r.HasError && r.Price > 0 {
+			prices[strings.ToUpper(r.Symbol)] = r.Price
+		}
+	}
+	return prices
+}
@@ -1,9 +1,14 @@
 package screener
 
 import (
+	"context"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/febritecno/stockmap-cli/internal/config"
 	"github.com/febritecno/stockmap-cli/internal/fetcher"
 	"github.com/febritecno/stockmap-cli/internal/watchlist"
 )
@@ -17,19 +22,118 @@ type FilterCriteria struct {
 	MinConfluence   float64
 	OnlyOversold    bool
 	OnlyUndervalued bool
+
+	// Expression is an optional screening expression results must also
+	// satisfy (see Compile); nil matches everything
+	Expression *Expr
 }
 
-// DefaultCriteria returns the default deep value criteria
+// DefaultCriteria returns the criteria of the default strategy, Deep Value,
+// as config/strategies.yaml may have changed them
 func DefaultCriteria() FilterCriteria {
-	return FilterCriteria{
-		MinRSI:          0,
-		MaxRSI:          40, // Only oversold/neutral
-		MaxPBV:          2.0,
-		MinGrahamUpside: 0,
-		MinConfluence:   50,
-		OnlyOversold:    false,
-		OnlyUndervalued: false,
+	return DefaultStrategy().Criteria()
+}
+
+// Matches checks if a result meets the criteria
+func (c FilterCriteria) Matches(r *ScreenResult) bool {
+	if r.HasError {
+		return false
+	}
+
+	// RSI filter
+	if r.RSI < c.MinRSI || r.RSI > c.MaxRSI {
+		if r.RSI > 0 { // Only filter if RSI was calculated
+			return false
+		}
+	}
+
+	// PBV filter
+	if r.PBV > c.MaxPBV && r.PBV > 0 {
+		return false
+	}
+
+	// Graham Upside filter
+	if r.GrahamUpside < c.MinGrahamUpside {
+		return false
+	}
+
+	// Confluence score filter
+	if r.ConfluenceScore < c.MinConfluence {
+		return false
+	}
+
+	// Boolean filters
+	if c.OnlyOversold && !r.IsOversold {
+		return false
+	}
+
+	if c.OnlyUndervalued && !r.IsUndervalued {
+		return false
+	}
+
+	if c.Expression != nil && !c.Expression.Match(r) {
+		return false
+	}
+
+	return true
+}
+
+// HistoryConfig selects the price history fetched for each symbol
+type HistoryConfig struct {
+	Interval     fetcher.Interval
+	LookbackDays int  // 0 picks the lookback from the largest indicator period
+	FillGaps     bool // Forward-fill null bars instead of dropping them
+}
+
+// DefaultHistoryConfig returns daily bars with an automatic lookback
+func DefaultHistoryConfig() HistoryConfig {
+	return HistoryConfig{Interval: fetcher.DefaultInterval}
+}
+
+// HistoryConfigFromSettings validates the scan settings from settings.json
+func HistoryConfigFromSettings(s config.ScanSettings) (HistoryConfig, error) {
+	interval, err := fetcher.ParseInterval(s.Interval)
+	if err != nil {
+		return DefaultHistoryConfig(), err
+	}
+	if s.LookbackDays < 0 {
+		return DefaultHistoryConfig(), fmt.Errorf("lookback_days must not be negative")
+	}
+	return HistoryConfig{Interval: interval, LookbackDays: s.LookbackDays, FillGaps: s.FillGaps}, nil
+}
+
+// Options returns the fetch options, resolving an automatic lookback
+// so that the largest default indicator period gets enough bars
+func (h HistoryConfig) Options() fetcher.HistoryOptions {
+	return h.OptionsFor(RequiredBars())
+}
+
+// OptionsFor returns the fetch options, resolving an automatic lookback
+// so that the given number of bars is fetched
+func (h HistoryConfig) OptionsFor(bars int) fetcher.HistoryOptions {
+	interval := h.Interval
+	if interval == "" {
+		interval = fetcher.DefaultInterval
+	}
+
+	days := h.LookbackDays
+	if days <= 0 {
+		days = fetcher.LookbackDays(bars, interval)
+	}
+
+	return fetcher.HistoryOptions{Days: days, Interval: interval, FillGaps: h.FillGaps}
+}
+
+// RankOptionsFromSettings validates the ranking settings from settings.json
+func RankOptionsFromSettings(s config.ScanSettings) (RankOptions, error) {
+	opts := RankOptions{BySector: s.RankBySector, Weights: s.RankWeights}
+	if len(opts.Weights) == 0 {
+		opts.Weights = DefaultRankWeights()
 	}
+	if err := opts.Validate(); err != nil {
+		return DefaultRankOptions(), err
+	}
+	return opts, nil
 }
 
 // ScanProgress contains verbose progress information
@@ -41,6 +145,7 @@ type ScanProgress struct {
 	ErrorCount   int
 	LastError    string
 	ErrorSymbol  string
+	Limiter      fetcher.LimiterStats // Provider throttling during this scan
 }
 
 // Engine handles the screening process
@@ -48,22 +153,72 @@ type Engine struct {
 	pool         *fetcher.WorkerPool
 	watchlist    *watchlist.Manager
 	criteria     FilterCriteria
+	strategy     *Strategy
+	model        *ScoringModel
+	history      HistoryConfig
+	rank         *RankOptions // Ranking pass after each scan; nil disables it
+	classify     Classifier   // Sector and industry tags; nil keeps the provider's
 	results      []*ScreenResult
+	scanned      []*ScreenResult // Every result of the last scan, filtered out or not
+	sectors      []SectorStats   // Sector aggregates of the last scan
 	mu           sync.RWMutex
 	onProgress   func(completed, total int, current string)
 	onProgressV2 func(progress ScanProgress)
 	progress     ScanProgress
+	limiterBase  fetcher.LimiterStats // Limiter stats when the scan started
+	baseCurrency string               // Currency results are converted to for ranking ("" disables FX)
+	fxErr        error                // Missing exchange rates of the last scan
 }
 
-// NewEngine creates a new screening engine
+// fxTimeout bounds the exchange rate requests made after a scan
+const fxTimeout = 15 * time.Second
+
+// NewEngine creates a new screening engine using the active data provider
+// and the history settings from settings.json
 func NewEngine(workers int) *Engine {
+	e := newEngine(fetcher.NewWorkerPool(workers))
+	if s, err := config.Load(); err == nil {
+		if h, err := HistoryConfigFromSettings(s.Scan); err == nil {
+			e.history = h
+		}
+		e.SetBaseCurrency(s.Scan.BaseCurrency)
+		if s.Scan.Strategy != "" {
+			if st, err := LookupStrategy(s.Scan.Strategy); err == nil {
+				e.SetStrategy(st)
+			}
+		}
+		if s.Scan.Rank || s.Scan.RankBySector {
+			if opts, err := RankOptionsFromSettings(s.Scan); err == nil {
+				e.SetRanking(&opts)
+			}
+		}
+	}
+	return e
+}
+
+// NewEngineWithProvider creates a new screening engine that fetches from the given provider
+func NewEngineWithProvider(workers int, provider fetcher.Provider) *Engine {
+	return newEngine(fetcher.NewWorkerPoolWithProvider(workers, provider))
+}
+
+// newEngine creates an engine around a worker pool
+func newEngine(pool *fetcher.WorkerPool) *Engine {
+	strategy := DefaultStrategy()
 	return &Engine{
-		pool:      fetcher.NewWorkerPool(workers),
+		pool:      pool,
 		watchlist: watchlist.NewManager(""),
-		criteria:  DefaultCriteria(),
+		criteria:  strategy.Criteria(),
+		strategy:  strategy,
+		model:     DefaultScoringModel(),
+		history:   DefaultHistoryConfig(),
 	}
 }
 
+// Provider returns the data provider used by the engine
+func (e *Engine) Provider() fetcher.Provider {
+	return e.pool.Provider()
+}
+
 // SetCriteria updates the filter criteria
 func (e *Engine) SetCriteria(c FilterCriteria) {
 	e.criteria = c
@@ -74,6 +229,104 @@ func (e *Engine) GetCriteria() FilterCriteria {
 	return e.criteria
 }
 
+// SetStrategy switches the scoring strategy and resets the filter
+// criteria to the strategy's
+func (e *Engine) SetStrategy(s *Strategy) {
+	e.strategy = s
+	e.criteria = s.Criteria()
+}
+
+// Strategy returns the active strategy
+func (e *Engine) Strategy() *Strategy {
+	return e.strategy
+}
+
+// SetScoringModel validates a scoring model and scores the next scans with it
+func (e *Engine) SetScoringModel(m *ScoringModel) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	e.model = m
+	return nil
+}
+
+// ScoringModel returns the scoring model
+func (e *Engine) ScoringModel() *ScoringModel {
+	return e.model
+}
+
+// Scoring returns a snapshot of the strategy and model results are scored with
+func (e *Engine) Scoring() *ScoringSnapshot {
+	return &ScoringSnapshot{
+		Strategy:   e.strategy.Name,
+		Technical:  e.strategy.Technical,
+		Weights:    e.model.WeightsFor(e.strategy),
+		Indicators: e.strategy.Indicators,
+		Model:      e.model,
+	}
+}
+
+// SetHistoryConfig sets the interval and lookback used by the next scan
+func (e *Engine) SetHistoryConfig(h HistoryConfig) {
+	e.history = h
+}
+
+// GetHistoryConfig returns the history configuration
+func (e *Engine) GetHistoryConfig() HistoryConfig {
+	return e.history
+}
+
+// HistoryOptions returns the fetch options of the next scan, sized for
+// the active strategy's indicators
+func (e *Engine) HistoryOptions() fetcher.HistoryOptions {
+	return e.history.OptionsFor(e.strategy.Indicators.RequiredBars())
+}
+
+// SetRanking enables the ranking pass of the next scans with the given
+// options, or disables it when opts is nil. Ranked scans are filtered once
+// every symbol is in, so results are ranked against the whole scan.
+func (e *Engine) SetRanking(opts *RankOptions) error {
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+	}
+	e.rank = opts
+	return nil
+}
+
+// Ranking returns the ranking options (nil if ranking is off)
+func (e *Engine) Ranking() *RankOptions {
+	return e.rank
+}
+
+// Classifier returns the sector and industry tags of a symbol ("" if untagged)
+type Classifier func(symbol string) (sector, industry string)
+
+// SetClassifier sets where results get their sector and industry from.
+// Tags it returns take precedence over the provider's company profile.
+func (e *Engine) SetClassifier(c Classifier) {
+	e.classify = c
+}
+
+// SetBaseCurrency sets the currency prices are converted to for
+// cross-market ranking; an empty currency disables conversion
+func (e *Engine) SetBaseCurrency(currency string) {
+	e.baseCurrency = strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// BaseCurrency returns the currency prices are converted to ("" if disabled)
+func (e *Engine) BaseCurrency() string {
+	return e.baseCurrency
+}
+
+// FXError returns the exchange rates the last scan couldn't fetch, or nil
+func (e *Engine) FXError() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.fxErr
+}
+
 // SetProgressCallback sets the progress callback
 func (e *Engine) SetProgressCallback(cb func(completed, total int, current string)) {
 	e.onProgress = cb
@@ -88,18 +341,24 @@ func (e *Engine) SetVerboseProgressCallback(cb func(progress ScanProgress)) {
 func (e *Engine) Scan(symbols []string) []*ScreenResult {
 	e.mu.Lock()
 	e.results = make([]*ScreenResult, 0)
+	e.scanned = nil
 	e.progress = ScanProgress{
 		Total: len(symbols),
 	}
+	e.limiterBase, _ = fetcher.LimiterStatsOf(e.pool.Provider())
 	e.mu.Unlock()
 
 	total := len(symbols)
 	completed := 0
+	scanned := make([]*ScreenResult, 0, total) // Every result, for comparisons across the scan
+	// Filters comparing results with each other wait for the whole scan
+	deferFilter := e.rank != nil || e.criteria.NeedsWholeScan()
 
+	e.pool.SetHistory(e.HistoryOptions())
 	resultChan := e.pool.Start(symbols)
 
 	for data := range resultChan {
-		result := CalculateMetrics(data)
+		result := CalculateMetricsWith(data, e.strategy, e.model)
 
 		e.mu.Lock()
 		// Update progress stats
@@ -114,9 +373,11 @@ func (e *Engine) Scan(symbols []string) []*ScreenResult {
 
 		// Mark if pinned in watchlist
 		result.IsPinned = e.watchlist.IsPinned(result.Symbol)
+		e.classifyResult(result)
 
 		// Only add if passes filter (or is pinned)
-		if result.IsPinned || e.passesFilter(result) {
+		scanned = append(scanned, result)
+		if !deferFilter && (result.IsPinned || e.passesFilter(result)) {
 			e.results = append(e.results, result)
 		}
 		e.mu.Unlock()
@@ -125,8 +386,8 @@ func (e *Engine) Scan(symbols []string) []*ScreenResult {
 
 		e.mu.Lock()
 		e.progress.Completed = completed
-		currentProgress := e.progress
 		e.mu.Unlock()
+		currentProgress := e.GetProgress()
 
 		if e.onProgress != nil {
 			e.onProgress(completed, total, data.Symbol)
@@ -136,6 +397,13 @@ func (e *Engine) Scan(symbols []string) []*ScreenResult {
 		}
 	}
 
+	// Convert prices to the base currency, so the comparisons are in one
+	// currency
+	e.applyFXRates(scanned)
+
+	// Compare with the sector and rank against everything scanned
+	e.compareResults(scanned, deferFilter)
+
 	// Add placeholders for watchlist symbols that weren't in scan results
 	e.addWatchlistPlaceholders()
 
@@ -149,7 +417,84 @@ func (e *Engine) Scan(symbols []string) []*ScreenResult {
 func (e *Engine) GetProgress() ScanProgress {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
-	return e.progress
+
+	progress := e.progress
+	if stats, ok := fetcher.LimiterStatsOf(e.pool.Provider()); ok {
+		progress.Limiter = stats.Since(e.limiterBase)
+	}
+	return progress
+}
+
+// classifyResult applies the classifier's tags to a result
+func (e *Engine) classifyResult(r *ScreenResult) {
+	if e.classify == nil {
+		return
+	}
+	sector, industry := e.classify(r.Symbol)
+	if sector != "" {
+		r.Sector = sector
+	}
+	if industry != "" {
+		r.Industry = industry
+	}
+}
+
+// compareResults sets the sector-relative valuation and the ranks of the
+// scanned results and aggregates them by sector. With deferFilter the
+// results are filtered afterwards, so the filter can use these fields.
+func (e *Engine) compareResults(scanned []*ScreenResult, deferFilter bool) {
+	applySectorValuation(scanned, SectorSummary(scanned, nil))
+	if e.rank != nil {
+		RankResults(scanned, *e.rank)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scanned = scanned
+	if deferFilter {
+		for _, r := range scanned {
+			if r.IsPinned || e.passesFilter(r) {
+				e.results = append(e.results, r)
+			}
+		}
+	}
+	e.sectors = SectorSummary(scanned, e.passesFilter)
+}
+
+// applyFXRates sets the FX rate of each scanned result to the base
+// currency. Results in currencies without a rate keep FXRate 0 (native
+// prices).
+func (e *Engine) applyFXRates(scanned []*ScreenResult) {
+	if e.baseCurrency == "" {
+		e.mu.Lock()
+		e.fxErr = nil
+		e.mu.Unlock()
+		return
+	}
+
+	var currencies []string
+	for _, r := range scanned {
+		if r.Currency != "" {
+			currencies = append(currencies, r.Currency)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fxTimeout)
+	defer cancel()
+	rates, err := fetcher.FetchFXRates(ctx, e.pool.Provider(), currencies, e.baseCurrency)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fxErr = err
+	for _, r := range scanned {
+		if r.Currency == "" {
+			continue
+		}
+		if rate, ok := rates[r.Currency]; ok {
+			r.BaseCurrency = e.baseCurrency
+			r.FXRate = rate
+		}
+	}
 }
 
 // addWatchlistPlaceholders adds placeholder results for watchlist symbols not in results
@@ -180,42 +525,7 @@ func (e *Engine) addWatchlistPlaceholders() {
 
 // passesFilter checks if a result meets the filter criteria
 func (e *Engine) passesFilter(r *ScreenResult) bool {
-	if r.HasError {
-		return false
-	}
-
-	// RSI filter
-	if r.RSI < e.criteria.MinRSI || r.RSI > e.criteria.MaxRSI {
-		if r.RSI > 0 { // Only filter if RSI was calculated
-			return false
-		}
-	}
-
-	// PBV filter
-	if r.PBV > e.criteria.MaxPBV && r.PBV > 0 {
-		return false
-	}
-
-	// Graham Upside filter
-	if r.GrahamUpside < e.criteria.MinGrahamUpside {
-		return false
-	}
-
-	// Confluence score filter
-	if r.ConfluenceScore < e.criteria.MinConfluence {
-		return false
-	}
-
-	// Boolean filters
-	if e.criteria.OnlyOversold && !r.IsOversold {
-		return false
-	}
-
-	if e.criteria.OnlyUndervalued && !r.IsUndervalued {
-		return false
-	}
-
-	return true
+	return e.criteria.Matches(r)
 }
 
 // sortResults sorts by pinned status first, then by confluence score
@@ -236,6 +546,13 @@ func (e *Engine) sortResults() {
 	})
 }
 
+// SectorStats returns the sector aggregates of the last scan
+func (e *Engine) SectorStats() []SectorStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.sectors
+}
+
 // GetResults returns current results
 func (e *Engine) GetResults() []*ScreenResult {
 	e.mu.RLock()
@@ -246,6 +563,17 @@ func (e *Engine) GetResults() []*ScreenResult {
 	return results
 }
 
+// Scanned returns every result of the last scan, including those the
+// filter left out
+func (e *Engine) Scanned() []*ScreenResult {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	results := make([]*ScreenResult, len(e.scanned))
+	copy(results, e.scanned)
+	return results
+}
+
 // GetWatchlistManager returns the watchlist manager
 func (e *Engine) GetWatchlistManager() *watchlist.Manager {
 	return e.watchlist
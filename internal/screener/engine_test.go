@@ -104,3 +104,30 @@ type mockStockData struct {
 	historicalHighs  []float64
 	historicalLows   []float64
 }
+
+func TestFilterCriteria_Matches(t *testing.T) {
+	c := DefaultCriteria()
+
+	tests := []struct {
+		name   string
+		result ScreenResult
+		want   bool
+	}{
+		{"passes", ScreenResult{RSI: 30, PBV: 1.2, ConfluenceScore: 60}, true},
+		{"rsi too high", ScreenResult{RSI: 55, PBV: 1.2, ConfluenceScore: 60}, false},
+		{"pbv too high", ScreenResult{RSI: 30, PBV: 3, ConfluenceScore: 60}, false},
+		{"unknown pbv", ScreenResult{RSI: 30, ConfluenceScore: 60}, true},
+		{"low score", ScreenResult{RSI: 30, PBV: 1.2, ConfluenceScore: 40}, false},
+		{"error", ScreenResult{RSI: 30, ConfluenceScore: 60, HasError: true}, false},
+	}
+	for _, tt := range tests {
+		if got := c.Matches(&tt.result); got != tt.want {
+			t.Errorf("%s: Matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	c.OnlyOversold = true
+	if c.Matches(&ScreenResult{RSI: 38, ConfluenceScore: 60}) {
+		t.Error("Expected OnlyOversold to reject a result that isn't oversold")
+	}
+}
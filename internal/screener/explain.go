@@ -0,0 +1,166 @@
+package screener
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Score components a contribution can belong to
+const (
+	ComponentTechnical = "technical"
+	ComponentValuation = "valuation"
+	ComponentRisk      = "risk"
+	ComponentBonus     = "bonus"
+)
+
+// Input is the value of a field a scoring rule tested
+type Input struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"` // nil when unknown
+}
+
+// Contribution is one term of a result's score: the points a scoring table
+// or bonus awarded, e.g. "RSI 28 → +45 technical"
+type Contribution struct {
+	Component string  `json:"component"` // technical, valuation, risk or bonus
+	Name      string  `json:"name"`      // Table or bonus name
+	Rule      string  `json:"rule"`      // Condition that held
+	Inputs    []Input `json:"inputs,omitempty"`
+	Points    float64 `json:"points"`   // Points added to the component (bonuses: to the confluence score)
+	Weighted  float64 `json:"weighted"` // Points added to the confluence score
+}
+
+// String describes the contribution: "RSI 28 → +45 technical" or
+// "Oversold and undervalued bonus +10"
+func (c Contribution) String() string {
+	if c.Component == ComponentBonus {
+		return c.Name + " bonus " + formatPoints(c.Points)
+	}
+
+	text := c.Name
+	if len(c.Inputs) == 1 {
+		text += " " + formatInput(c.Inputs[0].Value)
+	} else if len(c.Inputs) > 1 {
+		parts := make([]string, len(c.Inputs))
+		for i, in := range c.Inputs {
+			parts[i] = in.Field + " " + formatInput(in.Value)
+		}
+		text += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return text + " → " + formatPoints(c.Points) + " " + c.Component
+}
+
+// formatPoints formats points with their sign ("+45", "-2.5")
+func formatPoints(p float64) string {
+	s := strconv.FormatFloat(p, 'f', -1, 64)
+	if p >= 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// formatInput formats a rule input for display; numbers get at most two decimals
+func formatInput(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "unknown"
+	case float64:
+		return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return "?"
+	}
+}
+
+// round4 rounds to 4 decimals to keep JSON output readable
+func round4(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+// explainScore lists the tables and bonuses that awarded points to a
+// result, in the order they are scored. The weighted points of the
+// components add up to the confluence score unless a component or the
+// total was capped.
+func explainScore(r *ScreenResult, m *ScoringModel, s *Strategy) []Contribution {
+	w := m.WeightsFor(s)
+	if !r.HasFundamentals {
+		w.Valuation = 0 // Left out of the score, as in calculateConfluenceScore
+	}
+	total := w.Technical + w.Valuation + w.Risk
+
+	components := []struct {
+		name   string
+		tables []Table
+		share  float64
+	}{
+		{ComponentTechnical, m.Technical[s.Technical], w.Technical / total},
+		{ComponentValuation, m.Valuation, w.Valuation / total},
+		{ComponentRisk, m.Risk, w.Risk / total},
+	}
+
+	var contributions []Contribution
+	for _, c := range components {
+		for i := range c.tables {
+			points, rule := c.tables[i].Score(r)
+			if rule == nil || points == 0 {
+				continue
+			}
+			contributions = append(contributions, Contribution{
+				Component: c.name,
+				Name:      c.tables[i].Name,
+				Rule:      rule.When,
+				Inputs:    rule.inputs(r),
+				Points:    points,
+				Weighted:  round4(points * c.share),
+			})
+		}
+	}
+
+	for i := range m.Bonuses {
+		b := &m.Bonuses[i]
+		if b.Points == 0 || !b.Holds(r) {
+			continue
+		}
+		name := b.Name
+		if name == "" {
+			name = b.When
+		}
+		contributions = append(contributions, Contribution{
+			Component: ComponentBonus,
+			Name:      name,
+			Rule:      b.When,
+			Inputs:    b.inputs(r),
+			Points:    b.Points,
+			Weighted:  b.Points,
+		})
+	}
+	return contributions
+}
+
+// inputs returns the values of the fields a rule tests
+func (r *Rule) inputs(res *ScreenResult) []Input {
+	if r.expr == nil {
+		return nil
+	}
+	fields := r.expr.Fields()
+	inputs := make([]Input, len(fields))
+	for i, f := range fields {
+		v := f.Value(res)
+		switch n := v.(type) {
+		case float64:
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				v = nil
+			} else {
+				v = round4(n)
+			}
+		case int64:
+			v = float64(n)
+		}
+		inputs[i] = Input{Field: f.Name, Value: v}
+	}
+	return inputs
+}
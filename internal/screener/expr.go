@@ -0,0 +1,545 @@
+package screener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled screening expression such as
+//
+//	rsi < 30 and pbv < 1.2 and (macd_crossover == "bullish" or bb_percent_b < 0)
+//
+// Identifiers are result fields by their snake_case name (see Fields).
+// Operators are and/or/not (also &&, ||, !), the comparisons < <= > >= == !=
+// and + - * / on numbers. String equality ignores case. A comparison with an
+// unknown value (a valuation without fundamentals, division by zero) is false.
+type Expr struct {
+	src  string
+	root *node
+}
+
+// ExprError is a syntax or type error at a position of an expression
+type ExprError struct {
+	Pos int // Byte offset of the offending token
+	Msg string
+}
+
+// Error returns the message with its 1-based column
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Caret returns a line pointing at the error position, to print under the expression
+func (e *ExprError) Caret() string {
+	return strings.Repeat(" ", e.Pos) + "^"
+}
+
+// Compile parses and type checks an expression, which must be a condition
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, &ExprError{Pos: 0, Msg: "empty expression"}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s after expression", t)}
+	}
+	if root.kind != KindBool {
+		return nil, &ExprError{Pos: tokens[0].pos, Msg: fmt.Sprintf("expression must be a condition, got a %s", root.kind)}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Fields returns the fields the expression refers to, in order of first use
+func (e *Expr) Fields() []Field {
+	var fields []Field
+	seen := make(map[string]bool)
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil {
+			return
+		}
+		walk(n.left)
+		if n.op == "field" && !seen[n.field.Name] {
+			seen[n.field.Name] = true
+			fields = append(fields, n.field)
+		}
+		walk(n.right)
+	}
+	walk(e.root)
+	return fields
+}
+
+// Match reports whether a result satisfies the expression
+func (e *Expr) Match(r *ScreenResult) bool {
+	v, _ := e.root.eval(r).(bool)
+	return v
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string // Operator or identifier as written, string contents unquoted
+	num  float64
+	pos  int
+}
+
+// String describes the token in error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, &ExprError{Pos: start, Msg: fmt.Sprintf("bad number %q", src[start:i])}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: n, pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, &ExprError{Pos: start, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokString, text: src[i+1 : i+1+end], pos: start})
+			i += end + 2
+
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, o := range []string{"<=", ">=", "==", "!=", "&&", "||", "<", ">", "=", "!", "+", "-", "*", "/", "(", ")"} {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &ExprError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", src[i])}
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// node is a type checked expression tree node
+type node struct {
+	op          string // "field", "const", or the operator
+	pos         int
+	kind        FieldKind
+	field       Field
+	value       interface{} // Constant value
+	left, right *node
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports whether the next token is one of the given operators or
+// (case insensitive) keywords
+func (p *parser) keyword(words ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return t, false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return t, true
+		}
+	}
+	return t, false
+}
+
+// parseOr: and ("or" and)*
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.keyword("or", "||")
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical("or", t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseAnd: not ("and" not)*
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.keyword("and", "&&")
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical("and", t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// logical type checks an and/or node
+func logical(op string, t token, left, right *node) (*node, error) {
+	for _, n := range []*node{left, right} {
+		if n.kind != KindBool {
+			return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("'%s' needs conditions on both sides, got a %s", op, n.kind)}
+		}
+	}
+	return &node{op: op, pos: t.pos, kind: KindBool, left: left, right: right}, nil
+}
+
+// parseNot: "not" not | comparison
+func (p *parser) parseNot() (*node, error) {
+	t, ok := p.keyword("not", "!")
+	if !ok {
+		return p.parseComparison()
+	}
+	p.next()
+	operand, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind != KindBool {
+		return nil, &ExprError{Pos: operand.pos, Msg: fmt.Sprintf("'not' needs a condition, got a %s", operand.kind)}
+	}
+	return &node{op: "not", pos: t.pos, kind: KindBool, left: operand}, nil
+}
+
+// parseComparison: sum (compare-op sum)?
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.keyword("<", "<=", ">", ">=", "==", "=", "!=")
+	if !ok {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	op := t.text
+	if op == "=" {
+		op = "=="
+	}
+	if left.kind != right.kind {
+		return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("cannot compare a %s with a %s", left.kind, right.kind)}
+	}
+	if left.kind == KindBool && op != "==" && op != "!=" {
+		return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("'%s' doesn't apply to conditions", op)}
+	}
+	if _, chained := p.keyword("<", "<=", ">", ">=", "==", "=", "!="); chained {
+		return nil, &ExprError{Pos: p.peek().pos, Msg: "comparisons can't be chained; use 'and'"}
+	}
+	return &node{op: op, pos: t.pos, kind: KindBool, left: left, right: right}, nil
+}
+
+// parseSum: product (("+" | "-") product)*
+func (p *parser) parseSum() (*node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.keyword("+", "-")
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseProduct: unary (("*" | "/") unary)*
+func (p *parser) parseProduct() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.keyword("*", "/")
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// arithmetic type checks a + - * / node
+func arithmetic(t token, left, right *node) (*node, error) {
+	for _, n := range []*node{left, right} {
+		if n.kind != KindNumber {
+			return nil, &ExprError{Pos: n.pos, Msg: fmt.Sprintf("'%s' needs numbers, got a %s", t.text, n.kind)}
+		}
+	}
+	return &node{op: t.text, pos: t.pos, kind: KindNumber, left: left, right: right}, nil
+}
+
+// parseUnary: "-" unary | primary
+func (p *parser) parseUnary() (*node, error) {
+	t, ok := p.keyword("-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind != KindNumber {
+		return nil, &ExprError{Pos: operand.pos, Msg: fmt.Sprintf("'-' needs a number, got a %s", operand.kind)}
+	}
+	zero := &node{op: "const", pos: t.pos, kind: KindNumber, value: 0.0}
+	return &node{op: "-", pos: t.pos, kind: KindNumber, left: zero, right: operand}, nil
+}
+
+// parsePrimary: number | string | true | false | field | "(" or ")"
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &node{op: "const", pos: t.pos, kind: KindNumber, value: t.num}, nil
+	case tokString:
+		return &node{op: "const", pos: t.pos, kind: KindString, value: t.text}, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true", "false":
+			return &node{op: "const", pos: t.pos, kind: KindBool, value: strings.EqualFold(t.text, "true")}, nil
+		case "and", "or", "not":
+			return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("expected a value, got %s", t)}
+		}
+		f, ok := LookupField(t.text)
+		if !ok {
+			msg := fmt.Sprintf("unknown field %q", t.text)
+			if s := suggestField(t.text); s != "" {
+				msg += fmt.Sprintf(" (did you mean %s?)", s)
+			}
+			return nil, &ExprError{Pos: t.pos, Msg: msg}
+		}
+		return &node{op: "field", pos: t.pos, kind: f.Kind, field: f}, nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); closing.text != ")" || closing.kind != tokOp {
+				return nil, &ExprError{Pos: closing.pos, Msg: fmt.Sprintf("expected ')' to close '(' at column %d, got %s", t.pos+1, closing)}
+			}
+			return inner, nil
+		}
+	}
+	return nil, &ExprError{Pos: t.pos, Msg: fmt.Sprintf("expected a value, got %s", t)}
+}
+
+// suggestField returns the field name closest to a misspelled one, if any is close
+func suggestField(name string) string {
+	name = strings.ToLower(name)
+	best, bestDist := "", 3
+	for _, f := range FieldNames() {
+		if d := editDistance(name, f); d < bestDist {
+			best, bestDist = f, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// eval evaluates the node; numbers are float64 and unknown values nil
+func (n *node) eval(r *ScreenResult) interface{} {
+	switch n.op {
+	case "const":
+		return n.value
+	case "field":
+		switch v := n.field.Value(r).(type) {
+		case int64:
+			return float64(v)
+		default:
+			return v
+		}
+	case "and":
+		return n.left.eval(r) == true && n.right.eval(r) == true
+	case "or":
+		return n.left.eval(r) == true || n.right.eval(r) == true
+	case "not":
+		return n.left.eval(r) != true
+	case "+", "-", "*", "/":
+		a, ok1 := n.left.eval(r).(float64)
+		b, ok2 := n.right.eval(r).(float64)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		switch n.op {
+		case "+":
+			return a + b
+		case "-":
+			return a - b
+		case "*":
+			return a * b
+		default:
+			if b == 0 {
+				return nil
+			}
+			return a / b
+		}
+	default:
+		return compareValues(n.op, n.left.eval(r), n.right.eval(r))
+	}
+}
+
+// compareValues applies a comparison operator; false if either side is unknown
+func compareValues(op string, a, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	var cmp int
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	case string:
+		cmp = strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case bool:
+		if a != b.(bool) {
+			cmp = 1
+		}
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "==":
+		return cmp == 0
+	default:
+		return cmp != 0
+	}
+}
@@ -0,0 +1,168 @@
+package screener
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Symbol":          "symbol",
+		"PERatio":         "pe_ratio",
+		"SMA20":           "sma20",
+		"BBPercentB":      "bb_percent_b",
+		"ConfluenceScore": "confluence_score",
+		"FXRate":          "fx_rate",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	for name, kind := range map[string]FieldKind{"rsi": KindNumber, "volume": KindNumber, "macd_crossover": KindString, "is_oversold": KindBool, "grade": KindString} {
+		f, ok := LookupField(name)
+		if !ok || f.Kind != kind {
+			t.Errorf("LookupField(%q) = %+v, %v; want kind %s", name, f, ok, kind)
+		}
+	}
+	if _, ok := LookupField("historical_prices"); ok {
+		t.Error("Slices should not be fields")
+	}
+
+	f, _ := LookupField("pbv")
+	if v := f.Value(&ScreenResult{}); v != nil {
+		t.Errorf("Expected unknown PBV without fundamentals, got %v", v)
+	}
+}
+
+func TestExpr_Match(t *testing.T) {
+	r := &ScreenResult{
+		Symbol: "AAPL", RSI: 28, PBV: 1.1, HasFundamentals: true, Price: 100, GrahamNumber: 150,
+		MACDCrossover: "bullish", BBPercentB: 0.3, Volume: 2000000, IsOversold: true,
+	}
+
+	tests := map[string]bool{
+		`rsi < 30 and pbv < 1.2 and (macd_crossover == "bullish" or bb_percent_b < 0)`: true,
+		`rsi < 30 and pbv < 1.2 and (macd_crossover == "bearish" or bb_percent_b < 0)`: false,
+		`RSI<=28 && PBV>=1.1`:              true,
+		`not is_oversold`:                  false,
+		`!(rsi > 50) || false`:             true,
+		`is_oversold == true`:              true,
+		`macd_crossover = 'BULLISH'`:       true,
+		`symbol != "MSFT"`:                 true,
+		`price < graham_number * 0.8`:      true,
+		`price / 0 > 1`:                    false, // Unknown
+		`volume > 1000000 and -rsi < -20`:  true,
+		`graham_number - price >= 50`:      true,
+		`rsi > 20 and rsi < 30 or pbv > 5`: true,
+		`grade == "F" or grade != "F"`:     true,
+	}
+	for src, want := range tests {
+		e, err := Compile(src)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", src, err)
+			continue
+		}
+		if got := e.Match(r); got != want {
+			t.Errorf("%s = %v, want %v", src, got, want)
+		}
+	}
+
+	// Comparisons with unknown valuation are false
+	e, _ := Compile("pbv < 1.2")
+	if e.Match(&ScreenResult{RSI: 28}) {
+		t.Error("Expected no match on unknown PBV")
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		pos  int
+		want string
+	}{
+		{"", 0, "empty"},
+		{"rsi < 30 and", 12, "expected a value"},
+		{"rssi < 30", 0, "did you mean rsi"},
+		{"rsi < \"low\"", 4, "cannot compare a number with a string"},
+		{"rsi + 1", 0, "must be a condition"},
+		{"rsi < 30 and pbv", 13, "conditions on both sides"},
+		{"(rsi < 30", 9, "expected ')'"},
+		{"rsi < 30 )", 9, "unexpected ')'"},
+		{"symbol == 'AAPL", 10, "unterminated string"},
+		{"rsi # 3", 4, "unexpected character"},
+		{"1 < rsi < 30", 8, "can't be chained"},
+		{"is_oversold < true", 12, "doesn't apply"},
+		{"macd_crossover * 2 > 1", 0, "needs numbers"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Compile(%q): expected an ExprError, got %v", tt.src, err)
+			continue
+		}
+		if exprErr.Pos != tt.pos || !strings.Contains(exprErr.Msg, tt.want) {
+			t.Errorf("Compile(%q) = %q at %d; want %q at %d", tt.src, exprErr.Msg, exprErr.Pos, tt.want, tt.pos)
+		}
+	}
+
+	_, err := Compile("rsi < 30 and")
+	if got := err.(*ExprError).Caret(); got != strings.Repeat(" ", 12)+"^" {
+		t.Errorf("Unexpected caret %q", got)
+	}
+}
+
+func TestCriteria_Expression(t *testing.T) {
+	e, err := Compile("macd_crossover == 'bullish'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := DefaultCriteria()
+	c.Expression = e
+
+	if !c.Matches(&ScreenResult{RSI: 30, ConfluenceScore: 60, MACDCrossover: "bullish"}) {
+		t.Error("Expected a match")
+	}
+	if c.Matches(&ScreenResult{RSI: 30, ConfluenceScore: 60, MACDCrossover: "none"}) {
+		t.Error("Expected the expression to reject the result")
+	}
+}
+
+func TestScreens(t *testing.T) {
+	cleanup()
+	defer cleanup()
+
+	if screens, err := LoadScreens(); err != nil || len(screens) != 0 {
+		t.Fatalf("Expected no screens, got %v, %v", screens, err)
+	}
+
+	if err := SaveScreen(Screen{Name: "deep", Expression: "pbv < 1 and rsi < 30"}); err != nil {
+		t.Fatalf("SaveScreen failed: %v", err)
+	}
+	if err := SaveScreen(Screen{Name: "broken", Expression: "pbv <"}); err == nil {
+		t.Error("Expected an invalid expression to be rejected")
+	}
+	if err := SaveScreen(Screen{Name: "Deep", Expression: "pbv < 1.5"}); err != nil {
+		t.Fatalf("SaveScreen failed: %v", err)
+	}
+
+	s, err := LookupScreen("DEEP")
+	if err != nil || s.Expression != "pbv < 1.5" {
+		t.Errorf("Expected the replaced screen, got %+v, %v", s, err)
+	}
+	if _, err := LookupScreen("nope"); err == nil || !strings.Contains(err.Error(), "Deep") {
+		t.Errorf("Expected an error listing the screens, got %v", err)
+	}
+
+	if err := DeleteScreen("deep"); err != nil {
+		t.Fatalf("DeleteScreen failed: %v", err)
+	}
+	if err := DeleteScreen("deep"); err == nil {
+		t.Error("Expected an error deleting a missing screen")
+	}
+}
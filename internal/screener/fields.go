@@ -0,0 +1,180 @@
+package screener
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FieldKind is the type of a result field
+type FieldKind int
+
+const (
+	KindNumber FieldKind = iota
+	KindString
+	KindBool
+)
+
+// String returns the kind's name as used in error messages
+func (k FieldKind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	default:
+		return "number"
+	}
+}
+
+// Field is a named scalar value of a ScreenResult, addressed by its
+// snake_case name in scan output columns and screening expressions
+type Field struct {
+	Name  string
+	Kind  FieldKind
+	value func(r *ScreenResult) interface{}
+}
+
+// Value returns the field's value for a result: a string, bool, int64 or
+// float64, or nil when the value is unknown
+func (f Field) Value(r *ScreenResult) interface{} {
+	return f.value(r)
+}
+
+// valuationFields are 0 when fundamentals are missing; they are reported as
+// unknown rather than as zero (see ScreenResult.HasFundamentals)
+var valuationFields = map[string]bool{
+	"PBV": true, "PERatio": true, "EPS": true, "BookValue": true,
+	"GrahamNumber": true, "GrahamUpside": true, "DividendYield": true,
+}
+
+// sectorFields are 0 when the sector median they compare with is unknown
+var sectorFields = map[string]bool{"RelativePBV": true, "RelativePE": true}
+
+// rankedFields are set by the ranking pass; they are reported as unknown
+// for unranked results (see RankResults)
+var rankedFields = map[string]bool{"RankScore": true}
+
+// computedFields are derived values that aren't ScreenResult fields
+var computedFields = []Field{
+	{Name: "grade", Kind: KindString, value: func(r *ScreenResult) interface{} {
+		return ScoreToGrade(r.ConfluenceScore)
+	}},
+	{Name: "base_price", Kind: KindNumber, value: func(r *ScreenResult) interface{} {
+		if r.FXRate <= 0 {
+			return nil
+		}
+		return r.BasePrice()
+	}},
+	{Name: "data_quality", Kind: KindString, value: func(r *ScreenResult) interface{} {
+		if r.Quality.OK() {
+			return "ok"
+		}
+		return strings.Join(r.Quality.Issues(), "; ")
+	}},
+}
+
+// Fields returns every field: the scalar ScreenResult fields in declaration
+// order followed by the computed ones (grade, base_price, data_quality and
+// the metric ranks such as rsi_pct and rsi_z)
+func Fields() []Field {
+	var fields []Field
+	t := reflect.TypeOf(ScreenResult{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if kind, ok := fieldKind(f.Type.Kind()); ok {
+			fields = append(fields, structField(f, kind))
+		}
+	}
+	fields = append(fields, computedFields...)
+	return append(fields, rankFields()...)
+}
+
+// LookupField returns the field with the given name (case insensitive)
+func LookupField(name string) (Field, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, f := range Fields() {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// FieldNames returns the names of all fields, sorted
+func FieldNames() []string {
+	fields := Fields()
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldKind maps a scalar Go kind to a field kind
+func fieldKind(k reflect.Kind) (FieldKind, bool) {
+	switch k {
+	case reflect.String:
+		return KindString, true
+	case reflect.Bool:
+		return KindBool, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		return KindNumber, true
+	}
+	return 0, false
+}
+
+// structField returns the field reading a ScreenResult struct field
+func structField(f reflect.StructField, kind FieldKind) Field {
+	index := f.Index
+	valuation := valuationFields[f.Name]
+	ranked := rankedFields[f.Name]
+	sector := sectorFields[f.Name]
+	return Field{
+		Name: snakeCase(f.Name),
+		Kind: kind,
+		value: func(r *ScreenResult) interface{} {
+			v := reflect.ValueOf(r).Elem().FieldByIndex(index)
+			switch v.Kind() {
+			case reflect.String:
+				return v.String()
+			case reflect.Bool:
+				return v.Bool()
+			case reflect.Float32, reflect.Float64:
+				if valuation && !r.HasFundamentals && v.Float() == 0 {
+					return nil
+				}
+				if ranked && r.Ranks == nil || sector && v.Float() == 0 {
+					return nil
+				}
+				return v.Float()
+			default:
+				return v.Int()
+			}
+		},
+	}
+}
+
+// snakeCase converts a Go field name to snake_case ("PERatio" -> "pe_ratio",
+// "SMA20" -> "sma20", "BBPercentB" -> "bb_percent_b")
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,236 @@
+package screener
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// Rule awards points when its condition holds
+type Rule struct {
+	Name   string  `yaml:"name,omitempty" json:"name,omitempty"`
+	When   string  `yaml:"when" json:"when"` // Screening expression (see Compile)
+	Points float64 `yaml:"points" json:"points"`
+
+	expr *Expr
+}
+
+// Table is a threshold table: it awards the points of the first rule that holds
+type Table struct {
+	Name  string `yaml:"name" json:"name"`
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// ScoringModel defines how the component scores and the confluence score
+// are computed: weights, threshold tables per component and bonus rules
+type ScoringModel struct {
+	Name      string             `yaml:"name" json:"name"`
+	Weights   Weights            `yaml:"weights" json:"weights"`
+	Technical map[string][]Table `yaml:"technical" json:"technical"` // By technical style
+	Valuation []Table            `yaml:"valuation" json:"valuation"`
+	Risk      []Table            `yaml:"risk" json:"risk"`
+	Bonuses   []Rule             `yaml:"bonuses" json:"bonuses"` // Every bonus that holds adds its points
+}
+
+// maxComponentScore is the most a component can score
+const maxComponentScore = 100
+
+// Validate checks the model and compiles its rules
+func (m *ScoringModel) Validate() error {
+	w := m.Weights
+	if w.Technical < 0 || w.Valuation < 0 || w.Risk < 0 || w.Technical+w.Risk <= 0 {
+		return fmt.Errorf("weights must not be negative and technical+risk must be positive")
+	}
+
+	for style := range m.Technical {
+		if !validStyle(style) {
+			return fmt.Errorf("technical: unknown style %q (available: %s)", style, strings.Join(technicalStyles, ", "))
+		}
+	}
+	for _, style := range technicalStyles {
+		if len(m.Technical[style]) == 0 {
+			return fmt.Errorf("technical: no tables for style %s", style)
+		}
+		if err := compileTables("technical."+style, m.Technical[style]); err != nil {
+			return err
+		}
+	}
+	if err := compileTables("valuation", m.Valuation); err != nil {
+		return err
+	}
+	if err := compileTables("risk", m.Risk); err != nil {
+		return err
+	}
+	for i := range m.Bonuses {
+		if err := m.Bonuses[i].compile(); err != nil {
+			return fmt.Errorf("bonuses: %v", err)
+		}
+	}
+	return nil
+}
+
+// compileTables compiles the rules of a component's tables and checks that
+// the component can't score more than maxComponentScore
+func compileTables(component string, tables []Table) error {
+	max := 0.0
+	for ti := range tables {
+		t := &tables[ti]
+		if strings.TrimSpace(t.Name) == "" {
+			return fmt.Errorf("%s: table %d needs a name", component, ti+1)
+		}
+		if len(t.Rules) == 0 {
+			return fmt.Errorf("%s: table %s has no rules", component, t.Name)
+		}
+		best := 0.0
+		for i := range t.Rules {
+			if err := t.Rules[i].compile(); err != nil {
+				return fmt.Errorf("%s: table %s: %v", component, t.Name, err)
+			}
+			best = math.Max(best, t.Rules[i].Points)
+		}
+		max += best
+	}
+	if max > maxComponentScore {
+		return fmt.Errorf("%s: tables can award up to %.0f points (max %d)", component, max, maxComponentScore)
+	}
+	return nil
+}
+
+// compile checks a rule's points and compiles its condition
+func (r *Rule) compile() error {
+	if math.IsNaN(r.Points) || math.IsInf(r.Points, 0) {
+		return fmt.Errorf("rule %q: points must be a number", r.When)
+	}
+	e, err := Compile(r.When)
+	if err != nil {
+		return fmt.Errorf("rule %q: %v", r.When, err)
+	}
+	r.expr = e
+	return nil
+}
+
+// Holds reports whether the rule's condition holds for a result
+func (r *Rule) Holds(res *ScreenResult) bool {
+	return r.expr != nil && r.expr.Match(res)
+}
+
+// Score returns the points of the first rule that holds, and that rule
+// (nil if none holds)
+func (t *Table) Score(res *ScreenResult) (float64, *Rule) {
+	for i := range t.Rules {
+		if t.Rules[i].Holds(res) {
+			return t.Rules[i].Points, &t.Rules[i]
+		}
+	}
+	return 0, nil
+}
+
+// componentScore sums a component's tables, clamped to 0-100
+func componentScore(tables []Table, r *ScreenResult) float64 {
+	var score float64
+	for i := range tables {
+		points, _ := tables[i].Score(r)
+		score += points
+	}
+	return math.Max(0, math.Min(score, maxComponentScore))
+}
+
+// WeightsFor returns the weights a strategy is scored with: its own, or
+// the model's when it has none
+func (m *ScoringModel) WeightsFor(s *Strategy) Weights {
+	if s != nil && s.Weights != nil {
+		return *s.Weights
+	}
+	return m.Weights
+}
+
+// ScoringSnapshot records how a scan was scored, so saved scores stay
+// explainable after the model or a strategy is tuned
+type ScoringSnapshot struct {
+	Strategy   string        `json:"strategy"`
+	Technical  string        `json:"technical"`
+	Weights    Weights       `json:"weights"`
+	Indicators Indicators    `json:"indicators"`
+	Model      *ScoringModel `json:"model"`
+}
+
+// validStyle reports whether a technical style exists
+func validStyle(style string) bool {
+	for _, t := range technicalStyles {
+		if style == t {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	modelOnce    sync.Once
+	builtinModel *ScoringModel
+	activeModel  *ScoringModel
+	modelLoadErr error
+)
+
+// BuiltinScoringModel returns the scoring model embedded in the binary
+func BuiltinScoringModel() *ScoringModel {
+	loadScoringModels()
+	return builtinModel
+}
+
+// DefaultScoringModel returns the builtin model with the sections of
+// config/scoring.yaml applied, or the builtin model if that file is invalid
+func DefaultScoringModel() *ScoringModel {
+	loadScoringModels()
+	return activeModel
+}
+
+// ScoringModelLoadError returns the error, if any, from reading config/scoring.yaml
+func ScoringModelLoadError() error {
+	loadScoringModels()
+	return modelLoadErr
+}
+
+// loadScoringModels parses the builtin model and the config overrides once
+func loadScoringModels() {
+	modelOnce.Do(func() {
+		data, _ := builtinData.ReadFile("data/scoring.yaml")
+		builtinModel, modelLoadErr = ParseScoringModel(data, nil)
+		activeModel = builtinModel
+
+		path := config.Path("scoring.yaml")
+		user, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		m, err := ParseScoringModel(user, data)
+		if err != nil {
+			modelLoadErr = fmt.Errorf("%s: %v", path, err)
+			return
+		}
+		activeModel = m
+	})
+}
+
+// ParseScoringModel reads and validates a scoring model. Sections missing
+// from data are taken from base, if given.
+func ParseScoringModel(data, base []byte) (*ScoringModel, error) {
+	m := &ScoringModel{}
+	if base != nil {
+		if err := yaml.Unmarshal(base, m); err != nil {
+			return nil, err
+		}
+	}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,148 @@
+package screener
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScoringModel_Builtin(t *testing.T) {
+	m := BuiltinScoringModel()
+	if m == nil {
+		t.Fatalf("Builtin scoring model failed to load: %v", ScoringModelLoadError())
+	}
+	if m.Weights != (Weights{Technical: 0.3, Valuation: 0.4, Risk: 0.3}) {
+		t.Errorf("Unexpected builtin weights: %+v", m.Weights)
+	}
+
+	r := &ScreenResult{
+		Price: 90, SMA20: 100, RSI: 28, RiskRatio: 2, Volatility: 35,
+		HasFundamentals: true, PBV: 0.8, GrahamUpside: 40, PERatio: 12,
+		IsOversold: true, IsUndervalued: true,
+	}
+	if got := calculateTechnicalScore(r, m, TechnicalValue); got != 45+20+15 {
+		t.Errorf("Technical score = %.0f, want 80", got)
+	}
+	if got := calculateValuationScore(r, m); got != 30+30+25 {
+		t.Errorf("Valuation score = %.0f, want 85", got)
+	}
+	if got := calculateRiskAdjustedScore(r, m); got != 60 {
+		t.Errorf("Risk score = %.0f, want 60", got)
+	}
+
+	r.TechnicalScore, r.ValuationScore, r.RiskScore = 80, 85, 60
+	// 0.3*80 + 0.4*85 + 0.3*60 = 76, plus 10 (oversold and undervalued) and 5 (below book)
+	if got := calculateConfluenceScore(r, m, m.Weights); got != 91 {
+		t.Errorf("Confluence score = %.1f, want 91", got)
+	}
+}
+
+func TestParseScoringModel(t *testing.T) {
+	base, _ := builtinData.ReadFile("data/scoring.yaml")
+
+	// Sections given replace the builtin ones; the rest is kept
+	m, err := ParseScoringModel([]byte(`
+weights: {technical: 0.5, valuation: 0.2, risk: 0.3}
+technical:
+  momentum:
+    - name: Trend
+      rules: [{when: price > sma50, points: 100}]
+bonuses: []
+`), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Weights.Technical != 0.5 || len(m.Technical[TechnicalMomentum]) != 1 || len(m.Technical[TechnicalValue]) != 3 || len(m.Bonuses) != 0 || len(m.Valuation) != 3 {
+		t.Errorf("Unexpected merged model: %+v", m)
+	}
+
+	for src, want := range map[string]string{
+		`weights: {technical: 0, valuation: 1, risk: 0}`:                                                         "weights",
+		`technical: {scalping: [{name: X, rules: [{when: "true", points: 1}]}]}`:                                 "unknown style",
+		`valuation: [{name: P/B, rules: []}]`:                                                                    "no rules",
+		`valuation: [{rules: [{when: "true", points: 1}]}]`:                                                      "needs a name",
+		`risk: [{name: A, rules: [{when: "true", points: 80}]}, {name: B, rules: [{when: "true", points: 30}]}]`: "up to 110",
+		`bonuses: [{when: "pbv <", points: 5}]`:                                                                  "expected a value",
+		`bonuses: [{when: "rsi + 1", points: 5}]`:                                                                "must be a condition",
+	} {
+		if _, err := ParseScoringModel([]byte(src), base); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseScoringModel(%q) = %v, want an error containing %q", src, err, want)
+		}
+	}
+
+	// Without a base every technical style needs tables
+	if _, err := ParseScoringModel([]byte(`weights: {technical: 1, risk: 1}`), nil); err == nil || !strings.Contains(err.Error(), "no tables") {
+		t.Errorf("Expected missing technical tables to be rejected, got %v", err)
+	}
+}
+
+func TestEngine_Scoring(t *testing.T) {
+	e := newEngine(nil)
+	if err := e.SetScoringModel(&ScoringModel{Weights: Weights{Technical: -1}}); err == nil {
+		t.Error("Expected an invalid model to be rejected")
+	}
+
+	momentum, _ := LookupStrategy("Momentum")
+	e.SetStrategy(momentum)
+	snap := e.Scoring()
+	if snap.Strategy != "Momentum" || snap.Weights != *momentum.Weights || snap.Model != BuiltinScoringModel() {
+		t.Errorf("Unexpected snapshot: %+v", snap)
+	}
+
+	// The snapshot survives a round trip through scan history
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back ScoringSnapshot
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Model.Technical[TechnicalMomentum][0].Rules[0].When != snap.Model.Technical[TechnicalMomentum][0].Rules[0].When {
+		t.Error("Expected the model's rules to be recorded")
+	}
+}
+
+func TestExplainScore(t *testing.T) {
+	m := BuiltinScoringModel()
+	r := &ScreenResult{
+		Price: 90, SMA20: 100, RSI: 28, RiskRatio: 2, Volatility: 35,
+		HasFundamentals: true, PBV: 0.8, GrahamUpside: 40, PERatio: 12,
+		IsOversold: true, IsUndervalued: true,
+	}
+	r.TechnicalScore = calculateTechnicalScore(r, m, TechnicalValue)
+	r.ValuationScore = calculateValuationScore(r, m)
+	r.RiskScore = calculateRiskAdjustedScore(r, m)
+	r.ConfluenceScore = calculateConfluenceScore(r, m, m.Weights)
+
+	list := explainScore(r, m, DefaultStrategy())
+	var sum float64
+	for _, c := range list {
+		sum += c.Weighted
+	}
+	if math.Abs(sum-r.ConfluenceScore) > 0.01 {
+		t.Errorf("Contributions add up to %.2f, want %.2f: %v", sum, r.ConfluenceScore, list)
+	}
+
+	if got := list[0].String(); got != "RSI 28 → +45 technical" {
+		t.Errorf("First contribution = %q", got)
+	}
+	if got := list[1].String(); got != "Discount to SMA20 (sma20 100, price 90) → +20 technical" {
+		t.Errorf("Second contribution = %q", got)
+	}
+	if got := list[len(list)-1].String(); got != "Below book value bonus +5" {
+		t.Errorf("Last contribution = %q", got)
+	}
+
+	// Without fundamentals valuation contributes nothing and the rest is reweighted
+	r.HasFundamentals = false
+	for _, c := range explainScore(r, m, DefaultStrategy()) {
+		if c.Component == ComponentTechnical && c.Name == "RSI" && c.Weighted != 22.5 {
+			t.Errorf("Expected RSI to add 45 * 0.5 without fundamentals, got %v", c.Weighted)
+		}
+		if c.Component == ComponentValuation && c.Weighted != 0 {
+			t.Errorf("Expected no weighted valuation without fundamentals, got %+v", c)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package screener
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Ranked metrics
+const (
+	MetricRSI        = "rsi"
+	MetricPBV        = "pbv"
+	MetricVolatility = "volatility"
+	MetricMomentum   = "momentum"
+)
+
+// rankMetric reads a ranked metric; ok is false when the result has no value
+type rankMetric struct {
+	name  string
+	value func(r *ScreenResult) (v float64, ok bool)
+}
+
+// rankMetrics are the metrics ranked across a scan. As in the filter
+// criteria, 0 means the metric couldn't be calculated.
+var rankMetrics = []rankMetric{
+	{MetricRSI, func(r *ScreenResult) (float64, bool) { return r.RSI, r.RSI > 0 }},
+	{MetricPBV, func(r *ScreenResult) (float64, bool) { return r.PBV, r.HasFundamentals && r.PBV > 0 }},
+	{MetricVolatility, func(r *ScreenResult) (float64, bool) { return r.Volatility, r.Volatility > 0 }},
+	{MetricMomentum, func(r *ScreenResult) (float64, bool) { return r.Momentum, r.Momentum != 0 }},
+}
+
+// RankMetrics returns the names of the ranked metrics
+func RankMetrics() []string {
+	names := make([]string, len(rankMetrics))
+	for i, m := range rankMetrics {
+		names[i] = m.name
+	}
+	return names
+}
+
+// Rank is a result's standing on one metric within its peer group
+type Rank struct {
+	Percentile float64 // Share of peers with a lower value, ties counting half (0-100)
+	ZScore     float64 // Standard deviations from the peer group mean
+}
+
+// topDecile is the rank score percentile from which a result is in the top decile
+const topDecile = 90
+
+// RankOptions configures the ranking pass of a scan
+type RankOptions struct {
+	// BySector ranks each result against the results of its sector
+	// instead of the whole scan; untagged results form one group
+	BySector bool
+	// Weights of the metric percentiles in the rank score. A negative weight
+	// means lower is better (e.g. pbv -1 favours cheap stocks).
+	Weights map[string]float64
+}
+
+// DefaultRankWeights favours low RSI, P/B and volatility and strong momentum
+func DefaultRankWeights() map[string]float64 {
+	return map[string]float64{
+		MetricRSI:        -1,
+		MetricPBV:        -1,
+		MetricVolatility: -1,
+		MetricMomentum:   1,
+	}
+}
+
+// String describes the options, e.g. "-rsi -2×pbv +momentum within sectors"
+func (o RankOptions) String() string {
+	var parts []string
+	for _, m := range rankMetrics {
+		w, ok := o.Weights[m.name]
+		if !ok || w == 0 {
+			continue
+		}
+		sign := "+"
+		if w < 0 {
+			sign = "-"
+		}
+		if math.Abs(w) != 1 {
+			sign += strconv.FormatFloat(math.Abs(w), 'f', -1, 64) + "×"
+		}
+		parts = append(parts, sign+m.name)
+	}
+	if o.BySector {
+		return strings.Join(parts, " ") + " within sectors"
+	}
+	return strings.Join(parts, " ") + " across the scan"
+}
+
+// DefaultRankOptions ranks against the whole scan with the default weights
+func DefaultRankOptions() RankOptions {
+	return RankOptions{Weights: DefaultRankWeights()}
+}
+
+// Validate checks the rank weights
+func (o RankOptions) Validate() error {
+	total := 0.0
+	for name, w := range o.Weights {
+		if !validMetric(name) {
+			return fmt.Errorf("unknown rank metric %q (available: %s)", name, strings.Join(RankMetrics(), ", "))
+		}
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("rank weight of %s must be a number", name)
+		}
+		total += math.Abs(w)
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one rank weight must be non-zero")
+	}
+	return nil
+}
+
+// validMetric reports whether a ranked metric exists
+func validMetric(name string) bool {
+	for _, m := range rankMetrics {
+		if m.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RankResults sets the ranks, rank score and top decile flag of each result
+// relative to the other results (or, with BySector, to its sector's).
+// Results with errors are left unranked.
+func RankResults(results []*ScreenResult, opts RankOptions) {
+	groups := make(map[string][]*ScreenResult)
+	var keys []string
+	for _, r := range results {
+		r.Ranks, r.RankGroup, r.RankScore, r.TopDecile = nil, "", 0, false
+		if r.HasError {
+			continue
+		}
+		key := ""
+		if opts.BySector {
+			key = r.Sector
+			if key == "" {
+				key = UntaggedSector
+			}
+		}
+		r.RankGroup = key
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+
+	for _, key := range keys {
+		rankGroup(groups[key], opts.Weights)
+	}
+}
+
+// rankGroup ranks a peer group on every metric, then on the rank score
+func rankGroup(group []*ScreenResult, weights map[string]float64) {
+	for _, m := range rankMetrics {
+		var peers []*ScreenResult
+		var values []float64
+		for _, r := range group {
+			if v, ok := m.value(r); ok {
+				peers = append(peers, r)
+				values = append(values, v)
+			}
+		}
+
+		mean, std := meanStdDev(values)
+		for i, r := range peers {
+			z := 0.0
+			if std > 0 {
+				z = (values[i] - mean) / std
+			}
+			if r.Ranks == nil {
+				r.Ranks = make(map[string]Rank)
+			}
+			r.Ranks[m.name] = Rank{Percentile: round4(percentile(values, values[i])), ZScore: round4(z)}
+		}
+	}
+
+	var scored []*ScreenResult
+	var scores []float64
+	for _, r := range group {
+		if score, ok := rankScore(r, weights); ok {
+			r.RankScore = round4(score)
+			scored = append(scored, r)
+			scores = append(scores, r.RankScore)
+		}
+	}
+	for _, r := range scored {
+		r.TopDecile = percentile(scores, r.RankScore) >= topDecile
+	}
+}
+
+// rankScore averages the metric percentiles by weight, inverting those with
+// a negative weight; metrics the result lacks are left out
+func rankScore(r *ScreenResult, weights map[string]float64) (float64, bool) {
+	var sum, total float64
+	for _, m := range rankMetrics {
+		w := weights[m.name]
+		rank, ok := r.Ranks[m.name]
+		if !ok || w == 0 {
+			continue
+		}
+		p := rank.Percentile
+		if w < 0 {
+			p = 100 - p
+		}
+		sum += p * math.Abs(w)
+		total += math.Abs(w)
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return sum / total, true
+}
+
+// percentile returns the percentile rank of v among values: the share of
+// values below it, counting equal values half
+func percentile(values []float64, v float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var below, equal int
+	for _, x := range values {
+		if x < v {
+			below++
+		} else if x == v {
+			equal++
+		}
+	}
+	return (float64(below) + float64(equal)/2) / float64(len(values)) * 100
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// rankFields are the per-metric percentile and z-score fields, e.g.
+// rsi_pct and rsi_z; they are unknown for unranked results
+func rankFields() []Field {
+	var fields []Field
+	for _, m := range rankMetrics {
+		name := m.name
+		fields = append(fields,
+			Field{Name: name + "_pct", Kind: KindNumber, value: func(r *ScreenResult) interface{} {
+				if rank, ok := r.Ranks[name]; ok {
+					return rank.Percentile
+				}
+				return nil
+			}},
+			Field{Name: name + "_z", Kind: KindNumber, value: func(r *ScreenResult) interface{} {
+				if rank, ok := r.Ranks[name]; ok {
+					return rank.ZScore
+				}
+				return nil
+			}},
+		)
+	}
+	return fields
+}
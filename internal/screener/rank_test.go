@@ -0,0 +1,140 @@
+package screener
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+)
+
+func TestRankResults(t *testing.T) {
+	var results []*ScreenResult
+	for i := 1; i <= 10; i++ {
+		results = append(results, &ScreenResult{
+			Symbol: fmt.Sprintf("S%d", i), RSI: float64(10 * i), Volatility: 20,
+			HasFundamentals: true, PBV: float64(i) / 10, Momentum: float64(-i),
+		})
+	}
+	results = append(results, &ScreenResult{Symbol: "ERR", HasError: true, RSI: 50})
+
+	RankResults(results, DefaultRankOptions())
+
+	first, last := results[0], results[9]
+	if got := first.Ranks[MetricRSI]; got.Percentile != 5 || math.Abs(got.ZScore+1.5667) > 1e-3 {
+		t.Errorf("Lowest RSI rank = %+v, want percentile 5 and z -1.57", got)
+	}
+	if got := last.Ranks[MetricRSI].Percentile; got != 95 {
+		t.Errorf("Highest RSI percentile = %v, want 95", got)
+	}
+	// Equal values share the middle rank and have no spread
+	if got := first.Ranks[MetricVolatility]; got.Percentile != 50 || got.ZScore != 0 {
+		t.Errorf("Tied volatility rank = %+v, want percentile 50 and z 0", got)
+	}
+
+	// Low RSI, P/B and volatility and high momentum score best
+	if first.RankScore <= last.RankScore || !first.TopDecile || last.TopDecile {
+		t.Errorf("Expected S1 to top the ranking: S1 %.1f (top %v), S10 %.1f (top %v)",
+			first.RankScore, first.TopDecile, last.RankScore, last.TopDecile)
+	}
+	decile := 0
+	for _, r := range results {
+		if r.TopDecile {
+			decile++
+		}
+	}
+	if decile != 1 {
+		t.Errorf("Expected one top decile result out of 10, got %d", decile)
+	}
+	if errored := results[10]; errored.Ranks != nil || errored.TopDecile {
+		t.Errorf("Expected results with errors to stay unranked, got %+v", errored.Ranks)
+	}
+
+	// Ranked fields are usable in expressions; unranked ones are unknown
+	e, err := Compile("rsi_pct < 10 and top_decile and rank_score > 50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Match(first) || e.Match(last) {
+		t.Error("Expected the rank expression to match S1 only")
+	}
+	if f, _ := LookupField("rank_score"); f.Value(results[10]) != nil {
+		t.Error("Expected rank_score to be unknown for unranked results")
+	}
+}
+
+func TestRankResults_BySector(t *testing.T) {
+	results := []*ScreenResult{
+		{Symbol: "A", Sector: "Tech", RSI: 20},
+		{Symbol: "B", Sector: "Tech", RSI: 60},
+		{Symbol: "C", Sector: "Energy", RSI: 70},
+		{Symbol: "D", RSI: 40},
+	}
+	RankResults(results, RankOptions{BySector: true, Weights: map[string]float64{MetricRSI: -1}})
+
+	if results[0].Ranks[MetricRSI].Percentile != 25 || results[1].Ranks[MetricRSI].Percentile != 75 {
+		t.Errorf("Expected Tech to be ranked on its own, got %v and %v", results[0].Ranks, results[1].Ranks)
+	}
+	if results[2].Ranks[MetricRSI].Percentile != 50 || results[2].RankGroup != "Energy" {
+		t.Errorf("Expected a lone result to rank in the middle of its sector, got %+v", results[2])
+	}
+	if results[3].RankGroup != UntaggedSector {
+		t.Errorf("Expected untagged results to be grouped, got %q", results[3].RankGroup)
+	}
+	// P/B is unknown without fundamentals
+	if _, ok := results[0].Ranks[MetricPBV]; ok {
+		t.Error("Expected no P/B rank without fundamentals")
+	}
+}
+
+func TestRankOptions_Validate(t *testing.T) {
+	for want, weights := range map[string]map[string]float64{
+		"unknown rank metric": {"beta": 1},
+		"non-zero":            {"rsi": 0},
+		"must be a number":    {"rsi": math.NaN()},
+	} {
+		if err := (RankOptions{Weights: weights}).Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate(%v) = %v, want an error containing %q", weights, err, want)
+		}
+	}
+
+	opts := RankOptions{BySector: true, Weights: map[string]float64{MetricPBV: -2, MetricMomentum: 1}}
+	if got := opts.String(); got != "-2×pbv +momentum within sectors" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestScan_Ranked(t *testing.T) {
+	defer cleanup()
+
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	engine := NewEngineWithProvider(2, provider)
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatalf("Failed to clear watchlist: %v", err)
+	}
+
+	// Ranks are computed over every scanned symbol before filtering,
+	// so only the highest of the three RSIs is above the median
+	expr, err := Compile("rsi_pct > 50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetCriteria(FilterCriteria{MaxRSI: 100, MaxPBV: 100, MinGrahamUpside: -1000, Expression: expr})
+	if err := engine.SetRanking(&RankOptions{Weights: map[string]float64{"beta": 1}}); err == nil {
+		t.Error("Expected invalid rank options to be rejected")
+	}
+	opts := DefaultRankOptions()
+	if err := engine.SetRanking(&opts); err != nil {
+		t.Fatal(err)
+	}
+
+	results := engine.Scan([]string{"AAPL", "MSFT", "SPY"})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result above the median RSI, got %d", len(results))
+	}
+	if got := results[0].Ranks[MetricRSI].Percentile; math.Abs(got-83.3333) > 1e-3 {
+		t.Errorf("Expected the top RSI of three to rank at 83.3, got %v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/universe"
 )
 
 // cleanup removes temporary config files created during tests
@@ -34,9 +35,9 @@ func TestScan_All_Subset(t *testing.T) {
 	}
 
 	// 2. Prepare "All" scan (subset)
-	allSymbols := fetcher.DefaultSymbols()
+	allSymbols := universe.Default().Symbols()
 	if len(allSymbols) == 0 {
-		t.Fatal("DefaultSymbols returned empty list")
+		t.Fatal("default universe is empty")
 	}
 
 	// Take a small subset to avoid rate limits and long execution
@@ -180,3 +181,160 @@ func TestScan_Watchlist(t *testing.T) {
 		}
 	}
 }
+
+func TestScan_Replay(t *testing.T) {
+	defer cleanup()
+
+	// Recorded responses make this scan offline and reproducible
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	engine := NewEngineWithProvider(2, provider)
+
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatalf("Failed to clear watchlist: %v", err)
+	}
+
+	engine.SetCriteria(FilterCriteria{
+		MinRSI:          0,
+		MaxRSI:          100,
+		MaxPBV:          100,
+		MinGrahamUpside: -1000,
+		MinConfluence:   0,
+	})
+	engine.SetBaseCurrency("usd")
+
+	results := engine.Scan([]string{"AAPL", "MSFT", "MISSING"})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results (MISSING has no recording), got %d", len(results))
+	}
+
+	for _, r := range results {
+		if r.HasError {
+			t.Errorf("%s: unexpected error %s", r.Symbol, r.ErrorMessage)
+		}
+		if r.RSI <= 0 || r.ATR <= 0 {
+			t.Errorf("%s: expected indicators from replayed history, got RSI=%.1f ATR=%.2f", r.Symbol, r.RSI, r.ATR)
+		}
+		if len(r.Bars) != len(r.HistoricalPrices) || r.Bars[0].Time.IsZero() {
+			t.Errorf("%s: expected dated bars alongside prices, got %d bars", r.Symbol, len(r.Bars))
+		}
+		if r.Currency != "USD" || r.ExchangeTimezone != "America/New_York" {
+			t.Errorf("%s: expected currency and timezone from the chart, got %q %q", r.Symbol, r.Currency, r.ExchangeTimezone)
+		}
+		if r.BaseCurrency != "USD" || r.FXRate != 1 || r.BasePrice() != r.Price {
+			t.Errorf("%s: expected a 1:1 rate to the base currency, got %s %v", r.Symbol, r.BaseCurrency, r.FXRate)
+		}
+	}
+	if err := engine.FXError(); err != nil {
+		t.Errorf("Unexpected FX error %v", err)
+	}
+
+	progress := engine.GetProgress()
+	if progress.ErrorCount != 1 || progress.ErrorSymbol != "MISSING" {
+		t.Errorf("Expected one error for MISSING, got %+v", progress)
+	}
+}
+
+func TestScan_FXBeforeComparisons(t *testing.T) {
+	defer cleanup()
+
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	engine := NewEngineWithProvider(2, provider)
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatalf("Failed to clear watchlist: %v", err)
+	}
+	// Nothing passes, but every scanned result is converted and ranked
+	engine.SetCriteria(FilterCriteria{MinConfluence: 1000})
+	engine.SetBaseCurrency("USD")
+	ranking := DefaultRankOptions()
+	engine.SetRanking(&ranking)
+
+	if results := engine.Scan([]string{"AAPL", "MSFT"}); len(results) != 0 {
+		t.Fatalf("Expected the filter to leave nothing, got %d results", len(results))
+	}
+	scanned := engine.Scanned()
+	if len(scanned) != 2 {
+		t.Fatalf("Expected 2 scanned results, got %d", len(scanned))
+	}
+	for _, r := range scanned {
+		if r.BaseCurrency != "USD" || r.FXRate != 1 || r.Ranks == nil {
+			t.Errorf("%s: expected a rate and ranks on every scanned result, got %s %v %v", r.Symbol, r.BaseCurrency, r.FXRate, r.Ranks)
+		}
+	}
+}
+
+func TestScan_FXFilter(t *testing.T) {
+	defer cleanup()
+
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	engine := NewEngineWithProvider(2, provider)
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatalf("Failed to clear watchlist: %v", err)
+	}
+	// The rates come after the scan, so the filter waits for them
+	expr, err := Compile(`fx_rate == 1 and base_currency == "USD" and base_price > 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetCriteria(FilterCriteria{MaxRSI: 100, MaxPBV: 100, MinGrahamUpside: -1000, Expression: expr})
+	engine.SetBaseCurrency("USD")
+
+	if results := engine.Scan([]string{"AAPL", "MSFT"}); len(results) != 2 {
+		t.Errorf("Expected both results to match the FX fields, got %d", len(results))
+	}
+}
+
+func TestCalculateMetrics_UnknownFundamentals(t *testing.T) {
+	closes := make([]float64, 40)
+	highs := make([]float64, 40)
+	lows := make([]float64, 40)
+	for i := range closes {
+		closes[i] = 100 - float64(i%5)
+		highs[i] = closes[i] + 1
+		lows[i] = closes[i] - 1
+	}
+
+	data := &fetcher.StockData{
+		Symbol:           "TEST",
+		Price:            96,
+		HistoricalPrices: closes,
+		HistoricalCloses: closes,
+		HistoricalHighs:  highs,
+		HistoricalLows:   lows,
+	}
+
+	unknown := CalculateMetrics(data)
+	if unknown.ValuationScore != 0 || unknown.IsUndervalued {
+		t.Errorf("Unknown fundamentals must not score: valuation=%.1f undervalued=%v", unknown.ValuationScore, unknown.IsUndervalued)
+	}
+
+	// Score is the technical/risk average, not dragged down by a zero valuation
+	want := (unknown.TechnicalScore + unknown.RiskScore) / 2
+	if unknown.ConfluenceScore < want-0.01 {
+		t.Errorf("Expected confluence >= %.1f without fundamentals, got %.1f", want, unknown.ConfluenceScore)
+	}
+
+	// Poor fundamentals are scored (and penalised) normally
+	data.HasFundamentals = true
+	data.EPS = 1
+	data.BookValue = 10
+	data.PERatio = 96
+	known := CalculateMetrics(data)
+	if known.PBV <= 0 || known.ConfluenceScore >= unknown.ConfluenceScore {
+		t.Errorf("Expected expensive stock to score below unknown one: known=%.1f unknown=%.1f", known.ConfluenceScore, unknown.ConfluenceScore)
+	}
+}
+
+func TestHistoryConfig_Options(t *testing.T) {
+	// Automatic lookback must cover SMA200
+	opts := DefaultHistoryConfig().Options()
+	if opts.Interval != fetcher.Interval1d || opts.Days < RequiredBars() {
+		t.Errorf("Unexpected automatic history options: %+v", opts)
+	}
+
+	// An explicit lookback is used as is
+	opts = HistoryConfig{Interval: fetcher.Interval1wk, LookbackDays: 730}.Options()
+	if opts.Days != 730 || opts.Interval != fetcher.Interval1wk {
+		t.Errorf("Unexpected explicit history options: %+v", opts)
+	}
+}
@@ -18,11 +18,27 @@ type ScreenResult struct {
 	MarketCap     int64
 	Exchange      string
 
+	// Currency is the ISO code prices are quoted in; ExchangeTimezone the
+	// exchange's IANA time zone. Both are empty when the provider omits them.
+	Currency         string
+	ExchangeTimezone string
+
+	// Sector and Industry classify the symbol: the universe file's tags, else
+	// the provider's company profile ("" when unknown)
+	Sector   string
+	Industry string
+
+	// FXRate converts prices into BaseCurrency for cross-market ranking.
+	// It is 0 when no base currency is configured or the rate is unknown.
+	BaseCurrency string
+	FXRate       float64
+
 	// Technical Indicators
-	RSI   float64
-	ATR   float64
-	SMA20 float64
-	SMA50 float64
+	RSI    float64
+	ATR    float64
+	SMA20  float64
+	SMA50  float64
+	SMA200 float64
 
 	// MACD Indicators
 	MACD          float64
@@ -47,14 +63,32 @@ type ScreenResult struct {
 	GrahamUpside  float64
 	DividendYield float64
 
+	// RelativePBV and RelativePE compare P/B and P/E with the sector median
+	// of the scan (0.8 = 20% below it); 0 when either is unknown
+	RelativePBV float64
+	RelativePE  float64
+
+	// HasFundamentals is false when P/E, EPS and book value are unknown.
+	// The valuation fields are then 0 and excluded from the confluence score.
+	HasFundamentals bool
+
 	// Risk Metrics
 	StopLoss   float64
 	TakeProfit float64
 	RiskRatio  float64
 	Volatility float64
 
+	// Momentum is the percentage price change over the strategy's momentum bars
+	Momentum float64
+
 	// Historical Data (for charts)
 	HistoricalPrices []float64
+	// Bars is the OHLCV series behind HistoricalPrices, oldest first.
+	// It isn't saved with scan history to keep the files small.
+	Bars []fetcher.Bar `json:"-"`
+
+	// Quality reports missing bars, gaps, zero volume and stale data in the history
+	Quality fetcher.DataQuality
 
 	// Scores
 	TechnicalScore  float64
@@ -62,6 +96,21 @@ type ScreenResult struct {
 	RiskScore       float64
 	ConfluenceScore float64
 
+	// Ranks holds the result's percentile and z-score per metric against the
+	// other scanned results; nil when ranking is off (see RankResults)
+	Ranks map[string]Rank
+	// RankGroup is the sector a result was ranked within ("" when ranked
+	// against the whole scan)
+	RankGroup string
+	// RankScore is the composite of the metric percentiles (0-100)
+	RankScore float64
+	// TopDecile is set when RankScore is in the top 10% of the peer group
+	TopDecile bool
+
+	// Contributions lists the scoring tables and bonuses that awarded
+	// points, so the score can be audited (see Contribution)
+	Contributions []Contribution
+
 	// Flags
 	IsOversold    bool
 	IsUndervalued bool
@@ -70,8 +119,41 @@ type ScreenResult struct {
 	ErrorMessage  string
 }
 
-// CalculateMetrics computes all metrics for a stock
+// BasePrice returns the price in BaseCurrency, or the native price when
+// there is no conversion rate
+func (r *ScreenResult) BasePrice() float64 {
+	return r.ToBase(r.Price)
+}
+
+// ToBase converts an amount in the result's currency into BaseCurrency,
+// returning it unchanged when there is no conversion rate
+func (r *ScreenResult) ToBase(amount float64) float64 {
+	if r.FXRate <= 0 {
+		return amount
+	}
+	return amount * r.FXRate
+}
+
+// RequiredBars returns the number of bars the default indicators need
+func RequiredBars() int {
+	return DefaultIndicators().RequiredBars()
+}
+
+// CalculateMetrics computes all metrics for a stock with the default strategy
 func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
+	return CalculateMetricsFor(data, DefaultStrategy())
+}
+
+// CalculateMetricsFor computes all metrics for a stock with the indicator
+// parameters and scoring of a strategy and the default scoring model
+func CalculateMetricsFor(data *fetcher.StockData, strategy *Strategy) *ScreenResult {
+	return CalculateMetricsWith(data, strategy, DefaultScoringModel())
+}
+
+// CalculateMetricsWith computes all metrics for a stock with a strategy's
+// indicator parameters, scored by a validated scoring model
+func CalculateMetricsWith(data *fetcher.StockData, strategy *Strategy, model *ScoringModel) *ScreenResult {
+	in := strategy.Indicators
 	result := &ScreenResult{
 		Symbol:        data.Symbol,
 		Name:          data.ShortName,
@@ -84,6 +166,13 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 		PERatio:       data.PERatio,
 		EPS:           data.EPS,
 		BookValue:     data.BookValue,
+		DividendYield: data.DividendYield,
+
+		Currency:         data.Currency,
+		ExchangeTimezone: data.ExchangeTimezone,
+		Sector:           data.Sector,
+		Industry:         data.Industry,
+		HasFundamentals:  data.HasFundamentals,
 	}
 
 	if data.Error != nil {
@@ -93,14 +182,14 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 	}
 
 	// Calculate RSI
-	if len(data.HistoricalPrices) > 14 {
-		result.RSI = analysis.RSI(data.HistoricalPrices, 14)
-		result.IsOversold = analysis.IsOversold(result.RSI, 35)
+	if len(data.HistoricalPrices) > in.RSIPeriod {
+		result.RSI = analysis.RSI(data.HistoricalPrices, in.RSIPeriod)
+		result.IsOversold = analysis.IsOversold(result.RSI, in.OversoldRSI)
 	}
 
 	// Calculate ATR
-	if len(data.HistoricalHighs) > 14 {
-		result.ATR = analysis.ATR(data.HistoricalHighs, data.HistoricalLows, data.HistoricalCloses, 14)
+	if len(data.HistoricalHighs) > in.ATRPeriod {
+		result.ATR = analysis.ATR(data.HistoricalHighs, data.HistoricalLows, data.HistoricalCloses, in.ATRPeriod)
 	}
 
 	// Calculate SMAs
@@ -110,6 +199,9 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 	if len(data.HistoricalPrices) >= 50 {
 		result.SMA50 = analysis.SMA(data.HistoricalPrices, 50)
 	}
+	if len(data.HistoricalPrices) >= 200 {
+		result.SMA200 = analysis.SMA(data.HistoricalPrices, 200)
+	}
 
 	// Calculate PBV
 	if data.BookValue > 0 {
@@ -126,7 +218,7 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 	result.IsUndervalued = analysis.IsUndervalued(result.PBV, result.GrahamUpside)
 
 	// Calculate SL/TP
-	risk := analysis.CalculateSLTP(data.Price, result.ATR, 2.0, 3.0)
+	risk := analysis.CalculateSLTP(data.Price, result.ATR, in.StopLossATR, in.TakeProfitATR)
 	result.StopLoss = risk.StopLoss
 	result.TakeProfit = risk.TakeProfit
 	result.RiskRatio = risk.RiskRatio
@@ -136,18 +228,23 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 		result.Volatility = analysis.Volatility(data.HistoricalPrices)
 	}
 
-	// Calculate MACD (12, 26, 9)
-	if len(data.HistoricalPrices) >= 35 {
-		macd := analysis.MACD(data.HistoricalPrices, 12, 26, 9)
+	// Calculate Momentum (63 bars by default)
+	if len(data.HistoricalPrices) > in.MomentumBars {
+		result.Momentum = analysis.Momentum(data.HistoricalPrices, in.MomentumBars)
+	}
+
+	// Calculate MACD (12, 26, 9 by default)
+	if len(data.HistoricalPrices) >= in.MACDSlow+in.MACDSignal {
+		macd := analysis.MACD(data.HistoricalPrices, in.MACDFast, in.MACDSlow, in.MACDSignal)
 		result.MACD = macd.MACD
 		result.MACDSignal = macd.Signal
 		result.MACDHistogram = macd.Histogram
 		result.MACDCrossover = macd.Crossover
 	}
 
-	// Calculate Bollinger Bands (20, 2.0)
-	if len(data.HistoricalPrices) >= 20 {
-		bb := analysis.BollingerBands(data.HistoricalPrices, 20, 2.0)
+	// Calculate Bollinger Bands (20, 2.0 by default)
+	if len(data.HistoricalPrices) >= in.BBPeriod {
+		bb := analysis.BollingerBands(data.HistoricalPrices, in.BBPeriod, in.BBStdDev)
 		result.BBUpper = bb.Upper
 		result.BBMiddle = bb.Middle
 		result.BBLower = bb.Lower
@@ -158,116 +255,56 @@ func CalculateMetrics(data *fetcher.StockData) *ScreenResult {
 
 	// Store historical prices for chart display
 	result.HistoricalPrices = data.HistoricalPrices
+	result.Bars = data.Bars
+	result.Quality = data.Quality
 
 	// Calculate Scores
-	result.TechnicalScore = calculateTechnicalScore(result)
-	result.ValuationScore = analysis.ValuationScore(result.PBV, result.GrahamUpside, result.PERatio)
-	result.RiskScore = calculateRiskAdjustedScore(result)
-	result.ConfluenceScore = calculateConfluenceScore(result)
+	result.TechnicalScore = calculateTechnicalScore(result, model, strategy.Technical)
+	result.ValuationScore = calculateValuationScore(result, model)
+	result.RiskScore = calculateRiskAdjustedScore(result, model)
+	result.ConfluenceScore = calculateConfluenceScore(result, model, model.WeightsFor(strategy))
+	result.Contributions = explainScore(result, model, strategy)
 
 	return result
 }
 
 // calculateTechnicalScore returns a score based on technical indicators
-func calculateTechnicalScore(r *ScreenResult) float64 {
-	var score float64
-
-	// RSI scoring (max 50 points) - favor oversold conditions for deep value
-	if r.RSI > 0 {
-		if r.RSI < 25 {
-			score += 50
-		} else if r.RSI < 30 {
-			score += 45
-		} else if r.RSI < 35 {
-			score += 40
-		} else if r.RSI < 40 {
-			score += 30
-		} else if r.RSI < 50 {
-			score += 20
-		} else if r.RSI < 60 {
-			score += 10
-		}
-		// RSI > 60 gets 0 points (overbought territory)
-	}
-
-	// Price vs SMA scoring (max 30 points)
-	if r.SMA20 > 0 && r.Price < r.SMA20 {
-		discount := ((r.SMA20 - r.Price) / r.SMA20) * 100
-		if discount > 10 {
-			score += 30
-		} else if discount > 5 {
-			score += 20
-		} else {
-			score += 10
-		}
-	}
-
-	// Risk/Reward ratio scoring (max 20 points)
-	if r.RiskRatio >= 3.0 {
-		score += 20
-	} else if r.RiskRatio >= 2.0 {
-		score += 15
-	} else if r.RiskRatio >= 1.5 {
-		score += 10
-	} else if r.RiskRatio >= 1.0 {
-		score += 5
-	}
+// from the model's tables for the strategy's technical style
+func calculateTechnicalScore(r *ScreenResult, m *ScoringModel, style string) float64 {
+	return componentScore(m.Technical[style], r)
+}
 
-	return score
+// calculateValuationScore returns a score based on valuation metrics
+func calculateValuationScore(r *ScreenResult, m *ScoringModel) float64 {
+	return componentScore(m.Valuation, r)
 }
 
 // calculateRiskAdjustedScore adjusts score based on risk
-func calculateRiskAdjustedScore(r *ScreenResult) float64 {
-	// Lower volatility = lower risk = better score
-	if r.Volatility < 20 {
-		return 100
-	} else if r.Volatility < 30 {
-		return 80
-	} else if r.Volatility < 40 {
-		return 60
-	} else if r.Volatility < 50 {
-		return 40
-	} else if r.Volatility < 60 {
-		return 20
-	}
-	return 10
+func calculateRiskAdjustedScore(r *ScreenResult, m *ScoringModel) float64 {
+	return componentScore(m.Risk, r)
 }
 
 // calculateConfluenceScore combines all factors into final score
-func calculateConfluenceScore(r *ScreenResult) float64 {
+func calculateConfluenceScore(r *ScreenResult, m *ScoringModel, w Weights) float64 {
 	// Weighted average of all scores
-	// Technical: 30%, Valuation: 40%, Risk: 30%
-	score := (r.TechnicalScore * 0.30) +
-		(r.ValuationScore * 0.40) +
-		(r.RiskScore * 0.30)
-
-	// Bonus points for confluence signals
-	bonusPoints := 0.0
-
-	// Oversold + Undervalued confluence
-	if r.IsOversold && r.IsUndervalued {
-		bonusPoints += 10
-	}
+	score := (r.TechnicalScore*w.Technical + r.ValuationScore*w.Valuation + r.RiskScore*w.Risk) /
+		(w.Technical + w.Valuation + w.Risk)
 
-	// Low PBV bonus
-	if r.PBV > 0 && r.PBV < 1.0 {
-		bonusPoints += 5
+	// Without fundamentals the valuation weight is redistributed
+	// so missing data isn't scored as poor value
+	if !r.HasFundamentals {
+		score = (r.TechnicalScore*w.Technical + r.RiskScore*w.Risk) / (w.Technical + w.Risk)
 	}
 
-	// Strong Graham Upside bonus
-	if r.GrahamUpside > 50 {
-		bonusPoints += 5
-	}
-
-	// Excellent Risk/Reward bonus
-	if r.RiskRatio >= 2.5 {
-		bonusPoints += 5
+	// Bonus points for confluence signals
+	for i := range m.Bonuses {
+		if m.Bonuses[i].Holds(r) {
+			score += m.Bonuses[i].Points
+		}
 	}
 
-	score += bonusPoints
-
-	// Cap at 100
-	return math.Min(score, 100)
+	// Keep within 0-100
+	return math.Max(0, math.Min(score, 100))
 }
 
 // ScoreToGrade converts numeric score to letter grade
@@ -0,0 +1,127 @@
+package screener
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// Screen is a saved, named screening expression
+type Screen struct {
+	Name        string `json:"name"`
+	Expression  string `json:"expression"`
+	Description string `json:"description,omitempty"`
+}
+
+// screensFile is the JSON structure of config/screens.json
+type screensFile struct {
+	Screens []Screen `json:"screens"`
+}
+
+// ScreensPath returns the file saved screens are stored in
+func ScreensPath() string {
+	return config.Path("screens.json")
+}
+
+// LoadScreens returns the saved screens sorted by name; none if the file doesn't exist
+func LoadScreens() ([]Screen, error) {
+	data, err := os.ReadFile(ScreensPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var f screensFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", ScreensPath(), err)
+	}
+	sort.Slice(f.Screens, func(i, j int) bool {
+		return strings.ToLower(f.Screens[i].Name) < strings.ToLower(f.Screens[j].Name)
+	})
+	return f.Screens, nil
+}
+
+// LookupScreen returns the saved screen with the given name (case insensitive)
+func LookupScreen(name string) (Screen, error) {
+	screens, err := LoadScreens()
+	if err != nil {
+		return Screen{}, err
+	}
+	names := make([]string, len(screens))
+	for i, s := range screens {
+		if strings.EqualFold(s.Name, strings.TrimSpace(name)) {
+			return s, nil
+		}
+		names[i] = s.Name
+	}
+	if len(names) == 0 {
+		return Screen{}, fmt.Errorf("unknown screen %q (no screens saved)", name)
+	}
+	return Screen{}, fmt.Errorf("unknown screen %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// SaveScreen validates a screen's expression and saves it, replacing a
+// screen of the same name
+func SaveScreen(s Screen) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("screen needs a name")
+	}
+	if _, err := Compile(s.Expression); err != nil {
+		return err
+	}
+
+	screens, err := LoadScreens()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range screens {
+		if strings.EqualFold(screens[i].Name, s.Name) {
+			screens[i] = s
+			replaced = true
+		}
+	}
+	if !replaced {
+		screens = append(screens, s)
+	}
+	return writeScreens(screens)
+}
+
+// DeleteScreen removes a saved screen
+func DeleteScreen(name string) error {
+	screens, err := LoadScreens()
+	if err != nil {
+		return err
+	}
+	kept := screens[:0]
+	for _, s := range screens {
+		if !strings.EqualFold(s.Name, strings.TrimSpace(name)) {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == len(screens) {
+		return fmt.Errorf("unknown screen %q", name)
+	}
+	return writeScreens(kept)
+}
+
+// writeScreens writes the screens file
+func writeScreens(screens []Screen) error {
+	path := ScreensPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(screensFile{Screens: screens}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
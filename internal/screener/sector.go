@@ -0,0 +1,164 @@
+package screener
+
+import "sort"
+
+// UntaggedSector groups the results without a sector in rankings and
+// sector aggregates
+const UntaggedSector = "Untagged"
+
+// minSectorPeers is the fewest results with a value a sector median is
+// computed from; smaller samples aren't a meaningful benchmark
+const minSectorPeers = 3
+
+// SectorStats aggregates the scanned results of one sector
+type SectorStats struct {
+	Sector    string
+	Count     int     // Results scanned without errors
+	Passing   int     // Results that passed the filter
+	MedianPBV float64 // 0 when fewer than minSectorPeers results have a P/B
+	MedianPE  float64 // 0 when fewer than minSectorPeers results have a positive P/E
+	AvgRSI    float64
+	AvgScore  float64
+}
+
+// SectorSummary aggregates results by sector, sorted by name with untagged
+// results last. passes reports whether a result passed the filter.
+func SectorSummary(results []*ScreenResult, passes func(r *ScreenResult) bool) []SectorStats {
+	groups := make(map[string][]*ScreenResult)
+	for _, r := range results {
+		if r.HasError {
+			continue
+		}
+		key := r.Sector
+		if key == "" {
+			key = UntaggedSector
+		}
+		groups[key] = append(groups[key], r)
+	}
+
+	stats := make([]SectorStats, 0, len(groups))
+	for sector, group := range groups {
+		s := SectorStats{Sector: sector, Count: len(group)}
+		var pbv, pe []float64
+		var rsiSum float64
+		var rsiCount int
+		for _, r := range group {
+			if passes != nil && passes(r) {
+				s.Passing++
+			}
+			if r.HasFundamentals && r.PBV > 0 {
+				pbv = append(pbv, r.PBV)
+			}
+			if r.HasFundamentals && r.PERatio > 0 {
+				pe = append(pe, r.PERatio)
+			}
+			if r.RSI > 0 {
+				rsiSum += r.RSI
+				rsiCount++
+			}
+			s.AvgScore += r.ConfluenceScore
+		}
+		s.AvgScore = round4(s.AvgScore / float64(len(group)))
+		if rsiCount > 0 {
+			s.AvgRSI = round4(rsiSum / float64(rsiCount))
+		}
+		if len(pbv) >= minSectorPeers {
+			s.MedianPBV = round4(median(pbv))
+		}
+		if len(pe) >= minSectorPeers {
+			s.MedianPE = round4(median(pe))
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].Sector == UntaggedSector) != (stats[j].Sector == UntaggedSector) {
+			return stats[j].Sector == UntaggedSector
+		}
+		return stats[i].Sector < stats[j].Sector
+	})
+	return stats
+}
+
+// applySectorValuation sets the P/B and P/E of each tagged result relative
+// to its sector's median (0.8 = 20% cheaper than the sector)
+func applySectorValuation(results []*ScreenResult, stats []SectorStats) {
+	medians := make(map[string]SectorStats, len(stats))
+	for _, s := range stats {
+		medians[s.Sector] = s
+	}
+	for _, r := range results {
+		r.RelativePBV, r.RelativePE = 0, 0
+		s, ok := medians[r.Sector]
+		if r.Sector == "" || !ok || !r.HasFundamentals {
+			continue
+		}
+		if s.MedianPBV > 0 && r.PBV > 0 {
+			r.RelativePBV = round4(r.PBV / s.MedianPBV)
+		}
+		if s.MedianPE > 0 && r.PERatio > 0 {
+			r.RelativePE = round4(r.PERatio / s.MedianPE)
+		}
+	}
+}
+
+// median returns the median of values (which it sorts)
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// rankingFields are the fields only ranking sets
+func rankingFields() map[string]bool {
+	names := map[string]bool{"rank_score": true, "top_decile": true, "rank_group": true}
+	for _, f := range rankFields() {
+		names[f.Name] = true
+	}
+	return names
+}
+
+// wholeScanFields are fields computed by comparing results with each other,
+// or from the exchange rates fetched after the scan; a filter using them
+// must wait until every symbol is scanned
+func wholeScanFields() map[string]bool {
+	names := rankingFields()
+	for _, name := range []string{"relative_pbv", "relative_pe", "fx_rate", "base_currency", "base_price"} {
+		names[name] = true
+	}
+	return names
+}
+
+// usesFields reports whether an expression uses any of the named fields
+func usesFields(e *Expr, names map[string]bool) bool {
+	if e == nil {
+		return false
+	}
+	for _, f := range e.Fields() {
+		if names[f.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// needsWholeScan reports whether an expression uses fields that compare
+// results with each other
+func needsWholeScan(e *Expr) bool {
+	return usesFields(e, wholeScanFields())
+}
+
+// NeedsWholeScan reports whether the criteria's expression uses fields that
+// compare results with each other, which a single result can't satisfy
+func (c FilterCriteria) NeedsWholeScan() bool {
+	return needsWholeScan(c.Expression)
+}
+
+// NeedsRanking reports whether the criteria's expression uses rank fields,
+// which are unknown, so never match, unless the scan is ranked
+func (c FilterCriteria) NeedsRanking() bool {
+	return usesFields(c.Expression, rankingFields())
+}
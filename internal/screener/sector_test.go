@@ -0,0 +1,106 @@
+package screener
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+)
+
+func TestSectorSummary(t *testing.T) {
+	results := []*ScreenResult{
+		{Symbol: "A", Sector: "Tech", RSI: 30, HasFundamentals: true, PBV: 1, PERatio: 10, ConfluenceScore: 80},
+		{Symbol: "B", Sector: "Tech", RSI: 50, HasFundamentals: true, PBV: 2, PERatio: 20, ConfluenceScore: 40},
+		{Symbol: "C", Sector: "Tech", RSI: 70, HasFundamentals: true, PBV: 4, PERatio: -5, ConfluenceScore: 60},
+		{Symbol: "D", Sector: "Energy", RSI: 40, HasFundamentals: true, PBV: 1},
+		{Symbol: "E", RSI: 60},
+		{Symbol: "F", Sector: "Energy", HasError: true},
+	}
+
+	stats := SectorSummary(results, func(r *ScreenResult) bool { return r.ConfluenceScore >= 60 })
+	if len(stats) != 3 || stats[0].Sector != "Energy" || stats[1].Sector != "Tech" || stats[2].Sector != UntaggedSector {
+		t.Fatalf("Expected Energy, Tech and untagged sectors in order, got %+v", stats)
+	}
+
+	tech := stats[1]
+	if tech.Count != 3 || tech.Passing != 2 || tech.AvgRSI != 50 || tech.AvgScore != 60 || tech.MedianPBV != 2 {
+		t.Errorf("Unexpected Tech aggregates: %+v", tech)
+	}
+	// Only two positive P/Es: too few for a median
+	if tech.MedianPE != 0 {
+		t.Errorf("Expected no P/E median from two values, got %v", tech.MedianPE)
+	}
+	if energy := stats[0]; energy.Count != 1 || energy.MedianPBV != 0 {
+		t.Errorf("Expected errors left out and no median for one result, got %+v", energy)
+	}
+
+	applySectorValuation(results, stats)
+	if results[0].RelativePBV != 0.5 || results[2].RelativePBV != 2 {
+		t.Errorf("Expected P/B relative to the Tech median of 2, got %v and %v", results[0].RelativePBV, results[2].RelativePBV)
+	}
+	if results[3].RelativePBV != 0 || results[4].RelativePBV != 0 {
+		t.Error("Expected no relative P/B without a sector median")
+	}
+	if f, _ := LookupField("relative_pbv"); f.Value(results[3]) != nil {
+		t.Error("Expected relative_pbv to be unknown without a sector median")
+	}
+}
+
+func TestNeedsWholeScan(t *testing.T) {
+	for src, want := range map[string][2]bool{
+		"rsi < 30":                       {false, false},
+		"relative_pbv < 0.8":             {true, false},
+		"rsi < 30 and momentum_pct > 50": {true, true},
+		"top_decile":                     {true, true},
+		"base_price < 100":               {true, false},
+	} {
+		e, err := Compile(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := FilterCriteria{Expression: e}
+		if got := c.NeedsWholeScan(); got != want[0] {
+			t.Errorf("NeedsWholeScan(%q) = %v, want %v", src, got, want[0])
+		}
+		if got := c.NeedsRanking(); got != want[1] {
+			t.Errorf("NeedsRanking(%q) = %v, want %v", src, got, want[1])
+		}
+	}
+}
+
+func TestScan_Sectors(t *testing.T) {
+	defer cleanup()
+
+	provider := fetcher.NewReplayProvider(filepath.Join("..", "fetcher", "testdata", "replay"))
+	engine := NewEngineWithProvider(2, provider)
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatalf("Failed to clear watchlist: %v", err)
+	}
+	engine.SetCriteria(FilterCriteria{MaxRSI: 100, MaxPBV: 100, MinGrahamUpside: -1000})
+	engine.SetClassifier(func(symbol string) (string, string) {
+		if symbol == "MSFT" {
+			return "Software", "Systems Software"
+		}
+		if symbol == "AAPL" {
+			return "Hardware", ""
+		}
+		return "", ""
+	})
+
+	results := engine.Scan([]string{"AAPL", "MSFT", "SPY"})
+	bySymbol := make(map[string]*ScreenResult)
+	for _, r := range results {
+		bySymbol[r.Symbol] = r
+	}
+
+	// Universe tags win; the provider's profile fills in what they lack
+	if r := bySymbol["AAPL"]; r == nil || r.Sector != "Hardware" || r.Industry != "Consumer Electronics" {
+		t.Errorf("Expected AAPL tagged Hardware with the provider's industry, got %+v", r)
+	}
+	if r := bySymbol["MSFT"]; r == nil || r.Sector != "Software" || r.Industry != "Systems Software" {
+		t.Errorf("Expected MSFT's universe tags, got %+v", r)
+	}
+	if stats := engine.SectorStats(); len(stats) != 3 || stats[2].Sector != UntaggedSector || stats[2].Passing != 1 {
+		t.Errorf("Unexpected sector aggregates: %+v", stats)
+	}
+}
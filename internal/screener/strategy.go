@@ -0,0 +1,351 @@
+package screener
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// Technical scoring styles of a strategy
+const (
+	TechnicalValue         = "value"          // Oversold RSI, price below SMA20
+	TechnicalMomentum      = "momentum"       // Strong RSI, price above rising averages, bullish MACD
+	TechnicalMeanReversion = "mean_reversion" // Price stretched below the lower Bollinger band
+	TechnicalBreakout      = "breakout"       // Squeeze, close near or above the upper band
+)
+
+// technicalStyles lists the valid technical styles
+var technicalStyles = []string{TechnicalValue, TechnicalMomentum, TechnicalMeanReversion, TechnicalBreakout}
+
+// DefaultStrategyName is the strategy used when settings.json doesn't name one
+const DefaultStrategyName = "Deep Value"
+
+//go:embed data/strategies.yaml data/scoring.yaml
+var builtinData embed.FS
+
+// Indicators holds the indicator parameters of a strategy
+type Indicators struct {
+	RSIPeriod     int     `yaml:"rsi_period" json:"rsi_period"`
+	OversoldRSI   float64 `yaml:"oversold_rsi" json:"oversold_rsi"` // RSI below which a stock counts as oversold
+	ATRPeriod     int     `yaml:"atr_period" json:"atr_period"`
+	MACDFast      int     `yaml:"macd_fast" json:"macd_fast"`
+	MACDSlow      int     `yaml:"macd_slow" json:"macd_slow"`
+	MACDSignal    int     `yaml:"macd_signal" json:"macd_signal"`
+	BBPeriod      int     `yaml:"bb_period" json:"bb_period"`
+	BBStdDev      float64 `yaml:"bb_stddev" json:"bb_stddev"`
+	StopLossATR   float64 `yaml:"stop_loss_atr" json:"stop_loss_atr"`     // Stop loss distance in ATRs
+	TakeProfitATR float64 `yaml:"take_profit_atr" json:"take_profit_atr"` // Take profit distance in ATRs
+	MomentumBars  int     `yaml:"momentum_bars" json:"momentum_bars"`     // Bars the momentum change is measured over
+}
+
+// DefaultIndicators returns RSI(14), ATR(14), MACD(12, 26, 9), Bollinger(20, 2),
+// a 2/3 ATR stop loss/take profit and 63-bar momentum
+func DefaultIndicators() Indicators {
+	return Indicators{
+		RSIPeriod:     14,
+		OversoldRSI:   35,
+		ATRPeriod:     14,
+		MACDFast:      12,
+		MACDSlow:      26,
+		MACDSignal:    9,
+		BBPeriod:      20,
+		BBStdDev:      2.0,
+		StopLossATR:   2.0,
+		TakeProfitATR: 3.0,
+		MomentumBars:  63, // About three months of daily bars
+	}
+}
+
+// RequiredBars returns the number of bars the indicators need; the engine
+// sizes its history lookback from it
+func (i Indicators) RequiredBars() int {
+	periods := []int{
+		i.RSIPeriod + 1, i.ATRPeriod + 1,
+		20, // SMA20
+		i.BBPeriod,
+		i.MACDSlow + i.MACDSignal,
+		i.MomentumBars + 1,
+		50,  // SMA50
+		200, // SMA200
+	}
+
+	required := 0
+	for _, p := range periods {
+		if p > required {
+			required = p
+		}
+	}
+	return required
+}
+
+// Validate checks the indicator parameters
+func (i Indicators) Validate() error {
+	if i.RSIPeriod < 1 || i.ATRPeriod < 1 || i.BBPeriod < 2 || i.MACDFast < 1 || i.MACDSignal < 1 || i.MomentumBars < 1 {
+		return fmt.Errorf("indicator periods must be positive")
+	}
+	if i.MACDFast >= i.MACDSlow {
+		return fmt.Errorf("macd_fast must be shorter than macd_slow")
+	}
+	if i.BBStdDev <= 0 || i.StopLossATR <= 0 || i.TakeProfitATR <= 0 {
+		return fmt.Errorf("bb_stddev, stop_loss_atr and take_profit_atr must be positive")
+	}
+	return nil
+}
+
+// Weights are the shares of the component scores in the confluence score
+type Weights struct {
+	Technical float64 `yaml:"technical" json:"technical"`
+	Valuation float64 `yaml:"valuation" json:"valuation"`
+	Risk      float64 `yaml:"risk" json:"risk"`
+}
+
+// StrategyCriteria is the filter of a strategy as written in YAML. Omitted
+// limits are open.
+type StrategyCriteria struct {
+	MinRSI          float64 `yaml:"min_rsi"`
+	MaxRSI          float64 `yaml:"max_rsi"`
+	MaxPBV          float64 `yaml:"max_pbv"`
+	MinGrahamUpside float64 `yaml:"min_graham_upside"`
+	MinScore        float64 `yaml:"min_score"`
+	OnlyOversold    bool    `yaml:"only_oversold"`
+	OnlyUndervalued bool    `yaml:"only_undervalued"`
+	Where           string  `yaml:"where"` // Screening expression (see Compile)
+}
+
+// Strategy is a named screening preset: filter criteria, score weights,
+// a technical scoring style and indicator parameters
+type Strategy struct {
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description,omitempty"`
+	Technical   string           `yaml:"technical"`
+	Weights     *Weights         `yaml:"weights,omitempty"` // nil uses the scoring model's
+	Indicators  Indicators       `yaml:"indicators"`
+	Filter      StrategyCriteria `yaml:"criteria"`
+
+	criteria FilterCriteria
+}
+
+// UnmarshalYAML fills in the defaults for omitted fields
+func (s *Strategy) UnmarshalYAML(node *yaml.Node) error {
+	type plain Strategy
+	p := plain{
+		Technical:  TechnicalValue,
+		Indicators: DefaultIndicators(),
+		Filter:     StrategyCriteria{MaxRSI: 100, MaxPBV: 100, MinGrahamUpside: math.Inf(-1)},
+	}
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*s = Strategy(p)
+	return nil
+}
+
+// Criteria returns the strategy's filter criteria
+func (s *Strategy) Criteria() FilterCriteria {
+	return s.criteria
+}
+
+// init validates the strategy and compiles its criteria
+func (s *Strategy) init() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("strategy needs a name")
+	}
+
+	if !validStyle(s.Technical) {
+		return fmt.Errorf("strategy %s: unknown technical style %q (available: %s)", s.Name, s.Technical, strings.Join(technicalStyles, ", "))
+	}
+
+	if w := s.Weights; w != nil && (w.Technical < 0 || w.Valuation < 0 || w.Risk < 0 || w.Technical+w.Risk <= 0) {
+		return fmt.Errorf("strategy %s: weights must not be negative and technical+risk must be positive", s.Name)
+	}
+
+	if err := s.Indicators.Validate(); err != nil {
+		return fmt.Errorf("strategy %s: %v", s.Name, err)
+	}
+
+	f := s.Filter
+	if f.MinRSI > f.MaxRSI {
+		return fmt.Errorf("strategy %s: min_rsi is above max_rsi", s.Name)
+	}
+	s.criteria = FilterCriteria{
+		MinRSI:          f.MinRSI,
+		MaxRSI:          f.MaxRSI,
+		MaxPBV:          f.MaxPBV,
+		MinGrahamUpside: f.MinGrahamUpside,
+		MinConfluence:   f.MinScore,
+		OnlyOversold:    f.OnlyOversold,
+		OnlyUndervalued: f.OnlyUndervalued,
+	}
+	if strings.TrimSpace(f.Where) != "" {
+		e, err := Compile(f.Where)
+		if err != nil {
+			return fmt.Errorf("strategy %s: where: %v", s.Name, err)
+		}
+		s.criteria.Expression = e
+	}
+	return nil
+}
+
+var (
+	strategyOnce    sync.Once
+	strategies      []*Strategy
+	strategyLoadErr error
+)
+
+// Strategies returns the builtin strategies, with strategies of the same
+// name from config/strategies.yaml replacing them and new ones appended
+func Strategies() []*Strategy {
+	strategyOnce.Do(func() {
+		data, _ := builtinData.ReadFile("data/strategies.yaml")
+		strategies, strategyLoadErr = parseStrategies(data)
+
+		user, err := os.ReadFile(config.Path("strategies.yaml"))
+		if err != nil {
+			return
+		}
+		overrides, err := parseStrategies(user)
+		if err != nil {
+			strategyLoadErr = fmt.Errorf("%s: %v", config.Path("strategies.yaml"), err)
+			return
+		}
+		for _, o := range overrides {
+			replaced := false
+			for i, s := range strategies {
+				if strategyKey(s.Name) == strategyKey(o.Name) {
+					strategies[i] = o
+					replaced = true
+				}
+			}
+			if !replaced {
+				strategies = append(strategies, o)
+			}
+		}
+	})
+	return strategies
+}
+
+// StrategyLoadError returns the error, if any, from reading config/strategies.yaml
+func StrategyLoadError() error {
+	Strategies()
+	return strategyLoadErr
+}
+
+// SaveStrategy validates a strategy and writes it to config/strategies.yaml,
+// replacing a strategy of the same name there and keeping the other entries
+// and their comments; it returns the file's path
+func SaveStrategy(s *Strategy) (string, error) {
+	if err := s.init(); err != nil {
+		return "", err
+	}
+
+	path := config.Path("strategies.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.SequenceNode}}}
+	}
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return "", fmt.Errorf("%s: expected a list of strategies", path)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(s); err != nil {
+		return "", err
+	}
+	replaced := false
+	for i, item := range list.Content {
+		var named struct {
+			Name string `yaml:"name"`
+		}
+		if item.Decode(&named) == nil && strategyKey(named.Name) == strategyKey(s.Name) {
+			list.Content[i] = &node
+			replaced = true
+		}
+	}
+	if !replaced {
+		list.Content = append(list.Content, &node)
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return "", err
+	}
+
+	// Keep the loaded presets in step with the file
+	loaded := Strategies()
+	for i, o := range loaded {
+		if strategyKey(o.Name) == strategyKey(s.Name) {
+			loaded[i] = s
+			return path, nil
+		}
+	}
+	strategies = append(strategies, s)
+	return path, nil
+}
+
+// parseStrategies reads a list of strategies
+func parseStrategies(data []byte) ([]*Strategy, error) {
+	var list []*Strategy
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		if err := s.init(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// strategyKey returns the lookup key of a strategy name ("Deep Value" and
+// "deep-value" match)
+func strategyKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LookupStrategy returns the strategy with the given name (case and
+// punctuation insensitive)
+func LookupStrategy(name string) (*Strategy, error) {
+	list := Strategies()
+	names := make([]string, len(list))
+	for i, s := range list {
+		if strategyKey(s.Name) == strategyKey(name) {
+			return s, nil
+		}
+		names[i] = s.Name
+	}
+	return nil, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// DefaultStrategy returns the Deep Value strategy
+func DefaultStrategy() *Strategy {
+	s, _ := LookupStrategy(DefaultStrategyName)
+	return s
+}
@@ -0,0 +1,163 @@
+package screener
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+)
+
+func TestStrategies_Builtin(t *testing.T) {
+	if err := StrategyLoadError(); err != nil {
+		t.Fatalf("Unexpected load error: %v", err)
+	}
+	for _, name := range []string{"Deep Value", "momentum", "DIVIDEND", "mean-reversion", "breakout"} {
+		if _, err := LookupStrategy(name); err != nil {
+			t.Errorf("LookupStrategy(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := LookupStrategy("nope"); err == nil || !strings.Contains(err.Error(), "Momentum") {
+		t.Errorf("Expected an error listing the strategies, got %v", err)
+	}
+
+	// Deep Value keeps the historical defaults
+	s := DefaultStrategy()
+	want := FilterCriteria{MaxRSI: 40, MaxPBV: 2, MinConfluence: 50}
+	if got := s.Criteria(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deep Value criteria = %+v, want %+v", got, want)
+	}
+	if s.Indicators != DefaultIndicators() || s.Technical != TechnicalValue {
+		t.Errorf("Unexpected Deep Value parameters: %+v", s)
+	}
+}
+
+func TestParseStrategies(t *testing.T) {
+	list, err := parseStrategies([]byte(`
+- name: Fast
+  technical: momentum
+  indicators: {rsi_period: 7}
+  criteria: {min_score: 60, where: "price > sma50"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := list[0]
+	if s.Indicators.RSIPeriod != 7 || s.Indicators.ATRPeriod != 14 || s.Weights != nil {
+		t.Errorf("Expected defaults for omitted fields, got %+v", s)
+	}
+	c := s.Criteria()
+	if c.MinConfluence != 60 || c.MaxRSI != 100 || !math.IsInf(c.MinGrahamUpside, -1) || c.Expression == nil {
+		t.Errorf("Unexpected criteria: %+v", c)
+	}
+
+	for src, want := range map[string]string{
+		`- technical: value`:                                "needs a name",
+		`- {name: X, technical: scalping}`:                  "unknown technical style",
+		`- {name: X, weights: {technical: 0, risk: 0}}`:     "weights",
+		`- {name: X, indicators: {macd_fast: 30}}`:          "macd_fast",
+		`- {name: X, criteria: {min_rsi: 60, max_rsi: 50}}`: "min_rsi",
+		`- {name: X, criteria: {where: "rsi <"}}`:           "where",
+	} {
+		if _, err := parseStrategies([]byte(src)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseStrategies(%q) = %v, want an error containing %q", src, err, want)
+		}
+	}
+}
+
+func TestDefaultCriteria_Override(t *testing.T) {
+	defer cleanup()
+	// Strategies are read once; read them again with the override and after it
+	strategyOnce = sync.Once{}
+	t.Cleanup(func() { strategyOnce = sync.Once{} })
+
+	os.MkdirAll("config", 0755)
+	os.WriteFile(filepath.Join("config", "strategies.yaml"),
+		[]byte("- name: Deep Value\n  technical: value\n  criteria:\n    max_rsi: 55\n    max_pbv: 3\n"), 0644)
+
+	if c := DefaultCriteria(); c.MaxRSI != 55 || c.MaxPBV != 3 {
+		t.Errorf("DefaultCriteria = %+v, want the overridden Deep Value", c)
+	}
+	e := NewEngineWithProvider(1, fetcher.NewReplayProvider(t.TempDir()))
+	if c := e.GetCriteria(); c.MaxRSI != 55 || c.MaxPBV != 3 || e.Strategy().Criteria().MaxRSI != 55 {
+		t.Errorf("New engine criteria = %+v, want the active strategy's", c)
+	}
+}
+
+func TestSaveStrategy(t *testing.T) {
+	defer cleanup()
+
+	path := filepath.Join("config", "strategies.yaml")
+	os.MkdirAll("config", 0755)
+	os.WriteFile(path, []byte("# My presets\n- name: Mine\n  technical: breakout\n"), 0644)
+
+	tuned := *DefaultStrategy()
+	tuned.Name = "Deep Value Tuned"
+	tuned.Indicators.RSIPeriod = 10
+	if _, err := SaveStrategy(&tuned); err != nil {
+		t.Fatal(err)
+	}
+	tuned.Indicators.StopLossATR = 1.5
+	if _, err := SaveStrategy(&tuned); err != nil {
+		t.Fatal(err)
+	}
+	bad := tuned
+	bad.Indicators.MACDFast = 40
+	if _, err := SaveStrategy(&bad); err == nil || !strings.Contains(err.Error(), "macd_fast") {
+		t.Errorf("Expected invalid indicators to be rejected, got %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	list, err := parseStrategies(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "Mine" || !strings.HasPrefix(string(data), "# My presets") {
+		t.Fatalf("Expected the existing entry and comment to be kept:\n%s", data)
+	}
+	saved := list[1]
+	if saved.Indicators.RSIPeriod != 10 || saved.Indicators.StopLossATR != 1.5 || !reflect.DeepEqual(saved.Criteria(), DefaultCriteria()) {
+		t.Errorf("Expected the tuned strategy to round-trip, got %+v", saved)
+	}
+	if s, err := LookupStrategy("deep-value-tuned"); err != nil || s.Indicators.StopLossATR != 1.5 {
+		t.Errorf("Expected the saved strategy to be available, got %+v, %v", s, err)
+	}
+}
+
+func TestCalculateMetricsFor_Momentum(t *testing.T) {
+	// A steady uptrend: strong for momentum, unattractive for deep value
+	n := 220
+	closes := make([]float64, n)
+	highs := make([]float64, n)
+	lows := make([]float64, n)
+	for i := range closes {
+		closes[i] = 50 + float64(i)*0.5 + float64(i%3)
+		highs[i] = closes[i] + 1
+		lows[i] = closes[i] - 1
+	}
+	data := &fetcher.StockData{
+		Symbol:           "TEST",
+		Price:            closes[n-1],
+		HistoricalPrices: closes,
+		HistoricalCloses: closes,
+		HistoricalHighs:  highs,
+		HistoricalLows:   lows,
+	}
+
+	momentum, _ := LookupStrategy("Momentum")
+	value := CalculateMetrics(data)
+	trend := CalculateMetricsFor(data, momentum)
+	if trend.TechnicalScore <= value.TechnicalScore {
+		t.Errorf("Expected momentum to score an uptrend higher: momentum=%.0f value=%.0f", trend.TechnicalScore, value.TechnicalScore)
+	}
+
+	// The strategy's ATR multiples set the stop loss
+	want := data.Price - trend.ATR*momentum.Indicators.StopLossATR
+	if math.Abs(trend.StopLoss-want) > 0.01 {
+		t.Errorf("StopLoss = %.2f, want %.2f", trend.StopLoss, want)
+	}
+}
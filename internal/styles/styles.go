@@ -161,6 +161,10 @@ var (
 	// Pin/Star style
 	PinStyle = lipgloss.NewStyle().
 			Foreground(ColorWarning)
+
+	// Top decile badge style
+	TopDecileStyle = lipgloss.NewStyle().
+			Foreground(ColorHighlight)
 )
 
 // MutedStyle returns a muted text style
@@ -1,17 +1,21 @@
 package ui
 
 import (
-	"os"
 	"strconv"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/febritecno/stockmap-cli/internal/alerts"
+	"github.com/febritecno/stockmap-cli/internal/calendar"
+	"github.com/febritecno/stockmap-cli/internal/config"
 	"github.com/febritecno/stockmap-cli/internal/fetcher"
 	"github.com/febritecno/stockmap-cli/internal/history"
+	"github.com/febritecno/stockmap-cli/internal/paper"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
 	"github.com/febritecno/stockmap-cli/internal/screener"
 	"github.com/febritecno/stockmap-cli/internal/ui/views"
+	"github.com/febritecno/stockmap-cli/internal/universe"
 )
 
 // View represents the current view state
@@ -23,6 +27,8 @@ const (
 	ViewScanner
 	ViewDetails
 	ViewWatchlist
+	ViewPortfolio
+	ViewPaper
 	ViewHistory
 	ViewConnection
 	ViewScanMode
@@ -41,6 +47,8 @@ type Model struct {
 	scanner           *views.Scanner
 	details           *views.Details
 	watchlist         *views.WatchlistView
+	portfolioView     *views.PortfolioView
+	paperView         *views.PaperView
 	historyView       *views.HistoryView
 	connectionView    *views.ConnectionView
 	scanModeView      *views.ScanModeView
@@ -50,15 +58,19 @@ type Model struct {
 	engine            *screener.Engine
 	historyMgr        *history.Manager
 	alertsMgr         *alerts.Manager
+	portfolioMgr      *portfolio.Manager
+	paperMgr          *paper.Manager
+	account           portfolio.Account // Account positions are sized for
 	scanning          bool
 	results           []*screener.ScreenResult
 	totalScanned      int
 	err               error
 	loadedFromHistory bool
 	loadedHistoryID   string
-	scanSymbols       []string // Custom symbols to scan
-	isReload          bool     // Track if this is a reload (to update history instead of create new)
-	lastHistoryID     string   // Last saved history ID for reload
+	scanSymbols       []string           // Custom symbols to scan
+	universe          *universe.Universe // Universe scanned when no custom symbols are set
+	isReload          bool               // Track if this is a reload (to update history instead of create new)
+	lastHistoryID     string             // Last saved history ID for reload
 	// Auto-reload
 	autoReload        bool // Auto-reload enabled
 	autoReloadSeconds int  // Seconds between reloads (default 60)
@@ -79,6 +91,7 @@ type ScanProgressMsg struct {
 	ErrorCount   int
 	LastError    string // Last error message for verbose display
 	ErrorSymbol  string // Symbol that caused the last error
+	Limiter      fetcher.LimiterStats
 }
 
 // ScanCompleteMsg is sent when scanning is complete
@@ -88,7 +101,8 @@ type ScanCompleteMsg struct {
 
 // MarketStatusMsg contains market status
 type MarketStatusMsg struct {
-	Status string
+	Status    string            // State of the first exchange checked
+	Exchanges map[string]string // State by exchange when results span several
 }
 
 // ErrorMsg represents an error
@@ -118,6 +132,9 @@ type ReloadTickMsg struct{}
 // AutoReloadTickMsg triggers auto-reload countdown
 type AutoReloadTickMsg struct{}
 
+// ClockTickMsg refreshes the header's market session countdown
+type ClockTickMsg struct{}
+
 // AlertTriggeredMsg is sent when an alert is triggered
 type AlertTriggeredMsg struct {
 	Alert alerts.TriggeredAlert
@@ -126,24 +143,54 @@ type AlertTriggeredMsg struct {
 // NewModel creates a new app model
 func NewModel() *Model {
 	alertsMgr := alerts.NewManager("")
-	return &Model{
+	portfolioMgr := portfolio.NewManager("")
+	paperMgr := paper.NewManager("")
+	active := universe.Active()
+	watchlistView := views.NewWatchlistView()
+	watchlistView.SetCategories(active.Groups())
+	engine := screener.NewEngine(10) // 10 workers with rate limiting
+	engine.SetClassifier(active.Classify)
+	dashboard := views.NewDashboard()
+	dashboard.SetStrategy(engine.Strategy().Name)
+	if err := screener.ScoringModelLoadError(); err != nil {
+		dashboard.SetMessage("Builtin scoring model used: " + err.Error())
+	} else if err := screener.StrategyLoadError(); err != nil {
+		dashboard.SetMessage("Strategies: " + err.Error())
+	}
+	settings, _ := config.Load()
+	m := &Model{
 		currentView:       ViewSplash,
 		splash:            views.NewSplash(),
-		dashboard:         views.NewDashboard(),
+		dashboard:         dashboard,
 		scanner:           views.NewScanner(),
 		details:           views.NewDetails(),
-		watchlist:         views.NewWatchlistView(),
+		watchlist:         watchlistView,
+		portfolioView:     views.NewPortfolioView(portfolioMgr),
+		paperView:         views.NewPaperView(paperMgr, settings.Account),
 		historyView:       views.NewHistoryView(),
 		connectionView:    views.NewConnectionView(),
 		scanModeView:      views.NewScanModeView(),
 		alertsView:        views.NewAlertsView(alertsMgr),
 		filterView:        views.NewFilterView(),
 		helpView:          views.NewHelpView(),
-		engine:            screener.NewEngine(10), // 10 workers with rate limiting
+		engine:            engine,
 		historyMgr:        history.NewManager(),
 		alertsMgr:         alertsMgr,
+		portfolioMgr:      portfolioMgr,
+		paperMgr:          paperMgr,
+		account:           portfolio.NewAccount(settings.Account),
 		autoReloadSeconds: 60, // Default 60 seconds
+		universe:          active,
 	}
+	m.details.SetSizer(m.positionSize)
+	return m
+}
+
+// positionSize sizes a position in stock with its ATR stop for the account
+// in settings.json, counting the risk of the open portfolio positions
+func (m *Model) positionSize(stock *screener.ScreenResult) (portfolio.Sizing, error) {
+	summary := m.portfolioMgr.Summary(portfolio.Prices(m.results))
+	return m.account.Size(stock.Price, stock.StopLoss, summary.OpenRisk())
 }
 
 // Init initializes the model
@@ -182,20 +229,105 @@ func (m *Model) autoReloadTick() tea.Cmd {
 	})
 }
 
-// checkMarketStatus fetches the market status
-func (m *Model) checkMarketStatus() tea.Msg {
-	dns := os.Getenv("STOCKMAP_DNS")
-	client := fetcher.NewDirectYahooClientWithDNS(dns)
-	defer client.Close()
-	status := client.GetMarketStatus()
-	return MarketStatusMsg{Status: status}
+// clockTick returns a command refreshing the session countdown (30 seconds)
+func (m *Model) clockTick() tea.Cmd {
+	return tea.Tick(30*time.Second, func(t time.Time) tea.Msg {
+		return ClockTickMsg{}
+	})
+}
+
+// mainCalendar returns the trading calendar of the exchange most results
+// are listed on, or the US calendar
+func mainCalendar(results []*screener.ScreenResult) *calendar.Calendar {
+	counts := make(map[string]int)
+	best, bestCount := calendar.Default(), 0
+	for _, r := range results {
+		cal, ok := calendar.ForExchange(r.Exchange)
+		if !ok {
+			continue
+		}
+		counts[cal.Name]++
+		if counts[cal.Name] > bestCount {
+			best, bestCount = cal, counts[cal.Name]
+		}
+	}
+	return best
+}
+
+// marketInSession reports whether any exchange in the results is in its
+// regular session. Exchanges without a trading calendar count as open.
+func (m *Model) marketInSession(now time.Time) bool {
+	if len(m.results) == 0 {
+		return calendar.Default().IsOpen(now)
+	}
+	for _, r := range m.results {
+		cal, ok := calendar.ForExchange(r.Exchange)
+		if !ok || cal.IsOpen(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxStatusExchanges limits the exchanges whose session is checked
+const maxStatusExchanges = 6
+
+// checkMarketStatus returns a command reporting the session state of each
+// exchange in the results (the US market when there are none). States come
+// from the trading calendars; only exchanges without one ask the provider.
+func (m *Model) checkMarketStatus() tea.Cmd {
+	m.dashboard.SetCalendar(mainCalendar(m.results))
+
+	// One representative symbol per exchange, picked before the command runs
+	symbols := make(map[string]string)
+	var exchanges []string
+	for _, r := range m.results {
+		if r.Exchange == "" || r.HasError || len(exchanges) >= maxStatusExchanges {
+			continue
+		}
+		if _, ok := symbols[r.Exchange]; !ok {
+			symbols[r.Exchange] = r.Symbol
+			exchanges = append(exchanges, r.Exchange)
+		}
+	}
+
+	return func() tea.Msg {
+		now := time.Now()
+		if len(exchanges) == 0 {
+			return MarketStatusMsg{Status: calendar.Default().State(now)}
+		}
+
+		var client fetcher.Provider
+		states := make(map[string]string, len(exchanges))
+		for _, ex := range exchanges {
+			if cal, ok := calendar.ForExchange(ex); ok {
+				states[ex] = cal.State(now)
+				continue
+			}
+			if client == nil {
+				var err error
+				if client, err = fetcher.NewDefaultProvider(); err != nil {
+					states[ex] = "UNKNOWN"
+					continue
+				}
+				defer client.Close()
+			}
+			states[ex] = client.GetMarketStatus(symbols[ex])
+		}
+		return MarketStatusMsg{Status: states[exchanges[0]], Exchanges: states}
+	}
 }
 
 // runConnectionTest runs the connection test
 func (m *Model) runConnectionTest() tea.Cmd {
 	return func() tea.Msg {
-		dns := os.Getenv("STOCKMAP_DNS")
-		client := fetcher.NewDirectYahooClientWithDNS(dns)
+		client, err := fetcher.NewDefaultProvider()
+		if err != nil {
+			return ConnectionResultMsg{Result: &fetcher.ConnectionResult{
+				Error:   err.Error(),
+				Details: []string{"FAIL: " + err.Error()},
+			}}
+		}
 		defer client.Close()
 		result := client.CheckConnection()
 		return ConnectionResultMsg{Result: &result}
@@ -230,24 +362,7 @@ func (m *Model) startScan() tea.Cmd {
 		m.animationTick(),
 		// Start actual scan
 		func() tea.Msg {
-			// Use custom symbols if set, otherwise default
-			symbols := m.scanSymbols
-			if len(symbols) == 0 {
-				symbols = fetcher.DefaultSymbols()
-			}
-
-			// Always include watchlist symbols in scan
-			watchlistSymbols := m.engine.GetWatchlistManager().GetAll()
-			symbolSet := make(map[string]bool)
-			for _, s := range symbols {
-				symbolSet[s] = true
-			}
-			for _, s := range watchlistSymbols {
-				if !symbolSet[s] {
-					symbols = append(symbols, s)
-					symbolSet[s] = true
-				}
-			}
+			symbols := m.scanList()
 
 			total := len(symbols)
 
@@ -267,6 +382,41 @@ func (m *Model) startScan() tea.Cmd {
 	)
 }
 
+// scanList returns the symbols of the next scan: the custom symbols if set,
+// otherwise the universe, plus the watchlist and the open paper trades
+func (m *Model) scanList() []string {
+	// Use custom symbols if set, otherwise default
+	symbols := append([]string(nil), m.scanSymbols...)
+	if len(symbols) == 0 {
+		symbols = m.universe.Symbols()
+	}
+
+	// Always include watchlist symbols in scan
+	watchlistSymbols := m.engine.GetWatchlistManager().GetAll()
+	symbolSet := make(map[string]bool)
+	for _, s := range symbols {
+		symbolSet[s] = true
+	}
+	for _, s := range watchlistSymbols {
+		if !symbolSet[s] {
+			symbols = append(symbols, s)
+			symbolSet[s] = true
+		}
+	}
+
+	// And the open paper trades, whose stops and targets are checked
+	// even once their stock leaves the filter
+	if m.paperMgr.Active() {
+		for _, s := range m.paperMgr.OpenSymbols() {
+			if !symbolSet[s] {
+				symbols = append(symbols, s)
+				symbolSet[s] = true
+			}
+		}
+	}
+	return symbols
+}
+
 // saveHistory saves current results to history
 func (m *Model) saveHistory() tea.Cmd {
 	return func() tea.Msg {
@@ -279,9 +429,9 @@ func (m *Model) saveHistory() tea.Cmd {
 
 		// If this is a reload and we have a previous history ID, update instead of create new
 		if m.isReload && m.lastHistoryID != "" {
-			record, err = m.historyMgr.Update(m.lastHistoryID, m.results, m.totalScanned)
+			record, err = m.historyMgr.Update(m.lastHistoryID, m.results, m.totalScanned, m.engine.Scoring())
 		} else {
-			record, err = m.historyMgr.Save(m.results, m.totalScanned)
+			record, err = m.historyMgr.Save(m.results, m.totalScanned, m.engine.Scoring())
 		}
 
 		if err != nil {
@@ -303,6 +453,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.scanner.SetSize(msg.Width, msg.Height)
 		m.details.SetSize(msg.Width, msg.Height)
 		m.watchlist.SetSize(msg.Width, msg.Height)
+		m.portfolioView.SetSize(msg.Width, msg.Height)
+		m.paperView.SetSize(msg.Width, msg.Height)
 		m.historyView.SetSize(msg.Width, msg.Height)
 		m.connectionView.SetSize(msg.Width, msg.Height)
 		m.scanModeView.SetSize(msg.Width, msg.Height)
@@ -320,7 +472,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.splash.IsDone() {
 				m.currentView = ViewDashboard
 				// Try to load last history, if none exists, auto-scan
-				return m, tea.Batch(m.checkMarketStatus, m.loadLastHistoryOrScan())
+				return m, tea.Batch(m.checkMarketStatus(), m.clockTick(), m.loadLastHistoryOrScan())
 			}
 			return m, m.splashTick()
 		}
@@ -339,7 +491,19 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case ClockTickMsg:
+		// Re-rendering updates the header countdown
+		return m, m.clockTick()
+
 	case AutoReloadTickMsg:
+		// Auto-reload pauses while every exchange in the results is closed
+		if m.autoReload && !m.scanning && !m.marketInSession(time.Now()) {
+			m.autoReloadCounter = m.autoReloadSeconds
+			m.dashboard.SetAutoReloadPaused(true)
+			return m, m.autoReloadTick()
+		}
+		m.dashboard.SetAutoReloadPaused(false)
+
 		// Auto-reload countdown
 		if m.autoReload && !m.scanning {
 			m.autoReloadCounter--
@@ -371,10 +535,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case MarketStatusMsg:
 		m.dashboard.SetMarketState(msg.Status)
+		m.dashboard.SetExchangeStates(msg.Exchanges)
 		return m, nil
 
 	case ScanProgressMsg:
 		m.scanner.SetVerboseProgress(msg.Completed, msg.Total, msg.Current, msg.SuccessCount, msg.ErrorCount, msg.LastError, msg.ErrorSymbol)
+		m.scanner.SetLimiterStats(msg.Limiter)
 
 		// Update dashboard based on reload or regular scan
 		if m.isReload && m.currentView == ViewDashboard {
@@ -395,15 +561,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.scanning = false
 			m.results = m.engine.GetResults()
 			m.scanner.SetFoundCount(len(m.results))
+			m.dashboard.GetTable().SetSectorStats(m.engine.SectorStats())
 			m.dashboard.SetResults(m.results)
 			m.dashboard.SetScanning(false, "", msg.Completed)
 			m.dashboard.SetReloading(false) // Stop reload spinner
 			m.watchlist.SetResults(m.results)
+			m.portfolioView.SetResults(m.results)
 			m.loadedFromHistory = false
 			m.loadedHistoryID = ""
 
 			// Check alerts for each result
-			m.checkAlerts()
+			triggered := m.checkAlerts()
 
 			// Continue auto-reload timer if enabled
 			var cmds []tea.Cmd
@@ -415,6 +583,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.dashboard.SetMessage("Reloaded")
 				m.isReload = false
 			}
+			// Trade the alerts and high scores on paper
+			m.paperTrade(triggered)
 
 			if m.autoReload {
 				m.autoReloadCounter = m.autoReloadSeconds
@@ -435,11 +605,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.scanning = false
 		m.results = msg.Results
 		m.scanner.SetFoundCount(len(m.results))
+		m.dashboard.GetTable().SetSectorStats(m.engine.SectorStats())
 		m.dashboard.SetResults(m.results)
 		m.dashboard.SetScanning(false, "", len(m.results))
 		m.dashboard.SetReloading(false)
 		m.watchlist.SetResults(m.results)
-		return m, m.saveHistory()
+		m.portfolioView.SetResults(m.results)
+		return m, tea.Batch(m.saveHistory(), m.checkMarketStatus())
 
 	case HistorySavedMsg:
 		// Save the history ID for future reloads
@@ -480,10 +652,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.totalScanned = msg.Record.TotalScanned
 		m.dashboard.SetResults(m.results)
 		m.watchlist.SetResults(m.results)
+		m.portfolioView.SetResults(m.results)
 		m.loadedFromHistory = true
 		m.loadedHistoryID = msg.Record.ID
 		m.dashboard.SetMessage("Loaded: " + history.FormatTimestamp(msg.Record.Timestamp))
-		return m, nil
+		return m, m.checkMarketStatus()
 	}
 
 	return m, nil
@@ -502,6 +675,7 @@ func (m *Model) tickScan() tea.Cmd {
 			ErrorCount:   progress.ErrorCount,
 			LastError:    progress.LastError,
 			ErrorSymbol:  progress.ErrorSymbol,
+			Limiter:      progress.Limiter,
 		}
 	})
 }
@@ -513,7 +687,18 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Any key skips splash
 		m.splash.Skip()
 		m.currentView = ViewDashboard
-		return m, m.checkMarketStatus
+		return m, tea.Batch(m.checkMarketStatus(), m.clockTick())
+	}
+
+	// A view typing into an input gets every key but ctrl+c, so "q" can be
+	// typed and esc only closes the input
+	if msg.String() != "ctrl+c" {
+		switch {
+		case m.currentView == ViewFilter && m.filterView.IsInputActive():
+			return m.handleFilterKeys(msg)
+		case m.currentView == ViewPortfolio && m.portfolioView.IsInputActive():
+			return m.handlePortfolioKeys(msg)
+		}
 	}
 
 	// Global keys
@@ -543,6 +728,10 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleDetailsKeys(msg)
 	case ViewWatchlist:
 		return m.handleWatchlistKeys(msg)
+	case ViewPortfolio:
+		return m.handlePortfolioKeys(msg)
+	case ViewPaper:
+		return m.handlePaperKeys(msg)
 	case ViewHistory:
 		return m.handleHistoryKeys(msg)
 	case ViewConnection:
@@ -631,6 +820,8 @@ func (m *Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Show scan mode selection
 		m.scanModeView.Reset()
 		m.scanModeView.SetWatchlistCount(m.engine.GetWatchlistManager().Count())
+		universes, _ := universe.List()
+		m.scanModeView.SetUniverses(universes, m.universe.Name)
 		m.currentView = ViewScanMode
 		return m, nil
 
@@ -646,6 +837,18 @@ func (m *Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.watchlist.Refresh()
 		return m, nil
 
+	case "o", "O":
+		// Switch to portfolio view
+		m.currentView = ViewPortfolio
+		m.portfolioView.SetResults(m.results)
+		return m, nil
+
+	case "b", "B":
+		// Switch to paper trading view
+		m.currentView = ViewPaper
+		m.paperView.Refresh()
+		return m, nil
+
 	case "h", "H":
 		// Switch to history view
 		m.currentView = ViewHistory
@@ -742,9 +945,25 @@ func (m *Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.currentView = ViewAlerts
 		return m, nil
 
+	case "m", "M":
+		// Cycle strategy preset
+		m.cycleStrategy()
+		return m, nil
+
+	case "g", "G":
+		// Toggle grouping by sector
+		if m.dashboard.GetTable().ToggleGroupBySector() {
+			m.dashboard.SetMessage("Grouped by sector")
+		} else {
+			m.dashboard.SetMessage("Sector grouping off")
+		}
+		return m, nil
+
 	case "f", "F":
 		// Switch to filter view
 		m.filterView.SetCriteria(m.engine.GetCriteria())
+		m.filterView.SetDefaults(m.engine.Strategy().Criteria())
+		m.filterView.RefreshScreens()
 		m.currentView = ViewFilter
 		return m, nil
 
@@ -762,6 +981,7 @@ func (m *Model) handleDashboardKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.results = nil
 			m.dashboard.SetResults(m.results)
 			m.watchlist.SetResults(m.results)
+			m.portfolioView.SetResults(m.results)
 			m.totalScanned = 0
 			m.loadedFromHistory = false
 			m.loadedHistoryID = ""
@@ -933,6 +1153,77 @@ func (m *Model) handleWatchlistKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handlePortfolioKeys handles portfolio-specific keys
+func (m *Model) handlePortfolioKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Handle input mode
+	if m.portfolioView.IsInputActive() {
+		switch msg.String() {
+		case "esc":
+			m.portfolioView.ClearInput()
+			return m, nil
+		case "enter":
+			m.portfolioView.Submit(m.findStock(m.portfolioView.InputSymbol()))
+			return m, nil
+		case "tab", "down":
+			m.portfolioView.NextInputField()
+			return m, nil
+		case "shift+tab", "up":
+			m.portfolioView.PrevInputField()
+			return m, nil
+		case "backspace":
+			m.portfolioView.Backspace()
+			return m, nil
+		default:
+			if len(msg.String()) == 1 {
+				m.portfolioView.AddChar(rune(msg.String()[0]))
+			}
+			return m, nil
+		}
+	}
+
+	switch msg.String() {
+	case "a", "A":
+		// Record a purchase of the stock selected on the dashboard
+		m.portfolioView.StartAdd(m.dashboard.SelectedResult())
+		return m, nil
+
+	case "s", "S":
+		// Record a sale of the selected position
+		m.portfolioView.StartSell()
+		return m, nil
+
+	case "up", "k":
+		m.portfolioView.MoveUp()
+		return m, nil
+
+	case "down", "j":
+		m.portfolioView.MoveDown()
+		return m, nil
+
+	case "d", "D", "enter":
+		if pos := m.portfolioView.SelectedPosition(); pos != nil {
+			if stock := m.findStock(pos.Symbol); stock != nil {
+				m.details.SetStock(stock)
+				m.currentView = ViewDetails
+			}
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handlePaperKeys handles paper trading keys
+func (m *Model) handlePaperKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "s", "S":
+		m.paperView.Toggle()
+		return m, nil
+	}
+
+	return m, nil
+}
+
 // handleHistoryKeys handles history-specific keys
 func (m *Model) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
@@ -957,6 +1248,7 @@ func (m *Model) handleHistoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.totalScanned = record.TotalScanned
 		m.dashboard.SetResults(m.results)
 		m.watchlist.SetResults(m.results)
+		m.portfolioView.SetResults(m.results)
 		m.loadedFromHistory = true
 		m.loadedHistoryID = record.ID
 		m.dashboard.SetMessage("Loaded scan from " + history.FormatTimestamp(record.Timestamp))
@@ -1021,7 +1313,8 @@ func (m *Model) handleScanModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "1":
 		m.scanModeView.Reset()
-		// Scan all - start immediately
+		// Scan the selected universe - start immediately
+		m.selectUniverse(m.scanModeView.GetSelectedUniverse())
 		m.scanSymbols = nil
 		m.isReload = false // New scan, not reload
 		m.currentView = ViewScanner
@@ -1055,6 +1348,14 @@ func (m *Model) handleScanModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.scanModeView.MoveDown()
 		return m, nil
 
+	case "left", "h":
+		m.scanModeView.NextUniverse(-1)
+		return m, nil
+
+	case "right", "l":
+		m.scanModeView.NextUniverse(1)
+		return m, nil
+
 	case "tab":
 		// Toggle input for custom mode
 		if m.scanModeView.GetSelectedMode() == views.ScanModeCustom {
@@ -1067,6 +1368,7 @@ func (m *Model) handleScanModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		mode := m.scanModeView.GetSelectedMode()
 		switch mode {
 		case views.ScanModeAll:
+			m.selectUniverse(m.scanModeView.GetSelectedUniverse())
 			m.scanSymbols = nil
 		case views.ScanModeWatchlist:
 			watchlistSymbols := m.engine.GetWatchlistManager().GetAll()
@@ -1098,6 +1400,35 @@ func (m *Model) handleScanModeKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// selectUniverse makes u the scanned universe for this session
+func (m *Model) selectUniverse(u *universe.Universe) {
+	if u == nil {
+		return
+	}
+	m.universe = u
+	m.watchlist.SetCategories(u.Groups())
+	m.engine.SetClassifier(u.Classify)
+}
+
+// cycleStrategy switches to the next strategy preset, resetting the filter
+// criteria to its own; results are rescored on the next scan
+func (m *Model) cycleStrategy() {
+	list := screener.Strategies()
+	next := list[0]
+	for i, s := range list {
+		if s == m.engine.Strategy() {
+			next = list[(i+1)%len(list)]
+		}
+	}
+	m.engine.SetStrategy(next)
+	m.dashboard.SetStrategy(next.Name)
+	msg := "Strategy: " + next.Name + " (rescan to apply)"
+	if err := screener.StrategyLoadError(); err != nil {
+		msg += " · " + err.Error()
+	}
+	m.dashboard.SetMessage(msg)
+}
+
 // handleAlertsKeys handles alerts-specific keys
 func (m *Model) handleAlertsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Handle input mode
@@ -1109,10 +1440,12 @@ func (m *Model) handleAlertsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// Get current price for the symbol
 			lastPrice := 0.0
+			currency := ""
 			if stock := m.findStock(m.alertsView.SelectedAlert().Symbol); stock != nil {
 				lastPrice = stock.Price
+				currency = stock.Currency
 			}
-			m.alertsView.SubmitAlert(lastPrice)
+			m.alertsView.SubmitAlert(lastPrice, currency)
 			return m, nil
 		case "tab":
 			m.alertsView.NextInputField()
@@ -1228,15 +1561,25 @@ func (m *Model) handleFilterKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.filterView.Increment()
 		return m, nil
 
+	case "tab":
+		// Load the next saved screen into the expression
+		m.filterView.NextScreen()
+		return m, nil
+
 	case "r", "R":
-		// Reset to defaults
+		// Reset to the strategy's criteria
 		m.filterView.Reset()
 		return m, nil
 
 	case "esc":
 		// Apply criteria and go back
-		m.engine.SetCriteria(m.filterView.GetCriteria())
-		m.dashboard.SetMessage("Filter criteria updated")
+		criteria := m.filterView.GetCriteria()
+		m.engine.SetCriteria(criteria)
+		if criteria.Expression != nil {
+			m.dashboard.SetMessage("Filter criteria updated: " + criteria.Expression.String())
+		} else {
+			m.dashboard.SetMessage("Filter criteria updated")
+		}
 		m.currentView = ViewDashboard
 		return m, nil
 	}
@@ -1275,6 +1618,10 @@ func (m *Model) View() string {
 		return m.details.View()
 	case ViewWatchlist:
 		return m.watchlist.View()
+	case ViewPortfolio:
+		return m.portfolioView.View()
+	case ViewPaper:
+		return m.paperView.View()
 	case ViewHistory:
 		return m.historyView.View()
 	case ViewConnection:
@@ -1292,12 +1639,14 @@ func (m *Model) View() string {
 	}
 }
 
-// checkAlerts checks all results against active alerts
-func (m *Model) checkAlerts() {
+// checkAlerts checks all results against active alerts and returns the
+// alerts triggered by this check
+func (m *Model) checkAlerts() []alerts.TriggeredAlert {
+	var triggered []alerts.TriggeredAlert
 	for _, result := range m.results {
-		triggered := m.alertsMgr.CheckPrice(result.Symbol, result.Price, result.RSI)
-		m.triggeredAlerts = append(m.triggeredAlerts, triggered...)
+		triggered = append(triggered, m.alertsMgr.CheckPrice(result.Symbol, result.Price, result.RSI)...)
 	}
+	m.triggeredAlerts = append(m.triggeredAlerts, triggered...)
 
 	// Show notification if alerts were triggered
 	if len(m.triggeredAlerts) > 0 {
@@ -1308,6 +1657,33 @@ func (m *Model) checkAlerts() {
 		}
 		m.dashboard.SetMessage(msg)
 	}
+	return triggered
+}
+
+// paperTrade trades the completed scan or reload on paper when paper
+// trading is on
+func (m *Model) paperTrade(triggered []alerts.TriggeredAlert) {
+	// "stockmap paper" may have started or stopped it since the last reload
+	m.paperMgr.Load()
+	events, err := m.paperMgr.Process(m.engine.Scanned(), triggered, time.Now())
+	if err != nil {
+		m.dashboard.SetMessage("Paper trading: " + err.Error())
+		return
+	}
+	m.paperView.Refresh()
+
+	opened, closed := 0, 0
+	for _, e := range events {
+		switch e.Kind {
+		case paper.EventOpen:
+			opened++
+		case paper.EventClose:
+			closed++
+		}
+	}
+	if opened+closed > 0 {
+		m.dashboard.SetMessage("Paper trading: " + strconv.Itoa(opened) + " opened, " + strconv.Itoa(closed) + " closed. Press [B] to view.")
+	}
 }
 
 // Run starts the application
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/paper"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+	"github.com/febritecno/stockmap-cli/internal/ui/views"
+)
+
+// typeKeys sends each rune of s as a key press
+func typeKeys(m *Model, s string) {
+	for _, r := range s {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestFilterExpressionInput(t *testing.T) {
+	// The views open their files in ./config
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	m := &Model{
+		currentView: ViewFilter,
+		dashboard:   views.NewDashboard(),
+		filterView:  views.NewFilterView(),
+		engine:      screener.NewEngine(1),
+	}
+	for i := 0; i < int(views.FilterFieldExpression); i++ {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyDown})
+	}
+
+	// "q" is typed into the expression instead of leaving the view
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(m, "data_quality < 1")
+	if m.currentView != ViewFilter || !m.filterView.IsInputActive() {
+		t.Fatalf("typing left the filter input (view %d)", m.currentView)
+	}
+	if !strings.Contains(m.filterView.View(), "data_quality < 1_") {
+		t.Fatalf("typed text missing:\n%s", m.filterView.View())
+	}
+
+	// Esc closes the input, dropping the text, and stays in the view
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.filterView.IsInputActive() || m.currentView != ViewFilter {
+		t.Fatalf("esc left input active %v in view %d", m.filterView.IsInputActive(), m.currentView)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	typeKeys(m, `data_quality == "ok"`)
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	if e := m.engine.GetCriteria().Expression; e == nil || !strings.Contains(e.String(), "data_quality") {
+		t.Fatalf("expression = %v, want data_quality == \"ok\"", e)
+	}
+
+	// Outside the input esc leaves the view
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.currentView != ViewDashboard {
+		t.Errorf("esc stayed in view %d", m.currentView)
+	}
+}
+
+func TestPortfolioFormInput(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	m := &Model{
+		currentView:   ViewPortfolio,
+		dashboard:     views.NewDashboard(),
+		portfolioView: views.NewPortfolioView(portfolio.NewManager(filepath.Join(dir, "portfolio.json"))),
+	}
+
+	// "q" is a symbol letter in the form, not the key leaving the view
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	typeKeys(m, "qqq")
+	if m.currentView != ViewPortfolio || m.portfolioView.InputSymbol() != "QQQ" {
+		t.Fatalf("typing in the form left view %d with symbol %q", m.currentView, m.portfolioView.InputSymbol())
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.portfolioView.IsInputActive() || m.currentView != ViewPortfolio {
+		t.Fatalf("esc left input active %v in view %d", m.portfolioView.IsInputActive(), m.currentView)
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if m.currentView != ViewDashboard {
+		t.Errorf("q stayed in view %d", m.currentView)
+	}
+}
+
+func TestPaperTradeOutsideFilter(t *testing.T) {
+	fixtures, _ := filepath.Abs(filepath.Join("..", "fetcher", "testdata", "replay"))
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	provider := fetcher.NewReplayProvider(fixtures)
+	quote, err := provider.FetchQuote(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := paper.NewManager(filepath.Join(dir, "paper.json"))
+	settings := paper.Settings{Capital: 100000, MinScore: 100, MaxPositions: 5, RiskPercent: 1, MaxPositionPercent: 20}
+	if err := mgr.Start(&settings, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// An AAPL trade entered higher, with a stop above the recorded price
+	entry := &screener.ScreenResult{Symbol: "AAPL", Price: quote.Price + 10, ConfluenceScore: 100,
+		StopLoss: quote.Price + 5, TakeProfit: quote.Price + 30}
+	if _, err := mgr.Process([]*screener.ScreenResult{entry}, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reload covers MSFT only, and the filter lets nothing through
+	engine := screener.NewEngineWithProvider(1, provider)
+	engine.SetCriteria(screener.FilterCriteria{MinConfluence: 1000})
+	if err := engine.GetWatchlistManager().Clear(); err != nil {
+		t.Fatal(err)
+	}
+	m := &Model{
+		engine:      engine,
+		dashboard:   views.NewDashboard(),
+		paperMgr:    mgr,
+		paperView:   views.NewPaperView(mgr, config.AccountSettings{}),
+		scanSymbols: []string{"MSFT"},
+	}
+	symbols := m.scanList()
+	if len(symbols) != 2 || symbols[1] != "AAPL" {
+		t.Fatalf("scan symbols = %v, want the open trade's AAPL added", symbols)
+	}
+	if results := engine.Scan(symbols); len(results) != 0 {
+		t.Fatalf("expected the filter to leave nothing, got %d results", len(results))
+	}
+
+	m.paperTrade(nil)
+	trade := mgr.Journal().Trades[0]
+	if trade.Open() || trade.ExitReason != paper.ExitStop || trade.ExitPrice != quote.Price {
+		t.Errorf("trade = %+v, want stopped out at %.2f", trade, quote.Price)
+	}
+}
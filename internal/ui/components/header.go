@@ -2,9 +2,13 @@ package components
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/febritecno/stockmap-cli/internal/calendar"
 	"github.com/febritecno/stockmap-cli/internal/styles"
 )
 
@@ -14,7 +18,10 @@ type Header struct {
 	title       string
 	version     string
 	marketState string
+	exchanges   map[string]string  // Market state by exchange
+	calendar    *calendar.Calendar // Trading calendar of the main exchange (nil: provider state only)
 	strategy    string
+	now         func() time.Time
 }
 
 // NewHeader creates a new header component
@@ -23,7 +30,9 @@ func NewHeader() *Header {
 		title:       "STOCKMAP",
 		version:     "v1.0",
 		marketState: "CLOSED",
+		calendar:    calendar.Default(),
 		strategy:    "Deep Value",
+		now:         time.Now,
 	}
 }
 
@@ -37,6 +46,117 @@ func (h *Header) SetMarketState(state string) {
 	h.marketState = state
 }
 
+// SetExchangeStates updates the market state of each exchange in the results
+func (h *Header) SetExchangeStates(states map[string]string) {
+	h.exchanges = states
+}
+
+// SetStrategy sets the name of the active strategy
+func (h *Header) SetStrategy(name string) {
+	h.strategy = name
+}
+
+// SetCalendar sets the trading calendar of the main exchange, used for the
+// market state and the countdown to the next open or close
+func (h *Header) SetCalendar(c *calendar.Calendar) {
+	h.calendar = c
+}
+
+// isOpen reports whether a market state is a trading session
+func isOpen(state string) bool {
+	switch state {
+	case calendar.StateRegular, calendar.StatePre, calendar.StatePost:
+		return true
+	}
+	return false
+}
+
+// stateLabel returns the display name of a market state
+func stateLabel(state string) string {
+	switch state {
+	case calendar.StateRegular:
+		return "OPEN"
+	case calendar.StatePre:
+		return "PRE-MARKET"
+	case calendar.StatePost:
+		return "AFTER-HOURS"
+	default:
+		return "CLOSED"
+	}
+}
+
+// marketStatusView renders the market status: the main exchange's state with
+// a countdown to its next open or close, or the exchanges grouped by state
+// when they differ ("OPEN: LSE · CLOSED: NMS NYQ"). Exchanges with a trading
+// calendar are evaluated locally; others use the state their provider reported.
+func (h *Header) marketStatusView(compact bool) string {
+	now := h.now()
+
+	var open, closed []string
+	for ex, state := range h.exchanges {
+		if cal, ok := calendar.ForExchange(ex); ok {
+			state = cal.State(now)
+		}
+		if isOpen(state) {
+			open = append(open, ex)
+		} else {
+			closed = append(closed, ex)
+		}
+	}
+	if len(open) > 0 && len(closed) > 0 {
+		sort.Strings(open)
+		sort.Strings(closed)
+		return styles.MarketOpenStyle.Render("OPEN: "+strings.Join(open, " ")) +
+			styles.MutedStyle().Render(" · ") +
+			styles.MarketClosedStyle.Render("CLOSED: "+strings.Join(closed, " "))
+	}
+
+	state := h.marketState
+	label := "Market"
+	if h.calendar != nil {
+		state = h.calendar.State(now)
+		if h.calendar.Name != calendar.DefaultName {
+			label += " (" + h.calendar.Name + ")"
+		}
+	}
+
+	style := styles.MarketClosedStyle
+	if isOpen(state) {
+		style = styles.MarketOpenStyle
+	}
+	status := style.Render(label + ": " + stateLabel(state))
+	if h.calendar == nil || compact {
+		return status
+	}
+	return status + styles.MutedStyle().Render(" · "+h.countdown(state, now))
+}
+
+// countdown describes the time to the main exchange's next close (during the
+// regular session) or next open, naming today's holiday or early close
+func (h *Header) countdown(state string, now time.Time) string {
+	if state == calendar.StateRegular {
+		s, ok := h.calendar.NextSession(now)
+		if !ok {
+			return ""
+		}
+		text := "closes in " + calendar.FormatCountdown(s.Close.Sub(now))
+		if s.HalfDay != "" {
+			text += " (half day)"
+		}
+		return text
+	}
+
+	open, ok := h.calendar.NextOpen(now)
+	if !ok {
+		return ""
+	}
+	text := "opens in " + calendar.FormatCountdown(open.Sub(now))
+	if name, ok := h.calendar.Holiday(now); ok {
+		text = name + ", " + text
+	}
+	return text
+}
+
 // View renders the header
 func (h *Header) View() string {
 	// Logo
@@ -46,13 +166,7 @@ func (h *Header) View() string {
 	version := styles.MutedStyle().Render(h.version)
 
 	// Market status
-	var marketStatus string
-	switch h.marketState {
-	case "REGULAR", "PRE", "POST":
-		marketStatus = styles.MarketOpenStyle.Render("Market: OPEN")
-	default:
-		marketStatus = styles.MarketClosedStyle.Render("Market: CLOSED")
-	}
+	marketStatus := h.marketStatusView(h.width < 70)
 
 	// Strategy
 	strategy := styles.InfoStyle.Render("Strategy: " + h.strategy)
@@ -113,9 +227,43 @@ func RenderTitle(title string, width int) string {
 		lipgloss.NewStyle().Foreground(styles.ColorMuted).Render(right)
 }
 
-// FormatPrice formats a price with dollar sign
-func FormatPrice(price float64) string {
-	return fmt.Sprintf("$%.2f", price)
+// currencySymbols are the price prefixes of common quote currencies;
+// other currencies are prefixed with their ISO code
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "CN¥",
+	"KRW": "₩",
+	"INR": "₹",
+	"IDR": "Rp",
+	"CAD": "C$",
+	"AUD": "A$",
+	"NZD": "NZ$",
+	"HKD": "HK$",
+	"SGD": "S$",
+	"CHF": "CHF ",
+	"BRL": "R$",
+}
+
+// zeroDecimalCurrencies are quoted without minor units
+var zeroDecimalCurrencies = map[string]bool{"JPY": true, "KRW": true, "IDR": true}
+
+// FormatPrice formats a price in its currency ("$12.30", "£4.56", "¥1234").
+// An empty currency is treated as USD.
+func FormatPrice(price float64, currency string) string {
+	if currency == "" {
+		currency = "USD"
+	}
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency + " "
+	}
+	if zeroDecimalCurrencies[currency] {
+		return fmt.Sprintf("%s%.0f", symbol, price)
+	}
+	return fmt.Sprintf("%s%.2f", symbol, price)
 }
 
 // FormatLargeNumber formats large numbers with K/M/B suffix
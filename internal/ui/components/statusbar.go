@@ -24,7 +24,8 @@ type StatusBar struct {
 	isReloading   bool
 	reloadFrame   int
 	autoReload    bool
-	autoReloadSec int // seconds until next reload
+	autoReloadSec int  // seconds until next reload
+	autoPaused    bool // auto-reload waits for a trading session
 }
 
 // NewStatusBar creates a new status bar
@@ -71,6 +72,11 @@ func (s *StatusBar) SetAutoReload(enabled bool, secondsLeft int) {
 	s.autoReloadSec = secondsLeft
 }
 
+// SetAutoReloadPaused marks auto-reload as waiting for a trading session
+func (s *StatusBar) SetAutoReloadPaused(paused bool) {
+	s.autoPaused = paused
+}
+
 // SetMessage sets a custom message
 func (s *StatusBar) SetMessage(msg string) {
 	s.message = msg
@@ -155,6 +161,8 @@ func (s *StatusBar) View() string {
 		autoStatus := ""
 		if s.isReloading {
 			autoStatus = styles.ScoreHighStyle.Render(" [AUTO ON]")
+		} else if s.autoPaused {
+			autoStatus = styles.MutedStyle().Render(" [AUTO: paused, market closed]")
 		} else {
 			autoStatus = styles.ScoreHighStyle.Render(fmt.Sprintf(" [AUTO: %ds]", s.autoReloadSec))
 		}
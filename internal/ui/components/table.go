@@ -21,8 +21,12 @@ const (
 	SortByChange
 	SortByRSI
 	SortByVolatility
+	SortByRank
 )
 
+// TopDecileBadge marks results whose rank score is in the top decile
+const TopDecileBadge = "▲"
+
 // Column represents a table column
 type Column struct {
 	Title    string
@@ -45,21 +49,25 @@ type Table struct {
 	sortColumn   SortColumn
 	sortAsc      bool
 	compactMode  bool // Use compact layout for narrow screens
+
+	groupBySector bool                            // Sort by sector first, with a header per sector
+	sectorStats   map[string]screener.SectorStats // Aggregates shown in the sector headers
 }
 
 // NewTable creates a new table component
 func NewTable() *Table {
 	return &Table{
 		columns: []Column{
-			{Title: "", MinWidth: 2, MaxWidth: 2},       // Pin
-			{Title: "TICKER", MinWidth: 5, MaxWidth: 8}, // Ticker
-			{Title: "PRICE", MinWidth: 7, MaxWidth: 10}, // Price
-			{Title: "CHG%", MinWidth: 6, MaxWidth: 8},   // Change
-			{Title: "TP", MinWidth: 7, MaxWidth: 10},    // Take Profit
-			{Title: "SL", MinWidth: 7, MaxWidth: 10},    // Stop Loss
-			{Title: "RSI", MinWidth: 4, MaxWidth: 6},    // RSI
-			{Title: "VL%", MinWidth: 4, MaxWidth: 6},    // Volatility
-			{Title: "SCORE", MinWidth: 6, MaxWidth: 12}, // Score
+			{Title: "", MinWidth: 2, MaxWidth: 2},        // Pin
+			{Title: "TICKER", MinWidth: 5, MaxWidth: 8},  // Ticker
+			{Title: "PRICE", MinWidth: 7, MaxWidth: 10},  // Price
+			{Title: "CHG%", MinWidth: 6, MaxWidth: 8},    // Change
+			{Title: "TP", MinWidth: 7, MaxWidth: 10},     // Take Profit
+			{Title: "SL", MinWidth: 7, MaxWidth: 10},     // Stop Loss
+			{Title: "RSI", MinWidth: 4, MaxWidth: 6},     // RSI
+			{Title: "VL%", MinWidth: 4, MaxWidth: 6},     // Volatility
+			{Title: "SCORE", MinWidth: 6, MaxWidth: 12},  // Score
+			{Title: "SECTOR", MinWidth: 6, MaxWidth: 14}, // Sector
 		},
 		height: 15,
 	}
@@ -166,8 +174,8 @@ func (t *Table) MoveDown() {
 	if t.cursor < len(displayRows)-1 {
 		t.cursor++
 		visibleRows := t.height - 3
-		if t.cursor >= t.offset+visibleRows {
-			t.offset = t.cursor - visibleRows + 1
+		for t.offset < t.cursor && t.linesTo(displayRows, t.cursor) > visibleRows {
+			t.offset++
 		}
 	}
 }
@@ -206,25 +214,74 @@ func (t *Table) View() string {
 		visibleRows = 10
 	}
 
-	endIdx := t.offset + visibleRows
-	if endIdx > len(displayRows) {
-		endIdx = len(displayRows)
-	}
-
-	// Render rows
-	for i := t.offset; i < endIdx; i++ {
+	// Render rows, with a sector header before each group when grouped
+	lines := 0
+	for i := t.offset; i < len(displayRows) && lines < visibleRows; i++ {
+		if t.startsGroup(displayRows, i) {
+			b.WriteString(t.renderSectorHeader(sectorKey(displayRows[i])))
+			b.WriteString("\n")
+			if lines++; lines == visibleRows {
+				break
+			}
+		}
 		b.WriteString(t.renderRowData(displayRows[i], i == t.cursor))
 		b.WriteString("\n")
+		lines++
 	}
 
 	// Fill remaining space
-	for i := endIdx - t.offset; i < visibleRows; i++ {
+	for ; lines < visibleRows; lines++ {
 		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
+// startsGroup reports whether a sector header is drawn above row i: at the
+// top of the view and wherever the sector changes
+func (t *Table) startsGroup(rows []*screener.ScreenResult, i int) bool {
+	if !t.groupBySector {
+		return false
+	}
+	return i == t.offset || sectorKey(rows[i]) != sectorKey(rows[i-1])
+}
+
+// linesTo returns the lines from the top of the view to row i, headers included
+func (t *Table) linesTo(rows []*screener.ScreenResult, i int) int {
+	lines := 0
+	for j := t.offset; j <= i; j++ {
+		if t.startsGroup(rows, j) {
+			lines++
+		}
+		lines++
+	}
+	return lines
+}
+
+// renderSectorHeader renders the header line of a sector group with the
+// sector's aggregates from the last scan
+func (t *Table) renderSectorHeader(sector string) string {
+	text := "▸ " + sector
+	if s, ok := t.sectorStats[sector]; ok {
+		text += fmt.Sprintf("  %d/%d passing · RSI avg %.0f", s.Passing, s.Count, s.AvgRSI)
+		if s.MedianPBV > 0 {
+			text += fmt.Sprintf(" · P/B med %.2f", s.MedianPBV)
+		}
+		if s.MedianPE > 0 {
+			text += fmt.Sprintf(" · P/E med %.1f", s.MedianPE)
+		}
+	}
+	return styles.TitleStyle.Render(truncate(text, t.width-2))
+}
+
+// sectorKey returns the group a row is listed under
+func sectorKey(r *screener.ScreenResult) string {
+	if r.Sector == "" {
+		return screener.UntaggedSector
+	}
+	return r.Sector
+}
+
 // renderHeader renders the table header
 func (t *Table) renderHeader() string {
 	cells := make([]string, len(t.columns))
@@ -269,7 +326,7 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 		baseStyle = lipgloss.NewStyle().Foreground(styles.ColorWarning)
 	}
 
-	// Column 0: Pin indicator
+	// Column 0: Pin indicator and top decile badge
 	pinText := " "
 	if row.IsPinned {
 		if selected {
@@ -278,6 +335,13 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 			pinText = styles.PinStyle.Render("*")
 		}
 	}
+	if row.TopDecile {
+		if selected {
+			pinText += TopDecileBadge
+		} else {
+			pinText += styles.TopDecileStyle.Render(TopDecileBadge)
+		}
+	}
 	cells[0] = lipgloss.NewStyle().Width(t.getColWidth(0)).Render(pinText)
 
 	// Column 1: Ticker
@@ -288,7 +352,7 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 	cells[1] = tickerStyle.Width(t.getColWidth(1)).Render(truncate(row.Symbol, t.getColWidth(1)))
 
 	// Column 2: Price
-	priceText := fmt.Sprintf("$%.2f", row.Price)
+	priceText := FormatPrice(row.Price, row.Currency)
 	if t.getColWidth(2) < 8 {
 		priceText = fmt.Sprintf("%.1f", row.Price)
 	}
@@ -307,7 +371,7 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 	cells[3] = changeStyle.Width(t.getColWidth(3)).Render(truncate(changeText, t.getColWidth(3)))
 
 	// Column 4: Take Profit
-	tpText := fmt.Sprintf("$%.2f", row.TakeProfit)
+	tpText := FormatPrice(row.TakeProfit, row.Currency)
 	if t.getColWidth(4) < 8 {
 		tpText = fmt.Sprintf("%.1f", row.TakeProfit)
 	}
@@ -318,7 +382,7 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 	cells[4] = tpStyle.Width(t.getColWidth(4)).Render(truncate(tpText, t.getColWidth(4)))
 
 	// Column 5: Stop Loss
-	slText := fmt.Sprintf("$%.2f", row.StopLoss)
+	slText := FormatPrice(row.StopLoss, row.Currency)
 	if t.getColWidth(5) < 8 {
 		slText = fmt.Sprintf("%.1f", row.StopLoss)
 	}
@@ -383,6 +447,13 @@ func (t *Table) renderRowData(row *screener.ScreenResult, selected bool) string
 		cells[8] = scoreStyle.Width(scoreWidth).Render(truncate(scoreText, scoreWidth))
 	}
 
+	// Column 9: Sector
+	sectorStyle := baseStyle
+	if !selected {
+		sectorStyle = lipgloss.NewStyle().Foreground(styles.ColorMuted)
+	}
+	cells[9] = sectorStyle.Width(t.getColWidth(9)).Render(truncate(row.Sector, t.getColWidth(9)))
+
 	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
 }
 
@@ -468,7 +539,7 @@ func (t *Table) applyFilter() {
 
 // CycleSort cycles through sort columns
 func (t *Table) CycleSort() {
-	t.sortColumn = (t.sortColumn + 1) % 6
+	t.sortColumn = (t.sortColumn + 1) % 7
 	t.sortAsc = false
 	t.applySort()
 }
@@ -479,9 +550,25 @@ func (t *Table) ToggleSortDirection() {
 	t.applySort()
 }
 
+// ToggleGroupBySector switches grouping by sector and reports whether it is on
+func (t *Table) ToggleGroupBySector() bool {
+	t.groupBySector = !t.groupBySector
+	t.cursor, t.offset = 0, 0
+	t.applySort()
+	return t.groupBySector
+}
+
+// SetSectorStats sets the sector aggregates shown in the group headers
+func (t *Table) SetSectorStats(stats []screener.SectorStats) {
+	t.sectorStats = make(map[string]screener.SectorStats, len(stats))
+	for _, s := range stats {
+		t.sectorStats[s.Sector] = s
+	}
+}
+
 // GetSortInfo returns current sort column name and direction
 func (t *Table) GetSortInfo() (string, bool) {
-	names := []string{"Score", "Ticker", "Price", "Change", "RSI", "Volatility"}
+	names := []string{"Score", "Ticker", "Price", "Change", "RSI", "Volatility", "Rank"}
 	return names[t.sortColumn], t.sortAsc
 }
 
@@ -493,6 +580,17 @@ func (t *Table) applySort() {
 	}
 
 	sort.Slice(rowsToSort, func(i, j int) bool {
+		// Grouped by sector, untagged last; pinned items first within a group
+		if t.groupBySector {
+			a, b := sectorKey(rowsToSort[i]), sectorKey(rowsToSort[j])
+			if a != b {
+				if a == screener.UntaggedSector || b == screener.UntaggedSector {
+					return b == screener.UntaggedSector
+				}
+				return a < b
+			}
+		}
+
 		// Pinned items always first
 		if rowsToSort[i].IsPinned && !rowsToSort[j].IsPinned {
 			return true
@@ -508,13 +606,15 @@ func (t *Table) applySort() {
 		case SortByTicker:
 			less = rowsToSort[i].Symbol < rowsToSort[j].Symbol
 		case SortByPrice:
-			less = rowsToSort[i].Price < rowsToSort[j].Price
+			less = rowsToSort[i].BasePrice() < rowsToSort[j].BasePrice()
 		case SortByChange:
 			less = rowsToSort[i].ChangePercent < rowsToSort[j].ChangePercent
 		case SortByRSI:
 			less = rowsToSort[i].RSI < rowsToSort[j].RSI
 		case SortByVolatility:
 			less = rowsToSort[i].Volatility < rowsToSort[j].Volatility
+		case SortByRank:
+			less = rowsToSort[i].RankScore < rowsToSort[j].RankScore
 		}
 
 		if t.sortAsc {
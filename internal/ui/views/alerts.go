@@ -214,7 +214,7 @@ func (a *AlertsView) CycleAlertType() {
 }
 
 // SubmitAlert creates a new alert from current input
-func (a *AlertsView) SubmitAlert(lastPrice float64) error {
+func (a *AlertsView) SubmitAlert(lastPrice float64, currency string) error {
 	if a.newSymbol == "" {
 		a.message = "Symbol required"
 		return nil
@@ -230,7 +230,7 @@ func (a *AlertsView) SubmitAlert(lastPrice float64) error {
 		return nil
 	}
 
-	_, err := a.alertsMgr.Add(a.newSymbol, a.newType, threshold, lastPrice)
+	_, err := a.alertsMgr.Add(a.newSymbol, a.newType, threshold, lastPrice, currency)
 	if err != nil {
 		return err
 	}
@@ -279,10 +279,10 @@ func (a *AlertsView) View() string {
 		b.WriteString("\n")
 		for _, ta := range a.triggered {
 			icon := styles.ScoreHighStyle.Render("!")
-			msg := fmt.Sprintf("%s %s @ $%.2f - %s",
+			msg := fmt.Sprintf("%s %s @ %s - %s",
 				ta.Alert.Symbol,
 				alerts.FormatAlertType(ta.Alert.Type),
-				ta.CurrentPrice,
+				components.FormatPrice(ta.CurrentPrice, ta.Alert.Currency),
 				ta.Alert.Message,
 			)
 			b.WriteString(fmt.Sprintf("  %s %s\n", icon, msg))
@@ -357,10 +357,10 @@ func (a *AlertsView) View() string {
 			}
 
 			// Alert info
-			info := fmt.Sprintf("%s %s $%.2f",
+			info := fmt.Sprintf("%s %s %s",
 				alert.Symbol,
 				alerts.FormatAlertType(alert.Type),
-				alert.Threshold,
+				formatThreshold(alert),
 			)
 
 			b.WriteString(fmt.Sprintf("%s%s %s\n", cursor, status, info))
@@ -384,3 +384,16 @@ func (a *AlertsView) View() string {
 
 	return b.String()
 }
+
+// formatThreshold formats an alert threshold: a price in the alert's
+// currency, a percent change or an RSI level
+func formatThreshold(alert *alerts.Alert) string {
+	switch alert.Type {
+	case alerts.AlertChange:
+		return fmt.Sprintf("%.1f%%", alert.Threshold)
+	case alerts.AlertRSILow, alerts.AlertRSIHigh:
+		return fmt.Sprintf("%.0f", alert.Threshold)
+	default:
+		return components.FormatPrice(alert.Threshold, alert.Currency)
+	}
+}
@@ -3,6 +3,7 @@ package views
 import (
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/febritecno/stockmap-cli/internal/calendar"
 	"github.com/febritecno/stockmap-cli/internal/screener"
 	"github.com/febritecno/stockmap-cli/internal/styles"
 	"github.com/febritecno/stockmap-cli/internal/ui/components"
@@ -53,6 +54,26 @@ func (d *Dashboard) SetMarketState(state string) {
 	d.header.SetMarketState(state)
 }
 
+// SetExchangeStates updates the market status of each exchange
+func (d *Dashboard) SetExchangeStates(states map[string]string) {
+	d.header.SetExchangeStates(states)
+}
+
+// SetStrategy sets the strategy shown in the header
+func (d *Dashboard) SetStrategy(name string) {
+	d.header.SetStrategy(name)
+}
+
+// SetCalendar sets the trading calendar shown in the header
+func (d *Dashboard) SetCalendar(c *calendar.Calendar) {
+	d.header.SetCalendar(c)
+}
+
+// SetAutoReloadPaused shows auto-reload as paused outside trading sessions
+func (d *Dashboard) SetAutoReloadPaused(paused bool) {
+	d.statusBar.SetAutoReloadPaused(paused)
+}
+
 // SetScanning updates scanning state
 func (d *Dashboard) SetScanning(scanning bool, symbol string, scanned int) {
 	d.statusBar.SetScanning(scanning, symbol)
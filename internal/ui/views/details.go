@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
 	"github.com/febritecno/stockmap-cli/internal/screener"
 	"github.com/febritecno/stockmap-cli/internal/styles"
 	"github.com/febritecno/stockmap-cli/internal/ui/components"
@@ -18,6 +21,7 @@ type Details struct {
 	height    int
 	stock     *screener.ScreenResult
 	showChart bool
+	sizer     func(*screener.ScreenResult) (portfolio.Sizing, error)
 }
 
 // NewDetails creates a new details view
@@ -36,6 +40,12 @@ func (d *Details) SetStock(stock *screener.ScreenResult) {
 	d.stock = stock
 }
 
+// SetSizer sets how the position sizing panel sizes a position in a stock;
+// without one the panel is hidden
+func (d *Details) SetSizer(sizer func(*screener.ScreenResult) (portfolio.Sizing, error)) {
+	d.sizer = sizer
+}
+
 // ToggleChart toggles the chart view
 func (d *Details) ToggleChart() {
 	d.showChart = !d.showChart
@@ -95,20 +105,30 @@ func (d *Details) renderDetailsView(s *screener.ScreenResult) string {
 	var b strings.Builder
 
 	// Price section
-	priceSection := d.renderSection("PRICE", [][]string{
-		{"Current", fmt.Sprintf("$%.2f", s.Price)},
+	priceRows := [][]string{
+		{"Current", formatPrice(s, s.Price)},
 		{"Change", fmt.Sprintf("%+.2f (%.2f%%)", s.Change, s.ChangePercent)},
 		{"Volume", components.FormatLargeNumber(s.Volume)},
 		{"Market Cap", components.FormatLargeNumber(s.MarketCap)},
-	})
+	}
+	if s.FXRate > 0 && s.BaseCurrency != s.Currency {
+		priceRows = append(priceRows, []string{"In " + s.BaseCurrency, components.FormatPrice(s.BasePrice(), s.BaseCurrency)})
+	}
+	if s.Sector != "" || s.Industry != "" {
+		priceRows = append(priceRows, []string{"Sector", classificationText(s)})
+	}
+	priceSection := d.renderSection("PRICE", priceRows)
 
 	// Technical section
 	technicalSection := d.renderSection("TECHNICAL", [][]string{
 		{"RSI (14)", fmt.Sprintf("%.1f", s.RSI)},
 		{"ATR (14)", fmt.Sprintf("%.2f", s.ATR)},
-		{"SMA 20", fmt.Sprintf("$%.2f", s.SMA20)},
-		{"SMA 50", fmt.Sprintf("$%.2f", s.SMA50)},
+		{"SMA 20", formatPrice(s, s.SMA20)},
+		{"SMA 50", formatPrice(s, s.SMA50)},
+		{"SMA 200", formatKnownPrice(s, s.SMA200)},
 		{"Volatility", fmt.Sprintf("%.1f%%", s.Volatility)},
+		{"Momentum", formatKnown(s.Momentum, "%+.1f%%")},
+		{"Data", dataQualityText(s)},
 	})
 
 	// MACD section
@@ -119,21 +139,27 @@ func (d *Details) renderDetailsView(s *screener.ScreenResult) string {
 
 	// Valuation section
 	valuationSection := d.renderSection("VALUATION", [][]string{
-		{"P/B Ratio", fmt.Sprintf("%.2f", s.PBV)},
-		{"P/E Ratio", fmt.Sprintf("%.2f", s.PERatio)},
-		{"EPS", fmt.Sprintf("$%.2f", s.EPS)},
-		{"Book Value", fmt.Sprintf("$%.2f", s.BookValue)},
-		{"Graham Number", fmt.Sprintf("$%.2f", s.GrahamNumber)},
-		{"Graham Upside", fmt.Sprintf("%.1f%%", s.GrahamUpside)},
+		{"P/B Ratio", formatKnown(s.PBV, "%.2f")},
+		{"P/E Ratio", formatKnown(s.PERatio, "%.2f")},
+		{"EPS", formatKnownPrice(s, s.EPS)},
+		{"Book Value", formatKnownPrice(s, s.BookValue)},
+		{"Div Yield", formatKnown(s.DividendYield, "%.2f%%")},
+		{"Graham Number", formatKnownPrice(s, s.GrahamNumber)},
+		{"Graham Upside", formatKnown(s.GrahamUpside, "%.1f%%")},
+		{"P/B vs Sector", formatKnown(s.RelativePBV, "%.2fx median")},
+		{"P/E vs Sector", formatKnown(s.RelativePE, "%.2fx median")},
 	})
 
 	// Risk section
 	riskSection := d.renderSection("RISK/REWARD", [][]string{
-		{"Take Profit", fmt.Sprintf("$%.2f", s.TakeProfit)},
-		{"Stop Loss", fmt.Sprintf("$%.2f", s.StopLoss)},
+		{"Take Profit", formatPrice(s, s.TakeProfit)},
+		{"Stop Loss", formatPrice(s, s.StopLoss)},
 		{"Risk:Reward", fmt.Sprintf("1:%.1f", s.RiskRatio)},
 	})
 
+	// Position sizing below the risk section
+	riskSection += d.renderSizingSection(s)
+
 	// Score section
 	scoreSection := d.renderScoreSection(s)
 
@@ -171,8 +197,20 @@ func (d *Details) renderDetailsView(s *screener.ScreenResult) string {
 	}
 	b.WriteString("\n\n")
 
-	// Score section (full width)
-	b.WriteString(scoreSection)
+	// Score and "why this score" sections, side by side on wide screens
+	b.WriteString(components.RenderDivider(d.width))
+	b.WriteString("\n")
+	whySection := d.renderWhySection(s)
+	if d.width >= 100 {
+		colWidth := (d.width - 2) / 2
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(colWidth).Render(scoreSection), "  ",
+			lipgloss.NewStyle().Width(colWidth).Render(whySection)))
+	} else {
+		b.WriteString(scoreSection)
+		b.WriteString("\n")
+		b.WriteString(whySection)
+	}
 	b.WriteString("\n\n")
 
 	// Signals
@@ -235,9 +273,9 @@ func (d *Details) renderBollingerSection(s *screener.ScreenResult) string {
 	b.WriteString(styles.TitleStyle.Render("BOLLINGER (20,2)"))
 	b.WriteString("\n")
 
-	b.WriteString("  " + styles.MutedStyle().Render("Upper: ") + styles.InfoStyle.Render(fmt.Sprintf("$%.2f", s.BBUpper)) + "\n")
-	b.WriteString("  " + styles.MutedStyle().Render("Middle: ") + styles.InfoStyle.Render(fmt.Sprintf("$%.2f", s.BBMiddle)) + "\n")
-	b.WriteString("  " + styles.MutedStyle().Render("Lower: ") + styles.InfoStyle.Render(fmt.Sprintf("$%.2f", s.BBLower)) + "\n")
+	b.WriteString("  " + styles.MutedStyle().Render("Upper: ") + styles.InfoStyle.Render(formatPrice(s, s.BBUpper)) + "\n")
+	b.WriteString("  " + styles.MutedStyle().Render("Middle: ") + styles.InfoStyle.Render(formatPrice(s, s.BBMiddle)) + "\n")
+	b.WriteString("  " + styles.MutedStyle().Render("Lower: ") + styles.InfoStyle.Render(formatPrice(s, s.BBLower)) + "\n")
 
 	// Band width
 	b.WriteString("  " + styles.MutedStyle().Render("Width: ") + styles.InfoStyle.Render(fmt.Sprintf("%.1f%%", s.BBWidth)) + "\n")
@@ -269,10 +307,17 @@ func (d *Details) renderBollingerSection(s *screener.ScreenResult) string {
 func (d *Details) renderChart(s *screener.ScreenResult) string {
 	var b strings.Builder
 
-	b.WriteString(styles.TitleStyle.Render("PRICE CHART (60 Days)"))
+	prices := s.HistoricalPrices
+
+	title := fmt.Sprintf("PRICE CHART (%d bars)", len(prices))
+	if len(s.Bars) == len(prices) && len(prices) > 0 {
+		layout := chartDateLayout(s.Bars)
+		title = fmt.Sprintf("PRICE CHART (%s - %s)",
+			s.Bars[0].Time.Format(layout), s.Bars[len(s.Bars)-1].Time.Format(layout))
+	}
+	b.WriteString(styles.TitleStyle.Render(title))
 	b.WriteString("\n\n")
 
-	prices := s.HistoricalPrices
 	if len(prices) == 0 {
 		b.WriteString(centerText(styles.MutedStyle().Render("No historical data available"), d.width))
 		b.WriteString("\n\n")
@@ -287,7 +332,11 @@ func (d *Details) renderChart(s *screener.ScreenResult) string {
 	chartHeight := 12
 
 	// Sample prices to fit width
-	sampledPrices := samplePrices(prices, chartWidth)
+	indices := sampleIndices(len(prices), chartWidth)
+	sampledPrices := make([]float64, len(indices))
+	for i, idx := range indices {
+		sampledPrices[i] = prices[idx]
+	}
 
 	// Find min and max
 	minPrice, maxPrice := sampledPrices[0], sampledPrices[0]
@@ -343,9 +392,7 @@ func (d *Details) renderChart(s *screener.ScreenResult) string {
 
 	// X-axis labels
 	b.WriteString(strings.Repeat(" ", 8))
-	b.WriteString(styles.MutedStyle().Render("60d ago"))
-	b.WriteString(strings.Repeat(" ", len(sampledPrices)-14))
-	b.WriteString(styles.MutedStyle().Render("Today"))
+	b.WriteString(styles.MutedStyle().Render(d.chartAxisLabels(s, prices, indices)))
 	b.WriteString("\n\n")
 
 	// Strategy visualization
@@ -482,11 +529,11 @@ func (d *Details) renderStrategyLevels(s *screener.ScreenResult) string {
 	b.WriteString("\n")
 
 	// Stop Loss and Take Profit
-	b.WriteString(fmt.Sprintf("  %s $%.2f  |  %s $%.2f  |  R:R 1:%.1f\n",
+	b.WriteString(fmt.Sprintf("  %s %s  |  %s %s  |  R:R 1:%.1f\n",
 		styles.ScoreLowStyle.Render("Stop Loss:"),
-		s.StopLoss,
+		formatPrice(s, s.StopLoss),
 		styles.ScoreHighStyle.Render("Take Profit:"),
-		s.TakeProfit,
+		formatPrice(s, s.TakeProfit),
 		s.RiskRatio,
 	))
 
@@ -501,7 +548,7 @@ func (d *Details) renderStrategyLevels(s *screener.ScreenResult) string {
 				b.WriteString(" | ")
 			}
 			pct := (s.Price - sup) / s.Price * 100
-			b.WriteString(fmt.Sprintf("S%d: $%.2f (%.1f%%)", i+1, sup, pct))
+			b.WriteString(fmt.Sprintf("S%d: %s (%.1f%%)", i+1, formatPrice(s, sup), pct))
 		}
 	} else {
 		b.WriteString("N/A")
@@ -519,7 +566,7 @@ func (d *Details) renderStrategyLevels(s *screener.ScreenResult) string {
 				b.WriteString(" | ")
 			}
 			pct := (res - s.Price) / s.Price * 100
-			b.WriteString(fmt.Sprintf("R%d: $%.2f (+%.1f%%)", i+1, res, pct))
+			b.WriteString(fmt.Sprintf("R%d: %s (+%.1f%%)", i+1, formatPrice(s, res), pct))
 		}
 	} else {
 		b.WriteString("N/A")
@@ -582,24 +629,120 @@ func calculateSupportResistance(prices []float64, currentPrice float64) (support
 	return support, resistance
 }
 
-// samplePrices samples prices to fit a given width
-func samplePrices(prices []float64, width int) []float64 {
-	if len(prices) <= width {
-		return prices
+// chartAxisLabels returns the x-axis label line for the sampled chart columns.
+// With bar timestamps it shows dates at the start, middle and end; otherwise
+// it falls back to a bar count.
+func (d *Details) chartAxisLabels(s *screener.ScreenResult, prices []float64, indices []int) string {
+	width := len(indices)
+
+	if len(s.Bars) != len(prices) {
+		left := fmt.Sprintf("%d bars ago", len(prices))
+		right := "Now"
+		return left + strings.Repeat(" ", max(width-len(left)-len(right), 1)) + right
 	}
 
-	sampled := make([]float64, width)
-	step := float64(len(prices)) / float64(width)
+	layout := chartDateLayout(s.Bars)
+	line := []byte(strings.Repeat(" ", width))
+
+	// Place labels at the start, middle and end columns when they fit
+	place := func(col int, label string) {
+		if col < 0 {
+			col = 0
+		}
+		if col+len(label) > len(line) {
+			line = append(line, strings.Repeat(" ", col+len(label)-len(line))...)
+		}
+		copy(line[col:], label)
+	}
 
+	first := s.Bars[indices[0]].Time.Format(layout)
+	last := s.Bars[indices[width-1]].Time.Format(layout)
+	place(0, first)
+	if mid := s.Bars[indices[width/2]].Time.Format(layout); width >= 3*len(mid)+4 {
+		place(width/2-len(mid)/2, mid)
+	}
+	if width >= len(first)+len(last)+2 {
+		place(width-len(last), last)
+	}
+
+	return string(line)
+}
+
+// chartDateLayout picks a time layout for chart labels: with time of day for
+// intraday bars, dates otherwise
+func chartDateLayout(bars []fetcher.Bar) string {
+	if len(bars) > 1 && bars[1].Time.Sub(bars[0].Time) < 20*time.Hour {
+		return "01-02 15:04"
+	}
+	return "2006-01-02"
+}
+
+// sampleIndices picks up to width evenly spaced indices from a series of n points
+func sampleIndices(n, width int) []int {
+	if n <= width {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	indices := make([]int, width)
+	step := float64(n) / float64(width)
 	for i := 0; i < width; i++ {
 		idx := int(float64(i) * step)
-		if idx >= len(prices) {
-			idx = len(prices) - 1
+		if idx >= n {
+			idx = n - 1
 		}
-		sampled[i] = prices[idx]
+		indices[i] = idx
 	}
+	// Always end on the latest bar
+	indices[width-1] = n - 1
 
-	return sampled
+	return indices
+}
+
+// dataQualityText summarises the history data quality for the technical section
+func dataQualityText(s *screener.ScreenResult) string {
+	if len(s.HistoricalPrices) == 0 {
+		return "N/A"
+	}
+	if s.Quality.OK() {
+		return fmt.Sprintf("OK (%d bars)", len(s.HistoricalPrices))
+	}
+	return strings.Join(s.Quality.Issues(), ", ")
+}
+
+// classificationText returns "Sector / Industry", either part omitted when unknown
+func classificationText(s *screener.ScreenResult) string {
+	switch {
+	case s.Industry == "":
+		return s.Sector
+	case s.Sector == "":
+		return s.Industry
+	}
+	return s.Sector + " / " + s.Industry
+}
+
+// formatKnown formats a valuation value, showing N/A when it is unknown (0)
+func formatKnown(val float64, format string) string {
+	if val == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf(format, val)
+}
+
+// formatPrice formats a price in the stock's currency
+func formatPrice(s *screener.ScreenResult, price float64) string {
+	return components.FormatPrice(price, s.Currency)
+}
+
+// formatKnownPrice formats a price in the stock's currency, or N/A when it is zero
+func formatKnownPrice(s *screener.ScreenResult, price float64) string {
+	if price == 0 {
+		return "N/A"
+	}
+	return formatPrice(s, price)
 }
 
 // clamp clamps a value between min and max
@@ -629,12 +772,37 @@ func (d *Details) renderSection(title string, items [][]string) string {
 	return b.String()
 }
 
+// renderSizingSection renders the position size for the account risking a
+// fall to the stock's ATR stop, or why it can't be sized
+func (d *Details) renderSizingSection(s *screener.ScreenResult) string {
+	if d.sizer == nil {
+		return ""
+	}
+	sz, err := d.sizer(s)
+	if err != nil {
+		hint := err.Error()
+		if strings.Contains(hint, "equity") {
+			hint = "set the account equity (stockmap size --equity)"
+		}
+		return d.renderSection("POSITION SIZING", [][]string{{"N/A", hint}})
+	}
+
+	shares := fmt.Sprintf("%d", sz.Shares)
+	if sz.Capped {
+		shares += fmt.Sprintf(" (max position, from %d)", sz.RiskShares)
+	}
+	return d.renderSection("POSITION SIZING", [][]string{
+		{"Shares", shares},
+		{"Value", fmt.Sprintf("%s (%.1f%% of equity)", formatPrice(s, sz.Value), sz.ValuePercent)},
+		{"At Risk", fmt.Sprintf("%s (%.2f%%) to %s", formatPrice(s, sz.CapitalAtRisk), sz.RiskPercent, formatPrice(s, sz.StopLoss))},
+		{"Portfolio Heat", fmt.Sprintf("%.2f%% with this trade", sz.Heat)},
+	})
+}
+
 // renderScoreSection renders the score section with bars
 func (d *Details) renderScoreSection(s *screener.ScreenResult) string {
 	var b strings.Builder
 
-	b.WriteString(components.RenderDivider(d.width))
-	b.WriteString("\n")
 	b.WriteString(styles.TitleStyle.Render("CONFLUENCE SCORE"))
 	b.WriteString("\n\n")
 
@@ -669,6 +837,73 @@ func (d *Details) renderScoreSection(s *screener.ScreenResult) string {
 		b.WriteString(label + value + bar + "\n")
 	}
 
+	if s.Ranks != nil {
+		b.WriteString("\n" + d.renderRanks(s))
+	}
+
+	return b.String()
+}
+
+// renderRanks renders the rank score and the percentile and z-score of
+// each ranked metric
+func (d *Details) renderRanks(s *screener.ScreenResult) string {
+	var b strings.Builder
+
+	peers := "scan"
+	if s.RankGroup != "" {
+		peers = s.RankGroup
+	}
+	line := fmt.Sprintf("  Rank: %.0f/100 vs %s ", s.RankScore, peers)
+	if s.TopDecile {
+		line += styles.TopDecileStyle.Render(components.TopDecileBadge + " top decile")
+	}
+	b.WriteString(line + "\n")
+
+	for _, metric := range screener.RankMetrics() {
+		rank, ok := s.Ranks[metric]
+		if !ok {
+			continue
+		}
+		label := styles.MutedStyle().Render(fmt.Sprintf("  %-12s", strings.ToUpper(metric)))
+		b.WriteString(label + fmt.Sprintf("p%-4.0f z %+.2f\n", rank.Percentile, rank.ZScore))
+	}
+
+	return b.String()
+}
+
+// maxWhyLines is the most contributions the "why this score" panel lists
+const maxWhyLines = 12
+
+// renderWhySection renders the scoring rules and bonuses behind the
+// confluence score, with the points each added to it
+func (d *Details) renderWhySection(s *screener.ScreenResult) string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render("WHY THIS SCORE"))
+	b.WriteString("\n\n")
+
+	if len(s.Contributions) == 0 {
+		b.WriteString(styles.MutedStyle().Render("  No scoring rules matched (or the scan predates score breakdowns)"))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	for i, c := range s.Contributions {
+		if i == maxWhyLines {
+			b.WriteString(styles.MutedStyle().Render(fmt.Sprintf("  … %d more", len(s.Contributions)-maxWhyLines)))
+			b.WriteString("\n")
+			break
+		}
+		style := styles.ScoreHighStyle
+		if c.Weighted < 0 {
+			style = styles.ScoreLowStyle
+		}
+		b.WriteString("  " + style.Render(fmt.Sprintf("%+6.1f", c.Weighted)) + "  " + c.String() + "\n")
+	}
+	if !s.HasFundamentals {
+		b.WriteString(styles.MutedStyle().Render("  Valuation left out: no fundamentals"))
+		b.WriteString("\n")
+	}
 	return b.String()
 }
 
@@ -686,8 +921,8 @@ func (d *Details) renderSignals(s *screener.ScreenResult) string {
 	}{
 		{s.RSI < 30, true, "RSI Oversold (<30)"},
 		{s.RSI < 35, true, "RSI Near Oversold (<35)"},
-		{s.PBV < 1.0, true, "Trading Below Book Value"},
-		{s.PBV < 1.5, true, "Low P/B Ratio (<1.5)"},
+		{s.PBV > 0 && s.PBV < 1.0, true, "Trading Below Book Value"},
+		{s.PBV > 0 && s.PBV < 1.5, true, "Low P/B Ratio (<1.5)"},
 		{s.GrahamUpside > 30, true, "Strong Graham Upside (>30%)"},
 		{s.Price < s.SMA20, true, "Below SMA20"},
 		{s.RiskRatio >= 2.0, true, "Favorable Risk:Reward (>=1:2)"},
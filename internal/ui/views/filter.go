@@ -1,6 +1,7 @@
 package views
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -19,24 +20,35 @@ const (
 	FilterFieldMaxRSI
 	FilterFieldMaxPBV
 	FilterFieldMinScore
+	FilterFieldExpression
 	FilterFieldCount // Sentinel for counting fields
 )
 
+// maxExprDisplay is the longest expression shown in the field row
+const maxExprDisplay = 32
+
 // FilterView allows editing filter criteria
 type FilterView struct {
 	width        int
 	height       int
 	criteria     screener.FilterCriteria
+	defaults     screener.FilterCriteria // Criteria of the active strategy, restored by Reset
 	selectedRow  int
 	inputActive  bool
 	inputBuffer  string
 	currentField FilterField
+	exprErr      error             // Live validation of the expression being typed
+	screens      []screener.Screen // Saved screens, cycled with Tab
+	screenIndex  int
+	screensErr   error
 }
 
 // NewFilterView creates a new filter view
 func NewFilterView() *FilterView {
 	return &FilterView{
-		criteria: screener.DefaultCriteria(),
+		criteria:    screener.DefaultCriteria(),
+		defaults:    screener.DefaultCriteria(),
+		screenIndex: -1,
 	}
 }
 
@@ -51,11 +63,33 @@ func (f *FilterView) SetCriteria(c screener.FilterCriteria) {
 	f.criteria = c
 }
 
+// SetDefaults sets the criteria restored by Reset
+func (f *FilterView) SetDefaults(c screener.FilterCriteria) {
+	f.defaults = c
+}
+
 // GetCriteria returns the current filter criteria
 func (f *FilterView) GetCriteria() screener.FilterCriteria {
 	return f.criteria
 }
 
+// RefreshScreens reloads the saved screens
+func (f *FilterView) RefreshScreens() {
+	f.screens, f.screensErr = screener.LoadScreens()
+	f.screenIndex = -1
+}
+
+// NextScreen loads the next saved screen into the expression
+func (f *FilterView) NextScreen() {
+	if len(f.screens) == 0 || FilterField(f.selectedRow) != FilterFieldExpression {
+		return
+	}
+	f.screenIndex = (f.screenIndex + 1) % len(f.screens)
+	if e, err := screener.Compile(f.screens[f.screenIndex].Expression); err == nil {
+		f.criteria.Expression = e
+	}
+}
+
 // MoveUp moves selection up
 func (f *FilterView) MoveUp() {
 	if f.selectedRow > 0 {
@@ -90,18 +124,40 @@ func (f *FilterView) ToggleInput() {
 			f.inputBuffer = fmt.Sprintf("%.1f", f.criteria.MaxPBV)
 		case FilterFieldMinScore:
 			f.inputBuffer = fmt.Sprintf("%.0f", f.criteria.MinConfluence)
+		case FilterFieldExpression:
+			f.inputBuffer = ""
+			if f.criteria.Expression != nil {
+				f.inputBuffer = f.criteria.Expression.String()
+			}
+			f.validateExpression()
 		}
 	}
 }
 
+// validateExpression compiles the expression being typed
+func (f *FilterView) validateExpression() {
+	f.exprErr = nil
+	if strings.TrimSpace(f.inputBuffer) != "" {
+		_, f.exprErr = screener.Compile(f.inputBuffer)
+	}
+}
+
 // ClearInput clears input and exits input mode
 func (f *FilterView) ClearInput() {
 	f.inputBuffer = ""
 	f.inputActive = false
+	f.exprErr = nil
 }
 
 // AddChar adds a character to input
 func (f *FilterView) AddChar(c rune) {
+	if f.currentField == FilterFieldExpression {
+		if c >= ' ' && c <= '~' {
+			f.inputBuffer += string(c)
+			f.validateExpression()
+		}
+		return
+	}
 	// Only allow digits and decimal point
 	if (c >= '0' && c <= '9') || c == '.' {
 		f.inputBuffer += string(c)
@@ -113,10 +169,17 @@ func (f *FilterView) Backspace() {
 	if len(f.inputBuffer) > 0 {
 		f.inputBuffer = f.inputBuffer[:len(f.inputBuffer)-1]
 	}
+	if f.currentField == FilterFieldExpression {
+		f.validateExpression()
+	}
 }
 
-// SubmitInput applies the current input value
+// SubmitInput applies the current input value. An invalid expression
+// keeps input mode active.
 func (f *FilterView) SubmitInput() bool {
+	if f.currentField == FilterFieldExpression {
+		return f.submitExpression()
+	}
 	if f.inputBuffer == "" {
 		f.inputActive = false
 		return false
@@ -152,6 +215,23 @@ func (f *FilterView) SubmitInput() bool {
 	return true
 }
 
+// submitExpression applies the typed expression; an empty one clears it
+func (f *FilterView) submitExpression() bool {
+	if strings.TrimSpace(f.inputBuffer) == "" {
+		f.criteria.Expression = nil
+		f.ClearInput()
+		return true
+	}
+	e, err := screener.Compile(f.inputBuffer)
+	if err != nil {
+		f.exprErr = err
+		return false
+	}
+	f.criteria.Expression = e
+	f.ClearInput()
+	return true
+}
+
 // Increment increases current field value
 func (f *FilterView) Increment() {
 	switch FilterField(f.selectedRow) {
@@ -200,9 +280,9 @@ func (f *FilterView) Decrement() {
 	}
 }
 
-// Reset restores default criteria
+// Reset restores the active strategy's criteria
 func (f *FilterView) Reset() {
-	f.criteria = screener.DefaultCriteria()
+	f.criteria = f.defaults
 }
 
 // View renders the filter view
@@ -219,7 +299,7 @@ func (f *FilterView) View() string {
 	}
 
 	// Center content
-	contentHeight := 20
+	contentHeight := 22
 	topPadding := (f.height - contentHeight) / 2
 	if topPadding < 1 {
 		topPadding = 1
@@ -271,6 +351,11 @@ func (f *FilterView) View() string {
 			value:       fmt.Sprintf("%.0f", f.criteria.MinConfluence),
 			description: "Minimum confluence score (0-100)",
 		},
+		{
+			name:        "Expression",
+			value:       f.expressionDisplay(),
+			description: f.expressionDescription(),
+		},
 	}
 
 	for i, field := range fields {
@@ -294,6 +379,9 @@ func (f *FilterView) View() string {
 		displayValue := field.value
 		if f.inputActive && i == f.selectedRow {
 			displayValue = f.inputBuffer + "_"
+			if f.currentField == FilterFieldExpression {
+				displayValue = "editing"
+			}
 			valueStyle = lipgloss.NewStyle().Foreground(styles.ColorSuccess).Bold(true)
 		}
 
@@ -318,6 +406,11 @@ func (f *FilterView) View() string {
 		}
 	}
 
+	if f.inputActive && f.currentField == FilterFieldExpression {
+		b.WriteString("\n")
+		b.WriteString(f.renderExpressionInput())
+	}
+
 	b.WriteString("\n")
 
 	// RSI Bar visualization
@@ -335,12 +428,69 @@ func (f *FilterView) View() string {
 		help = "[Enter] Apply  [Esc] Cancel"
 	} else {
 		help = "[Up/Down] Select  [-/+] Adjust  [Enter] Edit  [R] Reset  [Esc] Back & Apply"
+		if FilterField(f.selectedRow) == FilterFieldExpression && len(f.screens) > 0 {
+			help = "[Up/Down] Select  [Enter] Edit  [Tab] Saved Screen  [R] Reset  [Esc] Back & Apply"
+		}
 	}
 	b.WriteString(centerText(styles.HelpStyle.Render(help), f.width))
 
 	return b.String()
 }
 
+// expressionDisplay returns the expression shown in its field row
+func (f *FilterView) expressionDisplay() string {
+	if f.criteria.Expression == nil {
+		return "none"
+	}
+	text := f.criteria.Expression.String()
+	if f.screenIndex >= 0 && f.screenIndex < len(f.screens) && f.screens[f.screenIndex].Expression == text {
+		text = f.screens[f.screenIndex].Name + ": " + text
+	}
+	if len(text) > maxExprDisplay {
+		text = text[:maxExprDisplay-3] + "..."
+	}
+	return text
+}
+
+// expressionDescription returns the help line of the expression row
+func (f *FilterView) expressionDescription() string {
+	switch {
+	case f.screensErr != nil:
+		return "Saved screens: " + f.screensErr.Error()
+	case len(f.screens) > 0:
+		return fmt.Sprintf("e.g. rsi < 30 and pbv < 1.2 · %d saved screens", len(f.screens))
+	default:
+		return `e.g. rsi < 30 and (macd_crossover == "bullish" or pbv < 1)`
+	}
+}
+
+// renderExpressionInput renders the expression being typed with its live
+// validation: a caret under the error position and the message
+func (f *FilterView) renderExpressionInput() string {
+	var b strings.Builder
+	input := "  " + f.inputBuffer + "_"
+	b.WriteString(centerText(styles.TextStyle.Render(input), f.width))
+	b.WriteString("\n")
+
+	var exprErr *screener.ExprError
+	switch {
+	case errors.As(f.exprErr, &exprErr):
+		caret := "  " + exprErr.Caret()
+		caret += strings.Repeat(" ", len(input)-len(caret))
+		b.WriteString(centerText(lipgloss.NewStyle().Foreground(styles.ColorDanger).Render(caret), f.width))
+		b.WriteString("\n")
+		b.WriteString(centerText(lipgloss.NewStyle().Foreground(styles.ColorDanger).Render(exprErr.Msg), f.width))
+	case strings.TrimSpace(f.inputBuffer) == "":
+		b.WriteString("\n")
+		b.WriteString(centerText(styles.MutedStyle().Render("Empty expression: no extra filter"), f.width))
+	default:
+		b.WriteString("\n")
+		b.WriteString(centerText(lipgloss.NewStyle().Foreground(styles.ColorSuccess).Render("✓ valid expression"), f.width))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
 // renderRSIBar renders a visual RSI range indicator
 func (f *FilterView) renderRSIBar() string {
 	barWidth := 40
@@ -65,9 +65,13 @@ func (h *HelpView) View() string {
 	}{
 		{"S", "Open scan mode selection"},
 		{"R", "Reload/Refresh data (toggle)"},
-		{"T", "Toggle auto-reload (60s interval)"},
+		{"T", "Toggle auto-reload (60s, paused while markets are closed)"},
 		{"F", "Open filter criteria editor"},
+		{"M", "Cycle strategy preset (applies on the next scan)"},
+		{"G", "Group results by sector"},
 		{"W", "View watchlist"},
+		{"O", "View portfolio (positions and P&L)"},
+		{"B", "View paper trading (simulated trades)"},
 		{"H", "View scan history"},
 		{"P", "View price alerts"},
 		{"D", "View stock details"},
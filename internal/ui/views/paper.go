@@ -0,0 +1,224 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+	"github.com/febritecno/stockmap-cli/internal/paper"
+	"github.com/febritecno/stockmap-cli/internal/styles"
+	"github.com/febritecno/stockmap-cli/internal/ui/components"
+)
+
+// PaperView shows the paper trading performance, open trades and journal
+type PaperView struct {
+	width   int
+	height  int
+	mgr     *paper.Manager
+	account config.AccountSettings // Defaults of a new paper account
+	journal paper.Journal
+	report  paper.Report
+	message string
+}
+
+// NewPaperView creates a new paper trading view
+func NewPaperView(mgr *paper.Manager, account config.AccountSettings) *PaperView {
+	p := &PaperView{mgr: mgr, account: account}
+	if err := mgr.LoadError(); err != nil {
+		p.message = "Paper journal not loaded: " + err.Error()
+	}
+	p.Refresh()
+	return p
+}
+
+// SetSize sets the view dimensions
+func (p *PaperView) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Refresh re-reads the journal, which "stockmap paper" may have changed
+func (p *PaperView) Refresh() {
+	if err := p.mgr.Load(); err != nil {
+		p.message = "Paper journal not loaded: " + err.Error()
+	}
+	p.journal = p.mgr.Journal()
+	p.report = p.journal.Report()
+}
+
+// Toggle turns paper trading on or off. A journal never started starts with
+// the default settings for the account.
+func (p *PaperView) Toggle() {
+	var err error
+	switch {
+	case p.mgr.Active():
+		err = p.mgr.Stop(time.Now())
+		p.message = "Paper trading stopped; open trades are kept"
+	case p.mgr.Started():
+		err = p.mgr.Start(nil, time.Now())
+		p.message = "Paper trading resumed: trades are placed on the next scan or reload"
+	default:
+		s := paper.DefaultSettings(p.account)
+		err = p.mgr.Start(&s, time.Now())
+		p.message = fmt.Sprintf("Paper trading started with %.0f: trades are placed on the next scan or reload", s.Capital)
+	}
+	if err != nil {
+		p.message = "Error: " + err.Error()
+	}
+	p.Refresh()
+}
+
+// View renders the paper trading view
+func (p *PaperView) View() string {
+	var b strings.Builder
+
+	title := styles.TitleStyle.Render("≈ PAPER TRADING")
+	b.WriteString(centerText(title, p.width))
+	b.WriteString("\n")
+	b.WriteString(components.RenderDivider(p.width))
+	b.WriteString("\n")
+
+	if !p.mgr.Started() {
+		emptyHeight := p.height - 10
+		for i := 0; i < emptyHeight/2; i++ {
+			b.WriteString("\n")
+		}
+		b.WriteString(centerText(styles.MutedStyle().Render("Paper trading is off"), p.width))
+		b.WriteString("\n\n")
+		hint := styles.HelpStyle.Render("Press [S] to trade triggered alerts and new high scores with simulated orders")
+		b.WriteString(centerText(hint, p.width))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(p.renderSummary())
+		b.WriteString("\n")
+		b.WriteString(p.renderOpenTrades())
+		b.WriteString("\n")
+		b.WriteString(p.renderJournal())
+	}
+
+	if p.message != "" {
+		b.WriteString("\n")
+		b.WriteString(styles.InfoStyle.Render("  " + p.message))
+	}
+	b.WriteString("\n")
+	b.WriteString(p.renderStatusBar())
+
+	return b.String()
+}
+
+// renderSummary renders the performance lines
+func (p *PaperView) renderSummary() string {
+	r := p.report
+	sep := styles.MutedStyle().Render(" | ")
+	state := styles.PriceDownStyle.Render("OFF")
+	if r.Active {
+		state = styles.PriceUpStyle.Render("ON")
+	}
+	updated := "no reload yet"
+	if !r.Updated.IsZero() {
+		updated = "last reload " + r.Updated.Format("2006-01-02 15:04")
+	}
+
+	line1 := styles.StatusItemStyle.Render("  Paper trading ") + state +
+		styles.MutedStyle().Render(fmt.Sprintf(" since %s, %s", r.Started.Format("2006-01-02"), updated))
+	line2 := styles.StatusItemStyle.Render(fmt.Sprintf("  Equity %.2f", r.Equity)) + " " +
+		styles.FormatChange(r.TotalReturn) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Cash %.2f", r.Cash)) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Realised %+.2f", r.Realized)) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Unrealised %+.2f", r.Unrealized)) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Max DD %.2f%%", r.MaxDrawdown))
+	pf := "-"
+	if r.ProfitFactor > 0 {
+		pf = fmt.Sprintf("%.2f", r.ProfitFactor)
+	}
+	line3 := styles.StatusItemStyle.Render(fmt.Sprintf("  Closed %d (%d won, %d lost)", len(r.Closed), r.Wins, r.Losses)) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Win rate %.1f%%", r.WinRate)) + sep +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Avg win %+.2f%% / loss %+.2f%%", r.AvgWin, r.AvgLoss)) + sep +
+		styles.StatusItemStyle.Render("Profit factor "+pf)
+	rules := styles.MutedStyle().Render(fmt.Sprintf("  Min score %g, alerts %v, up to %d positions, risk %g%%, max position %g%%",
+		r.Settings.MinScore, r.Settings.Alerts, r.Settings.MaxPositions, r.Settings.RiskPercent, r.Settings.MaxPositionPercent))
+	return line1 + "\n" + line2 + "\n" + line3 + "\n" + rules + "\n"
+}
+
+// renderOpenTrades renders the open trades at their last price
+func (p *PaperView) renderOpenTrades() string {
+	var b strings.Builder
+	b.WriteString(styles.TitleStyle.Render("  OPEN TRADES"))
+	b.WriteString("\n")
+	if len(p.report.Open) == 0 {
+		b.WriteString(styles.MutedStyle().Render("  none"))
+		b.WriteString("\n")
+		return b.String()
+	}
+	header := fmt.Sprintf("  %-10s %-6s %10s %7s %11s %11s %11s %11s %8s",
+		"SYMBOL", "SOURCE", "DATE", "SHARES", "ENTRY", "LAST", "SL", "TP", "P&L %")
+	b.WriteString(styles.TableHeaderStyle.Render(header))
+	b.WriteString("\n")
+	for _, t := range p.report.Open {
+		row := fmt.Sprintf("  %-10s %-6s %10s %7d %11s %11s %11s %11s ", t.Symbol, t.Source,
+			t.EntryTime.Format("2006-01-02"), t.Shares, components.FormatPrice(t.EntryPrice, t.Currency),
+			components.FormatPrice(t.LastPrice, t.Currency), components.FormatPrice(t.StopLoss, t.Currency),
+			components.FormatPrice(t.TakeProfit, t.Currency))
+		pnlStyle := styles.PriceUpStyle
+		if t.PnL() < 0 {
+			pnlStyle = styles.PriceDownStyle
+		}
+		b.WriteString(row + pnlStyle.Render(fmt.Sprintf("%+7.2f%%", t.ReturnPct())) + "\n")
+	}
+	return b.String()
+}
+
+// renderJournal renders the latest journal events that fit the view
+func (p *PaperView) renderJournal() string {
+	var b strings.Builder
+	b.WriteString(styles.TitleStyle.Render("  JOURNAL"))
+	b.WriteString("\n")
+
+	rows := p.height - 18 - len(p.report.Open)
+	if rows < 3 {
+		rows = 3
+	}
+	events := p.journal.Events
+	if len(events) > rows {
+		events = events[len(events)-rows:]
+	}
+	won := make(map[string]bool)
+	for _, t := range p.report.Closed {
+		won[t.ID] = t.PnL() > 0
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		style := styles.MutedStyle()
+		switch {
+		case e.Kind == paper.EventOpen:
+			style = styles.InfoStyle
+		case e.Kind == paper.EventClose && won[e.TradeID]:
+			style = styles.PriceUpStyle
+		case e.Kind == paper.EventClose:
+			style = styles.PriceDownStyle
+		}
+		b.WriteString(style.Render(fmt.Sprintf("  %s  %s", e.Time.Format("01-02 15:04"), e.Message)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderStatusBar renders the key bar of the paper trading view
+func (p *PaperView) renderStatusBar() string {
+	divider := components.RenderDivider(p.width)
+
+	action := "tart"
+	if p.mgr.Active() {
+		action = "top"
+	}
+	keys := styles.KeyStyle.Render("[S]") + styles.HelpStyle.Render(action+"  ") +
+		styles.KeyStyle.Render("[ESC]") + styles.HelpStyle.Render(" Back")
+
+	stats := styles.MutedStyle().Render(" | ") +
+		styles.StatusItemStyle.Render(intToStr(len(p.report.Open))+" open, "+intToStr(len(p.report.Closed))+" closed")
+
+	return lipgloss.JoinVertical(lipgloss.Left, divider, keys+stats)
+}
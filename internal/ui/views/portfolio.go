@@ -0,0 +1,389 @@
+package views
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/febritecno/stockmap-cli/internal/portfolio"
+	"github.com/febritecno/stockmap-cli/internal/screener"
+	"github.com/febritecno/stockmap-cli/internal/styles"
+	"github.com/febritecno/stockmap-cli/internal/ui/components"
+)
+
+// Portfolio input fields
+const (
+	portfolioFieldSymbol = iota
+	portfolioFieldQuantity
+	portfolioFieldPrice
+	portfolioFieldFees
+	portfolioFieldCount
+)
+
+// PortfolioView shows the open positions valued at the scan prices
+type PortfolioView struct {
+	width       int
+	height      int
+	mgr         *portfolio.Manager
+	results     []*screener.ScreenResult
+	summary     portfolio.Summary
+	cursor      int
+	inputActive bool
+	selling     bool // The input form records a sale instead of a purchase
+	inputField  int
+	inputs      [portfolioFieldCount]string
+	message     string
+}
+
+// NewPortfolioView creates a new portfolio view
+func NewPortfolioView(mgr *portfolio.Manager) *PortfolioView {
+	p := &PortfolioView{mgr: mgr}
+	if err := mgr.LoadError(); err != nil {
+		p.message = "Portfolio not loaded: " + err.Error()
+	}
+	return p
+}
+
+// SetSize sets the view dimensions
+func (p *PortfolioView) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// SetResults sets the scan results positions are valued at
+func (p *PortfolioView) SetResults(results []*screener.ScreenResult) {
+	p.results = results
+	p.Refresh()
+}
+
+// Refresh revalues the positions
+func (p *PortfolioView) Refresh() {
+	p.summary = p.mgr.Summary(portfolio.Prices(p.results))
+	if p.cursor >= len(p.summary.Positions) {
+		p.cursor = len(p.summary.Positions) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// MoveUp moves the cursor up
+func (p *PortfolioView) MoveUp() {
+	if p.cursor > 0 {
+		p.cursor--
+	}
+}
+
+// MoveDown moves the cursor down
+func (p *PortfolioView) MoveDown() {
+	if p.cursor < len(p.summary.Positions)-1 {
+		p.cursor++
+	}
+}
+
+// SelectedPosition returns the position under the cursor
+func (p *PortfolioView) SelectedPosition() *portfolio.Position {
+	if p.cursor >= 0 && p.cursor < len(p.summary.Positions) {
+		return &p.summary.Positions[p.cursor]
+	}
+	return nil
+}
+
+// IsInputActive returns whether the input form is shown
+func (p *PortfolioView) IsInputActive() bool {
+	return p.inputActive
+}
+
+// StartAdd opens the form recording a purchase, filled in from stock when
+// it is not nil
+func (p *PortfolioView) StartAdd(stock *screener.ScreenResult) {
+	p.inputActive = true
+	p.selling = false
+	p.inputs = [portfolioFieldCount]string{}
+	p.inputField = portfolioFieldSymbol
+	if stock != nil {
+		p.inputs[portfolioFieldSymbol] = stock.Symbol
+		if stock.Price > 0 {
+			p.inputs[portfolioFieldPrice] = strconv.FormatFloat(stock.Price, 'f', 2, 64)
+		}
+		p.inputField = portfolioFieldQuantity
+	}
+}
+
+// StartSell opens the form selling the selected position, at its price
+func (p *PortfolioView) StartSell() {
+	pos := p.SelectedPosition()
+	if pos == nil {
+		return
+	}
+	p.inputActive = true
+	p.selling = true
+	p.inputs = [portfolioFieldCount]string{}
+	p.inputs[portfolioFieldSymbol] = pos.Symbol
+	p.inputs[portfolioFieldQuantity] = strconv.FormatFloat(pos.Quantity, 'f', -1, 64)
+	if pos.Priced {
+		p.inputs[portfolioFieldPrice] = strconv.FormatFloat(pos.Price, 'f', 2, 64)
+	}
+	p.inputField = portfolioFieldQuantity
+}
+
+// InputSymbol returns the symbol typed into the form
+func (p *PortfolioView) InputSymbol() string {
+	return strings.TrimSpace(strings.ToUpper(p.inputs[portfolioFieldSymbol]))
+}
+
+// NextInputField moves to the next input field
+func (p *PortfolioView) NextInputField() {
+	if p.inputField < portfolioFieldCount-1 {
+		p.inputField++
+	}
+}
+
+// PrevInputField moves to the previous input field; the symbol of a sale
+// can't be changed
+func (p *PortfolioView) PrevInputField() {
+	min := portfolioFieldSymbol
+	if p.selling {
+		min = portfolioFieldQuantity
+	}
+	if p.inputField > min {
+		p.inputField--
+	}
+}
+
+// AddChar adds a character to the current field
+func (p *PortfolioView) AddChar(c rune) {
+	if !p.inputActive {
+		return
+	}
+	if p.inputField == portfolioFieldSymbol {
+		if c != ' ' {
+			p.inputs[p.inputField] += strings.ToUpper(string(c))
+		}
+		return
+	}
+	if (c >= '0' && c <= '9') || c == '.' {
+		p.inputs[p.inputField] += string(c)
+	}
+}
+
+// Backspace removes the last character of the current field
+func (p *PortfolioView) Backspace() {
+	if s := p.inputs[p.inputField]; len(s) > 0 {
+		p.inputs[p.inputField] = s[:len(s)-1]
+	}
+}
+
+// ClearInput closes the input form
+func (p *PortfolioView) ClearInput() {
+	p.inputActive = false
+	p.selling = false
+	p.inputs = [portfolioFieldCount]string{}
+}
+
+// Submit records the purchase or sale in the form. stock is the scan result
+// of the symbol, if any: a purchase takes its stop loss, take profit and
+// currency.
+func (p *PortfolioView) Submit(stock *screener.ScreenResult) {
+	symbol := p.InputSymbol()
+	qty, _ := strconv.ParseFloat(p.inputs[portfolioFieldQuantity], 64)
+	price, _ := strconv.ParseFloat(p.inputs[portfolioFieldPrice], 64)
+	fees, _ := strconv.ParseFloat(p.inputs[portfolioFieldFees], 64)
+	switch {
+	case symbol == "":
+		p.message = "Symbol required"
+		return
+	case qty <= 0:
+		p.message = "Valid quantity required"
+		return
+	case price <= 0:
+		p.message = "Valid price required"
+		return
+	}
+
+	if p.selling {
+		sales, err := p.mgr.Sell(symbol, qty, price, fees, time.Now())
+		if err != nil {
+			p.message = "Error: " + err.Error()
+			return
+		}
+		realized := 0.0
+		for _, s := range sales {
+			realized += s.Realized
+		}
+		p.message = fmt.Sprintf("Sold %g %s, realised %+.2f", qty, symbol, realized)
+	} else {
+		lot := portfolio.Lot{Symbol: symbol, Quantity: qty, EntryPrice: price, Fees: fees}
+		if stock != nil {
+			lot.StopLoss = stock.StopLoss
+			lot.TakeProfit = stock.TakeProfit
+			lot.Currency = stock.Currency
+		}
+		if _, err := p.mgr.Add(lot); err != nil {
+			p.message = "Error: " + err.Error()
+			return
+		}
+		p.message = fmt.Sprintf("Bought %g %s at %.2f", qty, symbol, price)
+	}
+	p.ClearInput()
+	p.Refresh()
+}
+
+// View renders the portfolio view
+func (p *PortfolioView) View() string {
+	var b strings.Builder
+
+	title := styles.TitleStyle.Render("$ PORTFOLIO")
+	b.WriteString(centerText(title, p.width))
+	b.WriteString("\n")
+	b.WriteString(components.RenderDivider(p.width))
+	b.WriteString("\n")
+
+	if p.inputActive {
+		b.WriteString(p.renderForm())
+	} else if len(p.summary.Positions) == 0 {
+		emptyHeight := p.height - 10
+		for i := 0; i < emptyHeight/2; i++ {
+			b.WriteString("\n")
+		}
+		b.WriteString(centerText(styles.MutedStyle().Render("No open positions"), p.width))
+		b.WriteString("\n\n")
+		hint := styles.HelpStyle.Render("Press [A] to record a purchase of the selected stock")
+		b.WriteString(centerText(hint, p.width))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(p.renderPositions())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(p.renderTotals())
+	if p.message != "" {
+		b.WriteString("\n")
+		b.WriteString(styles.InfoStyle.Render("  " + p.message))
+	}
+	b.WriteString("\n")
+	b.WriteString(p.renderStatusBar())
+
+	return b.String()
+}
+
+// renderForm renders the purchase or sale form
+func (p *PortfolioView) renderForm() string {
+	var b strings.Builder
+	heading := "RECORD PURCHASE"
+	if p.selling {
+		heading = "RECORD SALE"
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.TitleStyle.Render("  " + heading))
+	b.WriteString("\n\n")
+
+	labels := [portfolioFieldCount]string{"Symbol", "Quantity", "Price", "Fees"}
+	for i, label := range labels {
+		prefix := "    "
+		if i == p.inputField {
+			prefix = "  " + styles.ScoreHighStyle.Render("> ")
+		}
+		value := styles.InfoStyle.Render(p.inputs[i])
+		if i == p.inputField {
+			value += styles.MutedStyle().Render("_")
+		}
+		b.WriteString(fmt.Sprintf("%s%-9s %s\n", prefix, label+":", value))
+	}
+	b.WriteString("\n")
+	hint := "  Purchases take the stop loss and take profit of the scan result"
+	if p.selling {
+		hint = "  Sales are matched against the oldest lots first"
+	}
+	b.WriteString(styles.MutedStyle().Render(hint))
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("  Press TAB to switch fields, ENTER to save, ESC to cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderPositions renders the position table
+func (p *PortfolioView) renderPositions() string {
+	var b strings.Builder
+	header := fmt.Sprintf("  %-10s %9s %11s %11s %12s %12s %8s %7s %7s %7s",
+		"SYMBOL", "QTY", "AVG COST", "PRICE", "VALUE", "P&L", "P&L %", "TO SL", "TO TP", "WEIGHT")
+	b.WriteString(styles.TableHeaderStyle.Render(header))
+	b.WriteString("\n")
+
+	for i, pos := range p.summary.Positions {
+		cursor := "  "
+		if i == p.cursor {
+			cursor = styles.ScoreHighStyle.Render("> ")
+		}
+		price, value, pnl, pnlPct, weight := "-", "-", "-", "-", "-"
+		toStop, toTarget := "-", "-"
+		pnlStyle := styles.MutedStyle()
+		if pos.Priced {
+			price = components.FormatPrice(pos.Price, pos.Currency)
+			value = fmt.Sprintf("%.2f", pos.MarketValue)
+			pnl = fmt.Sprintf("%+.2f", pos.Unrealized)
+			pnlPct = fmt.Sprintf("%+.2f%%", pos.UnrealizedPct)
+			weight = fmt.Sprintf("%.1f%%", pos.Weight)
+			if pos.StopLoss > 0 {
+				toStop = fmt.Sprintf("%.1f%%", pos.ToStop)
+			}
+			if pos.TakeProfit > 0 {
+				toTarget = fmt.Sprintf("%.1f%%", pos.ToTarget)
+			}
+			pnlStyle = styles.PriceUpStyle
+			if pos.Unrealized < 0 {
+				pnlStyle = styles.PriceDownStyle
+			}
+		}
+		row := fmt.Sprintf("%-10s %9s %11s %11s %12s ", pos.Symbol, strconv.FormatFloat(pos.Quantity, 'f', -1, 64),
+			components.FormatPrice(pos.AvgCost, pos.Currency), price, value)
+		row += pnlStyle.Render(fmt.Sprintf("%12s %8s", pnl, pnlPct))
+		// A crossed level is flagged
+		stopCell := fmt.Sprintf(" %7s", toStop)
+		if pos.Priced && pos.StopLoss > 0 && pos.ToStop <= 0 {
+			stopCell = styles.PriceDownStyle.Render(stopCell)
+		}
+		targetCell := fmt.Sprintf(" %7s", toTarget)
+		if pos.Priced && pos.TakeProfit > 0 && pos.ToTarget <= 0 {
+			targetCell = styles.PriceUpStyle.Render(targetCell)
+		}
+		row += stopCell + targetCell + fmt.Sprintf(" %7s", weight)
+		b.WriteString(cursor + row + "\n")
+	}
+	return b.String()
+}
+
+// renderTotals renders the portfolio totals
+func (p *PortfolioView) renderTotals() string {
+	s := p.summary
+	line := styles.StatusItemStyle.Render(fmt.Sprintf("  Value %.2f", s.MarketValue)) +
+		styles.MutedStyle().Render(" | ") +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Cost %.2f", s.Cost)) +
+		styles.MutedStyle().Render(" | ") +
+		styles.FormatChange(s.UnrealizedPct) +
+		styles.MutedStyle().Render(fmt.Sprintf(" (%+.2f unrealised)", s.Unrealized)) +
+		styles.MutedStyle().Render(" | ") +
+		styles.StatusItemStyle.Render(fmt.Sprintf("Realised %+.2f", s.Realized))
+	if len(s.Unpriced) > 0 {
+		line += "\n" + styles.MutedStyle().Render("  Not in the scan: "+strings.Join(s.Unpriced, ", "))
+	}
+	return line
+}
+
+// renderStatusBar renders the key bar of the portfolio view
+func (p *PortfolioView) renderStatusBar() string {
+	divider := components.RenderDivider(p.width)
+
+	keys := styles.KeyStyle.Render("[A]") + styles.HelpStyle.Render("dd  ") +
+		styles.KeyStyle.Render("[S]") + styles.HelpStyle.Render("ell  ") +
+		styles.KeyStyle.Render("[D]") + styles.HelpStyle.Render("etails  ") +
+		styles.KeyStyle.Render("[ESC]") + styles.HelpStyle.Render(" Back")
+
+	stats := styles.MutedStyle().Render(" | ") +
+		styles.StatusItemStyle.Render(intToStr(len(p.summary.Positions))+" positions")
+
+	return lipgloss.JoinVertical(lipgloss.Left, divider, keys+stats)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/febritecno/stockmap-cli/internal/styles"
+	"github.com/febritecno/stockmap-cli/internal/universe"
 )
 
 // ScanMode represents the scan mode
@@ -24,6 +25,8 @@ type ScanModeView struct {
 	customInput    string
 	inputActive    bool
 	watchlistCount int
+	universes      []*universe.Universe
+	universeIndex  int
 }
 
 // NewScanModeView creates a new scan mode view
@@ -44,6 +47,33 @@ func (s *ScanModeView) SetWatchlistCount(count int) {
 	s.watchlistCount = count
 }
 
+// SetUniverses sets the universes to choose from and selects the named one
+func (s *ScanModeView) SetUniverses(list []*universe.Universe, selected string) {
+	s.universes = list
+	s.universeIndex = 0
+	for i, u := range list {
+		if universe.Key(u.Name) == universe.Key(selected) {
+			s.universeIndex = i
+		}
+	}
+}
+
+// GetSelectedUniverse returns the universe picked for "Scan Universe"
+func (s *ScanModeView) GetSelectedUniverse() *universe.Universe {
+	if s.universeIndex < 0 || s.universeIndex >= len(s.universes) {
+		return nil
+	}
+	return s.universes[s.universeIndex]
+}
+
+// NextUniverse cycles the universe selection forward (delta 1) or back (-1)
+func (s *ScanModeView) NextUniverse(delta int) {
+	if len(s.universes) == 0 || s.inputActive {
+		return
+	}
+	s.universeIndex = (s.universeIndex + delta + len(s.universes)) % len(s.universes)
+}
+
 // GetSelectedMode returns the selected scan mode
 func (s *ScanModeView) GetSelectedMode() ScanMode {
 	return s.selectedMode
@@ -126,6 +156,15 @@ func (s *ScanModeView) Reset() {
 	s.inputActive = false
 }
 
+// universeDesc describes the selected universe for the menu
+func (s *ScanModeView) universeDesc() string {
+	u := s.GetSelectedUniverse()
+	if u == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d stocks)", u.Name, len(u.Members))
+}
+
 // View renders the scan mode view - simplified version
 func (s *ScanModeView) View() string {
 	var b strings.Builder
@@ -143,7 +182,7 @@ func (s *ScanModeView) View() string {
 		name string
 		desc string
 	}{
-		{"1", "Scan All", fmt.Sprintf("(%d stocks)", 148)},
+		{"1", "Scan Universe", s.universeDesc()},
 		{"2", "Scan Watchlist", fmt.Sprintf("(%d pinned)", s.watchlistCount)},
 		{"3", "Custom Symbols", "(type symbols)"},
 	}
@@ -159,6 +198,25 @@ func (s *ScanModeView) View() string {
 
 	b.WriteString("\n")
 
+	// Universe picker
+	if s.selectedMode == ScanModeAll && len(s.universes) > 1 {
+		b.WriteString("  Universes: ")
+		for i, u := range s.universes {
+			name := u.Name
+			if i == s.universeIndex {
+				name = styles.InfoStyle.Render("[" + name + "]")
+			} else {
+				name = styles.MutedStyle().Render(name)
+			}
+			b.WriteString(name + " ")
+		}
+		b.WriteString("\n")
+		if u := s.GetSelectedUniverse(); u != nil && u.Description != "" {
+			b.WriteString("  " + styles.MutedStyle().Render(u.Description) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
 	// Show input for custom mode
 	if s.selectedMode == ScanModeCustom {
 		b.WriteString("  Symbols: ")
@@ -186,7 +244,7 @@ func (s *ScanModeView) View() string {
 	if s.inputActive {
 		b.WriteString("  Type symbols, Enter=done, Esc=cancel, Backspace=delete\n")
 	} else {
-		b.WriteString("  1/2/3=select, Enter=start, Tab=edit, Esc=back\n")
+		b.WriteString("  1/2/3=select, ←/→=universe, Enter=start, Tab=edit, Esc=back\n")
 	}
 
 	return b.String()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/febritecno/stockmap-cli/internal/fetcher"
 	"github.com/febritecno/stockmap-cli/internal/styles"
 )
 
@@ -40,6 +41,7 @@ type Scanner struct {
 	lastError    string
 	errorSymbol  string
 	recentErrors []string // Keep last few errors for display
+	limiter      fetcher.LimiterStats
 }
 
 // NewScanner creates a new scanner view
@@ -107,6 +109,11 @@ func (s *Scanner) SetVerboseProgress(completed, total int, current string, succe
 	s.errorSymbol = errorSymbol
 }
 
+// SetLimiterStats updates the provider throttling state
+func (s *Scanner) SetLimiterStats(stats fetcher.LimiterStats) {
+	s.limiter = stats
+}
+
 // SetFoundCount sets the number of stocks found
 func (s *Scanner) SetFoundCount(count int) {
 	s.foundCount = count
@@ -210,7 +217,15 @@ func (s *Scanner) renderScanning() string {
 		errorStyle.Render("✗"),
 		s.errorCount)
 	b.WriteString(centerText(verboseStats, s.width))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+
+	// Explain why the scan slowed down
+	if s.limiter.Slowed() {
+		throttleLine := "⏳ Throttled: " + s.limiter.Reason()
+		b.WriteString(centerText(lipgloss.NewStyle().Foreground(styles.ColorWarning).Render(throttleLine), s.width))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	// Current symbol with animation
 	if s.current != "" {
@@ -5,10 +5,10 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
-	"github.com/febritecno/stockmap-cli/internal/fetcher"
 	"github.com/febritecno/stockmap-cli/internal/screener"
 	"github.com/febritecno/stockmap-cli/internal/styles"
 	"github.com/febritecno/stockmap-cli/internal/ui/components"
+	"github.com/febritecno/stockmap-cli/internal/universe"
 )
 
 // WatchlistView shows only watchlist stocks
@@ -22,7 +22,7 @@ type WatchlistView struct {
 	inputActive     bool
 	inputText       string
 	categoryMode    bool
-	categories      []fetcher.Category
+	categories      []universe.Group
 	currentCategory int
 }
 
@@ -32,10 +32,16 @@ func NewWatchlistView() *WatchlistView {
 		header:     components.NewHeader(),
 		table:      components.NewTable(),
 		statusBar:  components.NewStatusBar(),
-		categories: fetcher.DefaultCategories(),
+		categories: universe.Default().Groups(),
 	}
 }
 
+// SetCategories sets the symbol groups offered in category mode
+func (w *WatchlistView) SetCategories(groups []universe.Group) {
+	w.categories = groups
+	w.currentCategory = 0
+}
+
 // SetSize sets the view dimensions
 func (w *WatchlistView) SetSize(width, height int) {
 	w.width = width
@@ -0,0 +1,47 @@
+package universe
+
+import (
+	"embed"
+	"path"
+	"sort"
+)
+
+// builtinFiles are the universes shipped with the binary. Index lists are
+// snapshots; import a newer list to override one (see "stockmap universe import").
+//
+//go:embed data/*.yaml data/*.csv
+var builtinFiles embed.FS
+
+// Builtin returns the universes shipped with the binary, sorted by name
+func Builtin() []*Universe {
+	entries, _ := builtinFiles.ReadDir("data")
+
+	list := make([]*Universe, 0, len(entries))
+	for _, e := range entries {
+		name := path.Join("data", e.Name())
+		data, err := builtinFiles.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		u, err := Parse(e.Name(), data)
+		if err != nil {
+			// Embedded files are checked by tests
+			continue
+		}
+		u.Source = "builtin"
+		list = append(list, u)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Default returns the builtin default universe
+func Default() *Universe {
+	for _, u := range Builtin() {
+		if u.Name == DefaultName {
+			return u
+		}
+	}
+	return &Universe{Name: DefaultName}
+}
@@ -0,0 +1,409 @@
+package universe
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/febritecno/stockmap-cli/internal/config"
+)
+
+// DefaultName is the universe scanned when settings.json doesn't name one
+const DefaultName = "default"
+
+// OtherGroup is the group of members without a sector tag
+const OtherGroup = "Other"
+
+// Member is a symbol of a universe with optional classification tags
+type Member struct {
+	Symbol   string `yaml:"symbol"`
+	Sector   string `yaml:"sector,omitempty"`
+	Industry string `yaml:"industry,omitempty"`
+}
+
+// UnmarshalYAML accepts both "AAPL" and {symbol: AAPL, sector: ...}
+func (m *Member) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		m.Symbol = node.Value
+		return nil
+	}
+	type plain Member
+	return node.Decode((*plain)(m))
+}
+
+// Universe is a named list of symbols to scan
+type Universe struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description,omitempty"`
+	Members     []Member `yaml:"symbols"`
+	Source      string   `yaml:"-"` // "builtin" or the file it was loaded from
+}
+
+// Group is a set of symbols sharing a sector tag
+type Group struct {
+	Name    string
+	Symbols []string
+}
+
+// Symbols returns the universe's symbols in file order
+func (u *Universe) Symbols() []string {
+	symbols := make([]string, len(u.Members))
+	for i, m := range u.Members {
+		symbols[i] = m.Symbol
+	}
+	return symbols
+}
+
+// Member returns the member for a symbol
+func (u *Universe) Member(symbol string) (Member, bool) {
+	for _, m := range u.Members {
+		if strings.EqualFold(m.Symbol, symbol) {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// Classify returns a symbol's sector and industry tags ("" if untagged or
+// not a member)
+func (u *Universe) Classify(symbol string) (sector, industry string) {
+	m, _ := u.Member(symbol)
+	return m.Sector, m.Industry
+}
+
+// Groups returns the members grouped by sector, in order of first appearance.
+// Untagged members are collected in OtherGroup at the end.
+func (u *Universe) Groups() []Group {
+	var groups []Group
+	index := make(map[string]int)
+	var other []string
+
+	for _, m := range u.Members {
+		if m.Sector == "" {
+			other = append(other, m.Symbol)
+			continue
+		}
+		i, ok := index[m.Sector]
+		if !ok {
+			i = len(groups)
+			index[m.Sector] = i
+			groups = append(groups, Group{Name: m.Sector})
+		}
+		groups[i].Symbols = append(groups[i].Symbols, m.Symbol)
+	}
+
+	if len(other) > 0 {
+		groups = append(groups, Group{Name: OtherGroup, Symbols: other})
+	}
+	return groups
+}
+
+// Add appends members, replacing the tags of symbols already present.
+// It returns the number of new symbols.
+func (u *Universe) Add(members ...Member) int {
+	added := 0
+	for _, m := range members {
+		m = normalize(m)
+		if m.Symbol == "" {
+			continue
+		}
+
+		found := false
+		for i := range u.Members {
+			if u.Members[i].Symbol == m.Symbol {
+				if m.Sector != "" {
+					u.Members[i].Sector = m.Sector
+				}
+				if m.Industry != "" {
+					u.Members[i].Industry = m.Industry
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			u.Members = append(u.Members, m)
+			added++
+		}
+	}
+	return added
+}
+
+// normalize trims tags and upper-cases the symbol
+func normalize(m Member) Member {
+	return Member{
+		Symbol:   strings.ToUpper(strings.TrimSpace(m.Symbol)),
+		Sector:   strings.TrimSpace(m.Sector),
+		Industry: strings.TrimSpace(m.Industry),
+	}
+}
+
+// Dir returns the directory holding user universe files
+func Dir() string {
+	return config.Path("universes")
+}
+
+// Key returns the lookup key of a universe name ("S&P 500" -> "s-p-500")
+func Key(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Parse reads a universe from YAML or CSV data; the format is taken from
+// the file name's extension. Names default to the file name.
+func Parse(filename string, data []byte) (*Universe, error) {
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+
+	var u *Universe
+	var err error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		u, err = parseYAML(data)
+	case ".csv":
+		u, err = parseCSV(data)
+	default:
+		return nil, fmt.Errorf("%s: unsupported universe format (use .yaml or .csv)", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	if u.Name == "" {
+		u.Name = base
+	}
+
+	members := u.Members
+	u.Members = nil
+	u.Add(members...)
+	if len(u.Members) == 0 {
+		return nil, fmt.Errorf("%s: universe has no symbols", filename)
+	}
+	return u, nil
+}
+
+// parseYAML reads a universe in YAML form
+func parseYAML(data []byte) (*Universe, error) {
+	var u Universe
+	if err := yaml.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// parseCSV reads a universe in CSV form: a header with a "symbol" column and
+// optional "sector" and "industry" columns, or a single column of symbols.
+// Lines starting with # are comments; "# name: X" sets the name.
+func parseCSV(data []byte) (*Universe, error) {
+	u := &Universe{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if v, ok := strings.CutPrefix(line, "# name:"); ok {
+			u.Name = strings.TrimSpace(v)
+		} else if v, ok := strings.CutPrefix(line, "# description:"); ok {
+			u.Description = strings.TrimSpace(v)
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	cols := map[string]int{"symbol": 0, "sector": -1, "industry": -1}
+	header := true
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header {
+			header = false
+			if isHeader(rec) {
+				cols["symbol"] = -1
+				for i, name := range rec {
+					key := strings.ToLower(strings.TrimSpace(name))
+					if key == "ticker" {
+						key = "symbol"
+					}
+					if _, ok := cols[key]; ok {
+						cols[key] = i
+					}
+				}
+				if cols["symbol"] < 0 {
+					return nil, errors.New(`CSV header has no "symbol" column`)
+				}
+				continue
+			}
+		}
+
+		field := func(name string) string {
+			if i := cols[name]; i >= 0 && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+		u.Members = append(u.Members, Member{Symbol: field("symbol"), Sector: field("sector"), Industry: field("industry")})
+	}
+	return u, nil
+}
+
+// isHeader reports whether a CSV record is a header row
+func isHeader(rec []string) bool {
+	for _, f := range rec {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "symbol", "ticker", "sector", "industry":
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFile reads a universe file
+func LoadFile(path string) (*Universe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	u, err := Parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	u.Source = path
+	return u, nil
+}
+
+// List returns all universes: user files from Dir() and the builtin ones.
+// A user file overrides a builtin universe of the same name.
+// Files that fail to parse are returned as errors next to the valid universes.
+func List() ([]*Universe, []error) {
+	byKey := make(map[string]*Universe)
+	for _, u := range Builtin() {
+		byKey[Key(u.Name)] = u
+	}
+
+	var errs []error
+	entries, err := os.ReadDir(Dir())
+	if err != nil && !os.IsNotExist(err) {
+		errs = append(errs, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".csv":
+		default:
+			continue
+		}
+		u, err := LoadFile(filepath.Join(Dir(), e.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		byKey[Key(u.Name)] = u
+	}
+
+	list := make([]*Universe, 0, len(byKey))
+	for _, u := range byKey {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		// The default universe first, then by name
+		ki, kj := Key(list[i].Name), Key(list[j].Name)
+		if (ki == DefaultName) != (kj == DefaultName) {
+			return ki == DefaultName
+		}
+		return ki < kj
+	})
+	return list, errs
+}
+
+// Load returns the universe with the given name (case and punctuation insensitive)
+func Load(name string) (*Universe, error) {
+	key := Key(name)
+	list, _ := List()
+	for _, u := range list {
+		if Key(u.Name) == key {
+			return u, nil
+		}
+	}
+
+	names := make([]string, len(list))
+	for i, u := range list {
+		names[i] = Key(u.Name)
+	}
+	return nil, fmt.Errorf("unknown universe %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Active returns the universe named in settings.json, falling back to the
+// default universe if it is unset or can't be loaded
+func Active() *Universe {
+	if s, err := config.Load(); err == nil && s.Scan.Universe != "" {
+		if u, err := Load(s.Scan.Universe); err == nil {
+			return u
+		}
+	}
+	return Default()
+}
+
+// Save writes a universe as YAML to Dir() and returns the file path.
+// A universe loaded from a YAML file in Dir() is written back to that file;
+// one loaded from a CSV file there is converted and the CSV removed.
+func Save(u *Universe) (string, error) {
+	if Key(u.Name) == "" {
+		return "", errors.New("universe needs a name")
+	}
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(u); err != nil {
+		return "", err
+	}
+	data := buf.Bytes()
+
+	path := filepath.Join(Dir(), Key(u.Name)+".yaml")
+	var old string
+	if u.Source != "" && filepath.Dir(u.Source) == Dir() {
+		switch strings.ToLower(filepath.Ext(u.Source)) {
+		case ".yaml", ".yml":
+			path = u.Source
+		default:
+			old = u.Source
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	if old != "" && old != path {
+		os.Remove(old)
+	}
+	u.Source = path
+	return path, nil
+}
@@ -0,0 +1,137 @@
+package universe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	want := map[string]int{"sp500": 500, "nasdaq100": 101, "dow": 30}
+
+	found := 0
+	for _, u := range Builtin() {
+		if n, ok := want[u.Name]; ok {
+			found++
+			if len(u.Members) != n {
+				t.Errorf("%s: expected %d symbols, got %d", u.Name, n, len(u.Members))
+			}
+			for _, m := range u.Members {
+				if m.Sector == "" {
+					t.Errorf("%s: %s has no sector", u.Name, m.Symbol)
+				}
+			}
+		}
+	}
+	if found != len(want) {
+		t.Errorf("Expected %d builtin index universes, found %d", len(want), found)
+	}
+
+	def := Default()
+	if len(def.Members) < 100 || len(def.Groups()) < 10 {
+		t.Errorf("Default universe too small: %d symbols, %d groups", len(def.Members), len(def.Groups()))
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	data := []byte(`# name: My Banks
+# description: Regional banks
+Ticker,Industry,Sector
+fitb, Regional Banks, Financials
+KEY,Regional Banks,Financials
+FITB,,
+HBAN
+`)
+	u, err := Parse("banks.csv", data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if u.Name != "My Banks" || u.Description != "Regional banks" {
+		t.Errorf("Unexpected name/description %q / %q", u.Name, u.Description)
+	}
+	if len(u.Members) != 3 {
+		t.Fatalf("Expected 3 symbols (duplicate merged), got %v", u.Members)
+	}
+	if m, _ := u.Member("FITB"); m.Sector != "Financials" || m.Industry != "Regional Banks" {
+		t.Errorf("Unexpected member %+v", m)
+	}
+	if sector, industry := u.Classify("fitb"); sector != "Financials" || industry != "Regional Banks" {
+		t.Errorf("Classify(fitb) = %q, %q", sector, industry)
+	}
+	if sector, _ := u.Classify("NONE"); sector != "" {
+		t.Errorf("Expected no sector for a non-member, got %q", sector)
+	}
+
+	groups := u.Groups()
+	if len(groups) != 2 || groups[1].Name != OtherGroup || groups[1].Symbols[0] != "HBAN" {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+
+	// Plain list without header; name from the file
+	u, err = Parse("/tmp/picks.csv", []byte("AAPL\nMSFT\n"))
+	if err != nil || u.Name != "picks" || len(u.Members) != 2 {
+		t.Errorf("Unexpected plain CSV result %+v, %v", u, err)
+	}
+
+	if _, err := Parse("empty.csv", []byte("symbol,sector\n")); err == nil {
+		t.Error("Expected an error for an empty universe")
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`name: chips
+symbols:
+  - NVDA
+  - {symbol: amd, sector: Technology, industry: Semiconductors}
+`)
+	u, err := Parse("chips.yaml", data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(u.Members) != 2 || u.Members[1].Symbol != "AMD" || u.Members[1].Industry != "Semiconductors" {
+		t.Errorf("Unexpected members %+v", u.Members)
+	}
+
+	if _, err := Parse("chips.txt", data); err == nil {
+		t.Error("Expected an error for an unknown extension")
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"S&P 500":    "s-p-500",
+		" My Banks ": "my-banks",
+		"nasdaq100":  "nasdaq100",
+	}
+	for in, want := range tests {
+		if got := Key(in); got != want {
+			t.Errorf("Key(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSaveOverridesBuiltin(t *testing.T) {
+	cwd, _ := os.Getwd()
+	defer os.RemoveAll(filepath.Join(cwd, "config"))
+
+	dow, err := Load("DOW")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	dow.Add(Member{Symbol: "nvda", Sector: "Information Technology"})
+	if _, err := Save(dow); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := Load("dow")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(reloaded.Members) != 31 || reloaded.Source == "builtin" {
+		t.Errorf("Expected the saved copy to override the builtin, got %d symbols from %s", len(reloaded.Members), reloaded.Source)
+	}
+
+	if _, err := Load("no-such-universe"); err == nil {
+		t.Error("Expected an error for an unknown universe")
+	}
+}